@@ -26,6 +26,10 @@ interface DashboardStats {
   clicks_by_source: { source: string; count: number }[]
   clicks_by_platform: { source: string; count: number }[]
   clicks_by_category: { source: string; count: number }[]
+  clicks_by_country?: { country: string; count: number }[]
+  clicks_by_device?: { source: string; count: number }[]
+  clicks_by_os?: { source: string; count: number }[]
+  clicks_by_browser?: { source: string; count: number }[]
   daily_clicks: { date: string; count: number }[]
   estimated_revenue?: number
 }
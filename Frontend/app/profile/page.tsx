@@ -35,6 +35,7 @@ export default function ProfilePage() {
   const [showPasswordConfirm, setShowPasswordConfirm] = useState(false)
   
   const [originalData, setOriginalData] = useState({ username: "", email: "" })
+  const [emailPassword, setEmailPassword] = useState("")
   
   const [formData, setFormData] = useState({
     username: "",
@@ -82,6 +83,11 @@ export default function ProfilePage() {
   }
 
   const confirmSaveProfile = async () => {
+    const emailChanged = formData.email !== originalData.email
+    if (emailChanged && !emailPassword) {
+      return
+    }
+
     setShowProfileConfirm(false)
     setSaving(true)
     setProfileMessage({ type: "", text: "" })
@@ -97,6 +103,7 @@ export default function ProfilePage() {
         body: JSON.stringify({
           username: formData.username,
           email: formData.email,
+          ...(emailChanged && { current_password: emailPassword }),
         }),
       })
 
@@ -106,20 +113,27 @@ export default function ProfilePage() {
         throw new Error(data.error || "Failed to update profile")
       }
 
-      // Update localStorage
+      // Update localStorage; a new email only applies once confirmed
       const userData = localStorage.getItem("user")
       if (userData) {
         const user = JSON.parse(userData)
-        user.username = formData.username
-        user.email = formData.email
+        user.username = data.username
+        user.email = data.email
         localStorage.setItem("user", JSON.stringify(user))
       }
       
-      setOriginalData({ username: formData.username, email: formData.email })
-      setProfileMessage({ type: "success", text: "Profil berhasil diperbarui!" })
+      setOriginalData({ username: data.username, email: data.email })
+      setFormData(prev => ({ ...prev, username: data.username, email: data.email }))
+      setProfileMessage({
+        type: "success",
+        text: emailChanged
+          ? `Profil berhasil diperbarui! Buka link yang kami kirim ke ${formData.email} untuk mengganti email.`
+          : "Profil berhasil diperbarui!",
+      })
     } catch (err) {
       setProfileMessage({ type: "error", text: err instanceof Error ? err.message : "Gagal memperbarui profil" })
     } finally {
+      setEmailPassword("")
       setSaving(false)
     }
   }
@@ -411,12 +425,28 @@ export default function ProfilePage() {
                 ⚠️ Email digunakan untuk login. Pastikan email baru valid.
               </p>
             )}
+            {formData.email !== originalData.email && (
+              <div>
+                <label className="text-sm font-medium text-muted-foreground">Password saat ini</label>
+                <Input
+                  type="password"
+                  value={emailPassword}
+                  onChange={(e) => setEmailPassword(e.target.value)}
+                  className="mt-1 h-11 bg-gray-100 border-0"
+                  placeholder="Masukkan password untuk mengganti email"
+                />
+              </div>
+            )}
           </div>
           <DialogFooter className="flex gap-2 sm:gap-0">
             <Button variant="outline" onClick={() => setShowProfileConfirm(false)}>
               Batal
             </Button>
-            <Button onClick={confirmSaveProfile} className="bg-[#4A7DFF] hover:bg-[#3a6dee]">
+            <Button
+              onClick={confirmSaveProfile}
+              disabled={formData.email !== originalData.email && !emailPassword}
+              className="bg-[#4A7DFF] hover:bg-[#3a6dee]"
+            >
               Ya, Ubah Profil
             </Button>
           </DialogFooter>
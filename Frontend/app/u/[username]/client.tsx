@@ -13,7 +13,18 @@ import Footer from "./components/Footer"
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:3001'
 
-export default function PublicProfileClient({ profile }: { profile: Profile }) {
+// visitorId and pageViewId come from the API response the page was rendered
+// from: the browser cannot see that response's visitor cookie, and the page
+// view it may already have counted must not be counted again by the beacon
+export default function PublicProfileClient({
+  profile,
+  visitorId: renderedVisitorId,
+  pageViewId,
+}: {
+  profile: Profile
+  visitorId?: string
+  pageViewId?: string
+}) {
   const [mounted, setMounted] = useState(false)
   const [activeCategory, setActiveCategory] = useState("all")
   const [searchQuery, setSearchQuery] = useState("")
@@ -32,7 +43,7 @@ export default function PublicProfileClient({ profile }: { profile: Profile }) {
     const urlParams = new URLSearchParams(window.location.search)
     const utmSource = urlParams.get('utm_source') || 'direct'
     setSource(utmSource)
-    const currentVisitorId = getVisitorId()
+    const currentVisitorId = renderedVisitorId || getVisitorId()
     setVisitorId(currentVisitorId)
 
     // Check if logged-in user is viewing their own profile
@@ -59,12 +70,14 @@ export default function PublicProfileClient({ profile }: { profile: Profile }) {
       }
     }
 
-    // Track page view only if NOT viewing own profile
-    // Backend will also deduplicate within 1 hour
+    // Track page view only if NOT viewing own profile. The beacon also sets
+    // the visitor cookie; a view already counted on navigation is recognised
+    // by its page_view_id and the backend deduplicates the rest
     if (profile.userId && !isOwnProfile) {
       const trackData = {
         user_id: profile.userId,
         visitor_id: currentVisitorId,
+        page_view_id: pageViewId || "",
         source: utmSource
       }
       const blob = new Blob([JSON.stringify(trackData)], { type: 'application/json' })
@@ -76,21 +89,19 @@ export default function PublicProfileClient({ profile }: { profile: Profile }) {
     } else if (process.env.NODE_ENV === 'development') {
       console.log('⏭️  Pageview skipped - viewing own profile')
     }
-  }, [profile.userId, profile.username])
-
-  // Track product click - non-blocking
-  const trackProductClick = (link: Product) => {
-    if (!profile.userId || !link.id) return
-    const trackData = {
-      link_id: link.id,
-      user_id: profile.userId,
-      visitor_id: visitorId,
-      source: source,
-      platform: link.platform || '',
-      category: link.category || ''
+  }, [profile.userId, profile.username, renderedVisitorId, pageViewId])
+
+  // Product URLs are signed redirects that record the click server-side;
+  // pass along the visitor and source so the click is attributed and deduplicated
+  const productHref = (link: Product) => {
+    try {
+      const href = new URL(link.url)
+      href.searchParams.set('utm_source', source)
+      if (visitorId) href.searchParams.set('vid', visitorId)
+      return href.toString()
+    } catch {
+      return link.url
     }
-    const blob = new Blob([JSON.stringify(trackData)], { type: 'application/json' })
-    navigator.sendBeacon?.(`${API_URL}/api/analytics/track`, blob)
   }
 
   // Track social click - non-blocking
@@ -168,7 +179,7 @@ export default function PublicProfileClient({ profile }: { profile: Profile }) {
               theme={theme}
               mounted={mounted}
               index={index}
-              trackProductClick={trackProductClick}
+              href={productHref(product)}
             />
           ))}
         </div>
@@ -9,16 +9,15 @@ type ProductCardProps = {
   theme: any
   mounted: boolean
   index: number
-  trackProductClick: (product: Product) => void
+  href: string
 }
 
-export default function ProductCard({ product, theme, mounted, index, trackProductClick }: ProductCardProps) {
+export default function ProductCard({ product, theme, mounted, index, href }: ProductCardProps) {
   return (
     <a
-      href={product.url}
+      href={href}
       target="_blank"
       rel="noopener noreferrer"
-      onClick={() => trackProductClick(product)}
       className={`group block transition-all duration-500 ${
         mounted ? "opacity-100 translate-y-0" : "opacity-0 translate-y-8"
       }`}
@@ -1,5 +1,7 @@
 import type { Metadata } from "next"
+import { cookies, headers } from "next/headers"
 import { notFound } from "next/navigation"
+import { cache } from "react"
 import PublicProfileClient from "./client"
 
 const API_URL = process.env.NEXT_PUBLIC_API_URL || "http://localhost:3001"
@@ -40,30 +42,82 @@ interface ProfileData {
   }
 }
 
-async function getProfile(username: string): Promise<ProfileData | null> {
+interface ProfileResult {
+  profile: ProfileData
+  visitorId: string
+  pageViewId: string
+}
+
+// Visitor headers passed on to the API, which counts the page view when the
+// visitor's browser navigated here and tells bots apart
+const FORWARDED_HEADERS = [
+  "user-agent",
+  "accept-language",
+  "referer",
+  "sec-ch-ua",
+  "sec-fetch-mode",
+  "sec-fetch-dest",
+  "sec-purpose",
+  "purpose",
+  // names the app of Android in-app browsers (WhatsApp, Instagram, ...)
+  "x-requested-with",
+  // coarse visitor location set by the CDN in front of the frontend
+  "cf-ipcountry",
+  "x-vercel-ip-country",
+  "x-vercel-ip-country-region",
+  "cloudfront-viewer-country",
+  "cloudfront-viewer-country-region",
+]
+
+// Cached per request so metadata and page share one fetch (and one page view)
+const getProfile = cache(async (username: string, source: string): Promise<ProfileResult | null> => {
   try {
-    const res = await fetch(`${API_URL}/api/u/${username}`, {
+    const incoming = await headers()
+    const forwarded = new Headers()
+    for (const name of FORWARDED_HEADERS) {
+      const value = incoming.get(name)
+      if (value) forwarded.set(name, value)
+    }
+    const forwardedFor = incoming.get("x-forwarded-for") || incoming.get("x-real-ip")
+    if (forwardedFor) forwarded.set("x-forwarded-for", forwardedFor)
+    // Readable here when TRACKING_VISITOR_COOKIE_DOMAIN covers the frontend
+    const visitorId = (await cookies()).get("od_vid")?.value
+    if (visitorId) forwarded.set("x-visitor-id", visitorId)
+
+    const query = source ? `?utm_source=${encodeURIComponent(source)}` : ""
+    const res = await fetch(`${API_URL}/api/u/${username}${query}`, {
       cache: "no-store",
+      headers: forwarded,
     })
     
     if (!res.ok) {
       return null
     }
     
-    return res.json()
+    return {
+      profile: await res.json(),
+      visitorId: res.headers.get("x-visitor-id") || "",
+      pageViewId: res.headers.get("x-page-view-id") || "",
+    }
   } catch (error) {
     console.error("Failed to fetch profile:", error)
     return null
   }
-}
+})
 
-export async function generateMetadata({
-  params,
-}: {
+type PageProps = {
   params: Promise<{ username: string }>
-}): Promise<Metadata> {
+  searchParams: Promise<{ utm_source?: string | string[] }>
+}
+
+async function sourceOf(searchParams: PageProps["searchParams"]): Promise<string> {
+  const { utm_source } = await searchParams
+  return (Array.isArray(utm_source) ? utm_source[0] : utm_source) || "direct"
+}
+
+export async function generateMetadata({ params, searchParams }: PageProps): Promise<Metadata> {
   const { username } = await params
-  const profile = await getProfile(username)
+  const profile = (await getProfile(username, await sourceOf(searchParams)))?.profile
   
   if (profile) {
     return {
@@ -78,19 +132,16 @@ export async function generateMetadata({
   }
 }
 
-export default async function PublicProfilePage({
-  params,
-}: {
-  params: Promise<{ username: string }>
-}) {
+export default async function PublicProfilePage({ params, searchParams }: PageProps) {
   const { username } = await params
   
   // Fetch from API
-  const apiProfile = await getProfile(username)
+  const result = await getProfile(username, await sourceOf(searchParams))
   
-  if (!apiProfile) {
+  if (!result) {
     notFound()
   }
+  const apiProfile = result.profile
   
   // Transform API response to match client component props
   const profile = {
@@ -125,6 +176,6 @@ export default async function PublicProfilePage({
     stats: apiProfile.stats,
   }
   
-  return <PublicProfileClient profile={profile} />
+  return <PublicProfileClient profile={profile} visitorId={result.visitorId} pageViewId={result.pageViewId} />
 }
 
@@ -1,17 +1,31 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
+	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/joho/godotenv"
 
 	"github.com/onedash/backend/config"
+	"github.com/onedash/backend/internal/botdetect"
+	"github.com/onedash/backend/internal/geoip"
 	"github.com/onedash/backend/internal/handlers"
+	"github.com/onedash/backend/internal/ingest"
+	"github.com/onedash/backend/internal/iphash"
+	"github.com/onedash/backend/internal/mailer"
 	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/migrations"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/orderreport"
 	"github.com/onedash/backend/internal/repository"
 	"github.com/onedash/backend/internal/services"
 	"github.com/onedash/backend/internal/services/scraper"
@@ -23,12 +37,71 @@ func main() {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Load and validate configuration
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load configuration: %v", err)
+	}
+
 	// Initialize database
-	db, err := config.InitDB()
+	db, err := config.InitDB(cfg)
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
 
+	// Schema migrations
+	migrator, err := migrations.New(db)
+	if err != nil {
+		log.Fatalf("Failed to load migrations: %v", err)
+	}
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := runMigrate(migrator, os.Args[2:]); err != nil {
+			log.Fatalf("Migration failed: %v", err)
+		}
+		return
+	}
+	if cfg.Database.AutoMigrate {
+		applied, err := migrator.Up()
+		if err != nil {
+			log.Fatalf("Failed to apply migrations: %v", err)
+		}
+		for _, m := range applied {
+			log.Printf("✅ Applied migration %04d_%s", m.Version, m.Name)
+		}
+	}
+	if err := migrator.EnsureCurrent(); err != nil {
+		log.Fatalf("Refusing to start: %v (run `migrate up`)", err)
+	}
+
+	// One-off command: promote an existing account to admin
+	if len(os.Args) > 1 && os.Args[1] == "make-admin" {
+		if len(os.Args) != 3 {
+			log.Fatal("Usage: make-admin <email>")
+		}
+		if err := makeAdmin(repository.NewUserRepository(db), os.Args[2]); err != nil {
+			log.Fatalf("Failed to promote user: %v", err)
+		}
+		return
+	}
+
+	// One-off command: roll up pending days, or rebuild the rollups from history
+	if len(os.Args) > 1 && os.Args[1] == "rollup" {
+		rollups := services.NewRollupService(repository.NewAnalyticsRepository(db))
+		if err := runRollup(rollups, os.Args[2:]); err != nil {
+			log.Fatalf("Rollup failed: %v", err)
+		}
+		return
+	}
+
+	// One-off command: classify the user agents of events stored before they were parsed at ingest
+	if len(os.Args) > 1 && os.Args[1] == "useragent" {
+		analyticsRepo := repository.NewAnalyticsRepository(db)
+		if err := runUserAgent(analyticsRepo, services.NewRollupService(analyticsRepo), os.Args[2:]); err != nil {
+			log.Fatalf("User agent backfill failed: %v", err)
+		}
+		return
+	}
+
 	// Ensure upload directories exist
 	if err := os.MkdirAll("./uploads/avatars", 0755); err != nil {
 		log.Printf("⚠️  Warning: Failed to create avatars directory: %v", err)
@@ -41,30 +114,131 @@ func main() {
 		log.Println("✅ Banners directory ready")
 	}
 
+	// Initialize mailer
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+
+	// Visitor location; without a GeoIP database only proxy headers are used
+	var geo *geoip.DB
+	if cfg.Tracking.GeoIPDatabase != "" {
+		geo, err = geoip.Open(cfg.Tracking.GeoIPDatabase)
+		if err != nil {
+			log.Fatalf("Failed to open GeoIP database: %v", err)
+		}
+		defer geo.Close()
+	}
+
 	// Initialize repositories
 	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+	sessionRepo := repository.NewSessionRepository(db)
+	userTokenRepo := repository.NewUserTokenRepository(db)
+	loginAttemptRepo := repository.NewLoginAttemptRepository(db)
+	recoveryCodeRepo := repository.NewRecoveryCodeRepository(db)
 	contactRepo := repository.NewContactRepository(db)
 	linkRepo := repository.NewLinkRepository(db)
 	analyticsRepo := repository.NewAnalyticsRepository(db)
+	commissionRateRepo := repository.NewCommissionRateRepository(db)
+	conversionRepo := repository.NewConversionRepository(db)
+	linkTaggingRepo := repository.NewLinkTaggingRepository(db)
+	categoryKeywordRepo := repository.NewCategoryKeywordRepository(db)
+	auditLogRepo := repository.NewAuditLogRepository(db)
+	trackingSettingsRepo := repository.NewTrackingSettingsRepository(db)
+	visitorSaltRepo := repository.NewVisitorSaltRepository(db)
 
 	// Initialize services
-	authService := services.NewAuthService(userRepo)
-	profileService := services.NewProfileService(userRepo)
+	sessionService := services.NewSessionService(sessionRepo, refreshTokenRepo)
+	emailVerificationService := services.NewEmailVerificationService(userRepo, userTokenRepo, mail, cfg.Server.FrontendURL)
+	loginAttemptService := services.NewLoginAttemptService(loginAttemptRepo)
+	twoFactorService := services.NewTwoFactorService(userRepo, recoveryCodeRepo)
+	authService := services.NewAuthService(
+		userRepo,
+		refreshTokenRepo,
+		sessionService,
+		emailVerificationService,
+		loginAttemptService,
+		twoFactorService,
+		cfg.Auth,
+	)
+	passwordResetService := services.NewPasswordResetService(userRepo, userTokenRepo, sessionService, mail, cfg.Server.FrontendURL)
+	profileService := services.NewProfileService(userRepo, sessionService, emailVerificationService)
 	contactService := services.NewContactService(contactRepo)
 	linkService := services.NewLinkService(linkRepo)
 	scraperService := scraper.NewService(db)
-	analyticsService := services.NewAnalyticsService(analyticsRepo, linkRepo)
+	trackingPipeline := ingest.New(analyticsRepo, ingest.Options{
+		QueueSize:     cfg.Tracking.QueueSize,
+		Workers:       cfg.Tracking.Workers,
+		BatchSize:     cfg.Tracking.BatchSize,
+		FlushInterval: cfg.Tracking.FlushInterval,
+		MaxRetries:    ingest.DefaultOptions.MaxRetries,
+	})
+	botFilter := services.BotFilter{
+		Classifier: botdetect.New(botdetect.Options{RateLimit: cfg.Tracking.BotRateLimit, RateWindow: time.Minute}),
+		Drop:       cfg.Tracking.BotPolicy == "drop",
+	}
+	trackingSettingsService := services.NewTrackingSettingsService(trackingSettingsRepo)
+	analyticsService := services.NewAnalyticsService(
+		analyticsRepo,
+		linkRepo,
+		trackingSettingsService,
+		trackingPipeline,
+		botFilter,
+		iphash.New(visitorSaltRepo),
+		geo,
+	)
+	retentionService := services.NewRetentionService(analyticsRepo, visitorSaltRepo, cfg.Tracking.RetentionDays)
+	rollupService := services.NewRollupService(analyticsRepo)
+	linkSigner := services.NewLinkSigner(cfg.Tracking.LinkSigningSecret, cfg.Server.PublicURL, cfg.Tracking.LinkSignatureTTL)
+	conversionService := services.NewConversionService(conversionRepo, analyticsRepo, linkRepo)
+	linkTaggingService := services.NewLinkTaggingService(linkTaggingRepo)
+	redirectService := services.NewRedirectService(linkRepo, userRepo, analyticsService, linkSigner, linkTaggingService)
+	adminService := services.NewAdminService(
+		userRepo,
+		commissionRateRepo,
+		categoryKeywordRepo,
+		auditLogRepo,
+		sessionService,
+		scraperService,
+	)
 
 	// Initialize handlers
 	authHandler := handlers.NewAuthHandler(authService)
+	sessionHandler := handlers.NewSessionHandler(sessionService)
+	passwordResetHandler := handlers.NewPasswordResetHandler(passwordResetService)
+	emailVerificationHandler := handlers.NewEmailVerificationHandler(emailVerificationService)
+	twoFactorHandler := handlers.NewTwoFactorHandler(twoFactorService)
 	profileHandler := handlers.NewProfileHandler(profileService)
 	contactHandler := handlers.NewContactHandler(contactService)
 	linkHandler := handlers.NewLinkHandler(linkService, scraperService)
-	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
-	publicHandler := handlers.NewPublicHandler(userRepo, linkRepo, contactRepo, analyticsRepo)
+	visitorCookie := handlers.VisitorCookie{
+		Domain: cfg.Tracking.VisitorCookieDomain,
+		Secure: strings.HasPrefix(cfg.Server.PublicURL, "https://"),
+	}
+	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService, visitorCookie)
+	conversionHandler := handlers.NewConversionHandler(conversionService)
+	trackingSettingsHandler := handlers.NewTrackingSettingsHandler(trackingSettingsService)
+	linkTaggingHandler := handlers.NewLinkTaggingHandler(linkTaggingService)
+	publicHandler := handlers.NewPublicHandler(userRepo, linkRepo, contactRepo, analyticsRepo, analyticsService, linkSigner, visitorCookie)
+	redirectHandler := handlers.NewRedirectHandler(redirectService, visitorCookie)
+	adminHandler := handlers.NewAdminHandler(adminService, trackingPipeline)
 
 	// Create Fiber app
+	// Visitor IPs come from X-Forwarded-For, but only when a trusted proxy sent it
+	var proxyHeader string
+	if len(cfg.Server.TrustedProxies) > 0 {
+		proxyHeader = fiber.HeaderXForwardedFor
+	}
 	app := fiber.New(fiber.Config{
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.Server.TrustedProxies,
+		ProxyHeader:             proxyHeader,
+		EnableIPValidation:      true,
+		// Bodies over the default limit are streamed and left to middleware.BodyLimit,
+		// so only the report import accepts more; multipart forms are parsed as they are read
+		StreamRequestBody:            true,
+		DisablePreParseMultipartForm: true,
 		ErrorHandler: func(c *fiber.Ctx, err error) error {
 			code := fiber.StatusInternalServerError
 			if e, ok := err.(*fiber.Error); ok {
@@ -77,14 +251,23 @@ func main() {
 	})
 
 	// Middleware
+	// A panic in one handler answers 500 instead of crashing the server
+	app.Use(recover.New(recover.Config{EnableStackTrace: true}))
 	app.Use(logger.New())
+	// Affiliate reports are the only large uploads
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, map[string]int{
+		"/api/conversions/import": orderreport.MaxSize + 1<<20,
+	}))
 	app.Use(cors.New(cors.Config{
-		AllowOrigins:     os.Getenv("CORS_ORIGINS"),
+		AllowOrigins:     cfg.Server.CORSOrigins,
 		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
 		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS",
 		AllowCredentials: true,
 	}))
 
+	// Product link redirects (public, recorded server-side)
+	app.Get("/go/:linkID", redirectHandler.Redirect)
+
 	// API routes
 	api := app.Group("/api")
 
@@ -92,22 +275,38 @@ func main() {
 	auth := api.Group("/auth")
 	auth.Post("/register", authHandler.Register)
 	auth.Post("/login", authHandler.Login)
+	auth.Post("/2fa/verify", authHandler.VerifyTwoFactor)
 	auth.Post("/refresh", authHandler.RefreshToken)
+	auth.Post("/password/forgot", passwordResetHandler.RequestReset)
+	auth.Post("/password/reset", passwordResetHandler.ResetPassword)
+	auth.Post("/email/verify", emailVerificationHandler.VerifyEmail)
 
 	// Public routes (no auth required) - MUST be before protected routes
 	api.Get("/u/:username", publicHandler.GetPublicProfile)
-	api.Post("/analytics/track", analyticsHandler.TrackClick)
 	api.Post("/analytics/social", analyticsHandler.TrackSocialClick)
 	api.Post("/analytics/pageview", analyticsHandler.TrackPageView)
 
 	// Protected routes
-	protected := api.Group("/", middleware.AuthMiddleware())
+	protected := api.Group("/", middleware.AuthMiddleware(authService))
+
+	// Sensitive actions that require a confirmed email address
+	requireVerifiedEmail := middleware.RequireVerifiedEmail(userRepo)
 
 	// Profile routes
 	protected.Get("profile", profileHandler.GetProfile)
 	protected.Put("profile", profileHandler.UpdateProfile)
 	protected.Post("profile/avatar", profileHandler.UploadAvatar)
 	protected.Post("profile/banner", profileHandler.UploadBanner)
+	protected.Post("profile/email/resend", emailVerificationHandler.ResendVerification)
+
+	// Session routes
+	protected.Get("sessions", sessionHandler.GetSessions)
+	protected.Delete("sessions", sessionHandler.RevokeAllSessions)
+	protected.Delete("sessions/:id", sessionHandler.RevokeSession)
+	protected.Get("security/login-attempts", authHandler.GetLoginAttempts)
+	protected.Post("security/2fa/setup", twoFactorHandler.Setup)
+	protected.Post("security/2fa/enable", twoFactorHandler.Enable)
+	protected.Post("security/2fa/disable", twoFactorHandler.Disable)
 
 	// Contacts routes
 	protected.Get("contacts", contactHandler.GetContacts)
@@ -117,8 +316,8 @@ func main() {
 
 	// Links routes
 	protected.Get("links", linkHandler.GetLinks)
-	protected.Post("links", linkHandler.CreateLink)
-	protected.Put("links/:id", linkHandler.UpdateLink)
+	protected.Post("links", requireVerifiedEmail, linkHandler.CreateLink)
+	protected.Put("links/:id", requireVerifiedEmail, linkHandler.UpdateLink)
 	protected.Delete("links/:id", linkHandler.DeleteLink)
 	protected.Post("links/reorder", linkHandler.ReorderLinks)
 	protected.Post("links/scrape", linkHandler.ScrapeProduct)
@@ -128,6 +327,33 @@ func main() {
 	protected.Get("analytics/clicks", analyticsHandler.GetClicks)
 	protected.Get("analytics/dashboard", analyticsHandler.GetDashboardStats)
 	protected.Get("analytics/timeline", analyticsHandler.GetTimelineChart)
+	protected.Get("analytics/geo", analyticsHandler.GetGeoBreakdown)
+	protected.Get("analytics/revenue", analyticsHandler.GetRevenue)
+	protected.Get("analytics/commission", conversionHandler.GetCommissionReport)
+	protected.Post("conversions/import", conversionHandler.ImportReport)
+	protected.Get("conversions/imports", conversionHandler.GetImports)
+	protected.Get("settings/tracking", trackingSettingsHandler.GetSettings)
+	protected.Put("settings/tracking", trackingSettingsHandler.UpdateSettings)
+	protected.Get("settings/link-tagging", linkTaggingHandler.GetSettings)
+	protected.Put("settings/link-tagging", linkTaggingHandler.UpdateSettings)
+
+	// Admin routes
+	admin := protected.Group("admin", middleware.RequireAdmin(userRepo))
+	admin.Get("/users", adminHandler.SearchUsers)
+	admin.Get("/users/:id", adminHandler.GetUser)
+	admin.Post("/users/:id/suspend", adminHandler.SuspendUser)
+	admin.Post("/users/:id/unsuspend", adminHandler.UnsuspendUser)
+	admin.Put("/users/:id/verified", adminHandler.SetVerified)
+	admin.Get("/commission-rates", adminHandler.GetCommissionRates)
+	admin.Post("/commission-rates", adminHandler.CreateCommissionRate)
+	admin.Put("/commission-rates/:id", adminHandler.UpdateCommissionRate)
+	admin.Delete("/commission-rates/:id", adminHandler.DeleteCommissionRate)
+	admin.Get("/category-keywords", adminHandler.GetCategoryKeywords)
+	admin.Post("/category-keywords", adminHandler.CreateCategoryKeyword)
+	admin.Put("/category-keywords/:id", adminHandler.UpdateCategoryKeyword)
+	admin.Delete("/category-keywords/:id", adminHandler.DeleteCategoryKeyword)
+	admin.Get("/audit-logs", adminHandler.GetAuditLogs)
+	admin.Get("/tracking/stats", adminHandler.GetTrackingStats)
 
 	// Health check
 	app.Get("/health", func(c *fiber.Ctx) error {
@@ -137,14 +363,51 @@ func main() {
 	// Static file serving for uploads
 	app.Static("/uploads", "./uploads")
 
+	// Background jobs stop with the server
+	jobs, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	retentionService.Start(jobs, cfg.Tracking.RetentionInterval)
+	rollupService.Start(jobs, cfg.Tracking.RollupInterval)
+
 	// Start server
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3001"
+	go func() {
+		log.Printf("🚀 Server starting on port %s (%s)", cfg.Server.Port, cfg.Environment)
+		if err := app.Listen(":" + cfg.Server.Port); err != nil {
+			log.Fatalf("Failed to start server: %v", err)
+		}
+	}()
+
+	// Graceful shutdown: stop taking requests, then write queued tracking events
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
+	<-quit
+
+	log.Println("⏳ Shutting down...")
+	stopJobs()
+	if err := app.ShutdownWithTimeout(cfg.Tracking.DrainTimeout); err != nil {
+		log.Printf("⚠️  Server shutdown: %v", err)
 	}
+	// Reset links requested just before the shutdown are still sent
+	passwordResetService.Wait()
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Tracking.DrainTimeout)
+	defer cancel()
+	if err := trackingPipeline.Shutdown(ctx); err != nil {
+		log.Printf("⚠️  Tracking queue not fully drained: %v", err)
+	}
+	stats := trackingPipeline.Stats()
+	log.Printf("✅ Tracking queue drained (%d written, %d dropped, %d failed)", stats.Written, stats.Dropped, stats.Failed)
+}
 
-	log.Printf("🚀 Server starting on port %s", port)
-	if err := app.Listen(":" + port); err != nil {
-		log.Fatalf("Failed to start server: %v", err)
+// makeAdmin grants the admin role to the account with the given email
+func makeAdmin(userRepo *repository.UserRepository, email string) error {
+	user, err := userRepo.FindByEmail(email)
+	if err != nil {
+		return err
+	}
+	user.Role = models.RoleAdmin
+	if err := userRepo.Update(user); err != nil {
+		return err
 	}
+	log.Printf("✅ %s (%s) is now an admin", user.Username, user.Email)
+	return nil
 }
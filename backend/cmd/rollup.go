@@ -0,0 +1,33 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/onedash/backend/internal/services"
+)
+
+const rollupUsage = "Usage: rollup run | backfill"
+
+// runRollup implements the `rollup` subcommand
+func runRollup(rollups *services.RollupService, args []string) error {
+	if len(args) != 1 {
+		return errors.New(rollupUsage)
+	}
+
+	switch args[0] {
+	case "run":
+		days, err := rollups.Run(time.Now())
+		log.Printf("📊 Rolled up %d day(s)", days)
+		return err
+
+	case "backfill":
+		days, err := rollups.Backfill(time.Now())
+		log.Printf("📊 Rebuilt the rollups of %d day(s)", days)
+		return err
+
+	default:
+		return errors.New(rollupUsage)
+	}
+}
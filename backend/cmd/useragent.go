@@ -0,0 +1,35 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+	"github.com/onedash/backend/internal/useragent"
+)
+
+const useragentUsage = "Usage: useragent backfill"
+
+// runUserAgent implements the `useragent` subcommand: events stored before
+// user agents were parsed at ingest get their device, OS and browser, then the
+// rollups are rebuilt so reports include them
+func runUserAgent(analyticsRepo *repository.AnalyticsRepository, rollups *services.RollupService, args []string) error {
+	if len(args) != 1 || args[0] != "backfill" {
+		return errors.New(useragentUsage)
+	}
+
+	updated, err := analyticsRepo.ClassifyUserAgents(func(ua string) repository.ClientFields {
+		client := useragent.Parse(ua, "")
+		return repository.ClientFields{DeviceType: client.DeviceType, OS: client.OS, Browser: client.Browser}
+	})
+	log.Printf("📱 Classified the user agents of %d event(s)", updated)
+	if err != nil {
+		return err
+	}
+
+	days, err := rollups.Backfill(time.Now())
+	log.Printf("📊 Rebuilt the rollups of %d day(s)", days)
+	return err
+}
@@ -0,0 +1,447 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultConfigFile is read when CONFIG_FILE is not set and the file exists
+const DefaultConfigFile = "config.yaml"
+
+// minJWTSecretLength is enforced in production (256-bit HMAC key)
+const minJWTSecretLength = 32
+
+// insecureJWTSecrets are placeholder values that must never sign production tokens
+var insecureJWTSecrets = []string{
+	"default-secret-key",
+	"your-super-secret-key-change-in-production",
+}
+
+// devJWTSecret is only used outside production when no secret is configured
+const devJWTSecret = "default-secret-key"
+
+// Config holds all application settings. It is loaded once at startup by Load.
+type Config struct {
+	Environment string         `yaml:"environment"`
+	Server      ServerConfig   `yaml:"server"`
+	Database    DatabaseConfig `yaml:"database"`
+	Auth        AuthConfig     `yaml:"auth"`
+	Mail        MailConfig     `yaml:"mail"`
+	Tracking    TrackingConfig `yaml:"tracking"`
+}
+
+type ServerConfig struct {
+	Port        string `yaml:"port"`
+	CORSOrigins string `yaml:"cors_origins"`
+	FrontendURL string `yaml:"frontend_url"` // Base URL for links in emails
+	PublicURL   string `yaml:"public_url"`   // Base URL of this API, used for /go redirect links
+	// TrustedProxies are the addresses (IPs or CIDRs) of reverse proxies and the
+	// frontend's server renderer whose X-Forwarded-For header gives the visitor IP
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+type DatabaseConfig struct {
+	Driver string `yaml:"driver"` // "postgres" (default) or "sqlite"
+	Path   string `yaml:"path"`   // SQLite database file, ":memory:" for a throwaway database
+
+	Host     string `yaml:"host"`
+	Port     string `yaml:"port"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Name     string `yaml:"name"`
+	SSLMode  string `yaml:"sslmode"`
+
+	// AutoMigrate applies pending migrations at startup instead of refusing to boot
+	AutoMigrate bool `yaml:"auto_migrate"`
+}
+
+type AuthConfig struct {
+	JWTSecret          string        `yaml:"jwt_secret"`
+	JWTExpiry          time.Duration `yaml:"jwt_expiry"`
+	RefreshTokenExpiry time.Duration `yaml:"refresh_token_expiry"`
+}
+
+type MailConfig struct {
+	Driver       string `yaml:"driver"` // "log" or "smtp"
+	From         string `yaml:"from"`
+	LogDir       string `yaml:"log_dir"`
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     string `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+}
+
+type TrackingConfig struct {
+	// LinkSigningSecret signs the /go/:linkID redirect URLs; defaults to the JWT secret
+	LinkSigningSecret string `yaml:"link_signing_secret"`
+	// LinkSignatureTTL is how long a signed redirect URL is accepted after it was issued
+	LinkSignatureTTL time.Duration `yaml:"link_signature_ttl"`
+
+	// Events are written asynchronously in batches, see internal/ingest
+	QueueSize     int           `yaml:"queue_size"`
+	Workers       int           `yaml:"workers"`
+	BatchSize     int           `yaml:"batch_size"`
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	DrainTimeout  time.Duration `yaml:"drain_timeout"` // how long shutdown waits for queued events
+
+	// BotPolicy is "flag" (store bot events with is_bot set) or "drop"
+	BotPolicy string `yaml:"bot_policy"`
+	// BotRateLimit is the number of events per minute from one IP before the rest count as bots; 0 disables it
+	BotRateLimit int `yaml:"bot_rate_limit"`
+
+	// VisitorCookieDomain scopes the visitor cookie; set it to the parent domain
+	// when the frontend and this API run on different hosts of one site
+	VisitorCookieDomain string `yaml:"visitor_cookie_domain"`
+
+	// GeoIPDatabase is the path of a MaxMind-format (.mmdb) City or Country
+	// database used to locate visitors; empty disables the lookup
+	GeoIPDatabase string `yaml:"geoip_database"`
+
+	// RetentionDays is how long events keep visitor IDs, IP hashes, user agents
+	// and referers before the retention job anonymizes them; 0 keeps them
+	RetentionDays     int           `yaml:"retention_days"`
+	RetentionInterval time.Duration `yaml:"retention_interval"` // how often the retention job runs
+
+	// RollupInterval is how often closed days are added to the daily analytics rollups
+	RollupInterval time.Duration `yaml:"rollup_interval"`
+}
+
+// IsProduction reports whether the app runs with ENVIRONMENT=production
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production"
+}
+
+// Default returns the settings used when nothing is configured
+func Default() *Config {
+	return &Config{
+		Environment: "development",
+		Server: ServerConfig{
+			Port:        "3001",
+			CORSOrigins: "http://localhost:3000",
+			FrontendURL: "http://localhost:3000",
+			PublicURL:   "http://localhost:3001",
+		},
+		Database: DatabaseConfig{
+			Driver:  "postgres",
+			Path:    "onedash.db",
+			Host:    "localhost",
+			Port:    "5432",
+			SSLMode: "require",
+		},
+		Auth: AuthConfig{
+			JWTExpiry:          24 * time.Hour,
+			RefreshTokenExpiry: 30 * 24 * time.Hour,
+		},
+		Tracking: TrackingConfig{
+			LinkSignatureTTL: 24 * time.Hour,
+
+			QueueSize:     10000,
+			Workers:       4,
+			BatchSize:     200,
+			FlushInterval: time.Second,
+			DrainTimeout:  10 * time.Second,
+			BotPolicy:     "flag",
+			BotRateLimit:  120,
+
+			RetentionDays:     90,
+			RetentionInterval: time.Hour,
+			RollupInterval:    15 * time.Minute,
+		},
+		Mail: MailConfig{
+			Driver:   "log",
+			From:     "OneDash <no-reply@onedash.local>",
+			SMTPPort: "587",
+		},
+	}
+}
+
+// Load builds the configuration from defaults, an optional YAML file
+// (CONFIG_FILE, or config.yaml if present) and environment variables, in that
+// order of precedence, and validates the result.
+func Load() (*Config, error) {
+	cfg := Default()
+
+	path := os.Getenv("CONFIG_FILE")
+	if path == "" {
+		if _, err := os.Stat(DefaultConfigFile); err == nil {
+			path = DefaultConfigFile
+		}
+	}
+	if path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	setString(&c.Environment, "ENVIRONMENT")
+
+	setString(&c.Server.Port, "PORT")
+	setString(&c.Server.CORSOrigins, "CORS_ORIGINS")
+	setString(&c.Server.FrontendURL, "FRONTEND_URL")
+	setString(&c.Server.PublicURL, "PUBLIC_URL")
+	setList(&c.Server.TrustedProxies, "TRUSTED_PROXIES")
+
+	setString(&c.Database.Driver, "DB_DRIVER")
+	setString(&c.Database.Path, "DB_PATH")
+	setString(&c.Database.Host, "DB_HOST")
+	setString(&c.Database.Port, "DB_PORT")
+	setString(&c.Database.User, "DB_USER")
+	setString(&c.Database.Password, "DB_PASSWORD")
+	setString(&c.Database.Name, "DB_NAME")
+	setString(&c.Database.SSLMode, "DB_SSLMODE")
+	if err := setBool(&c.Database.AutoMigrate, "DB_AUTO_MIGRATE"); err != nil {
+		return err
+	}
+
+	setString(&c.Auth.JWTSecret, "JWT_SECRET")
+	if err := setDuration(&c.Auth.JWTExpiry, "JWT_EXPIRY"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Auth.RefreshTokenExpiry, "REFRESH_TOKEN_EXPIRY"); err != nil {
+		return err
+	}
+
+	setString(&c.Mail.Driver, "MAIL_DRIVER")
+	setString(&c.Mail.From, "MAIL_FROM")
+	setString(&c.Mail.LogDir, "MAIL_LOG_DIR")
+	setString(&c.Mail.SMTPHost, "SMTP_HOST")
+	setString(&c.Mail.SMTPPort, "SMTP_PORT")
+	setString(&c.Mail.SMTPUsername, "SMTP_USERNAME")
+	setString(&c.Mail.SMTPPassword, "SMTP_PASSWORD")
+
+	setString(&c.Tracking.LinkSigningSecret, "LINK_SIGNING_SECRET")
+	if err := setDuration(&c.Tracking.LinkSignatureTTL, "LINK_SIGNATURE_TTL"); err != nil {
+		return err
+	}
+	if err := setInt(&c.Tracking.QueueSize, "TRACKING_QUEUE_SIZE"); err != nil {
+		return err
+	}
+	if err := setInt(&c.Tracking.Workers, "TRACKING_WORKERS"); err != nil {
+		return err
+	}
+	if err := setInt(&c.Tracking.BatchSize, "TRACKING_BATCH_SIZE"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Tracking.FlushInterval, "TRACKING_FLUSH_INTERVAL"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Tracking.DrainTimeout, "TRACKING_DRAIN_TIMEOUT"); err != nil {
+		return err
+	}
+	setString(&c.Tracking.BotPolicy, "TRACKING_BOT_POLICY")
+	if err := setInt(&c.Tracking.BotRateLimit, "TRACKING_BOT_RATE_LIMIT"); err != nil {
+		return err
+	}
+	setString(&c.Tracking.VisitorCookieDomain, "TRACKING_VISITOR_COOKIE_DOMAIN")
+	setString(&c.Tracking.GeoIPDatabase, "TRACKING_GEOIP_DATABASE")
+	if err := setInt(&c.Tracking.RetentionDays, "TRACKING_RETENTION_DAYS"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Tracking.RetentionInterval, "TRACKING_RETENTION_INTERVAL"); err != nil {
+		return err
+	}
+	if err := setDuration(&c.Tracking.RollupInterval, "TRACKING_ROLLUP_INTERVAL"); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Validate normalizes the configuration and rejects unsafe or incomplete settings.
+// Outside production a missing JWT secret falls back to an insecure development value.
+func (c *Config) Validate() error {
+	var problems []string
+
+	c.Environment = strings.ToLower(strings.TrimSpace(c.Environment))
+	c.Server.FrontendURL = strings.TrimRight(c.Server.FrontendURL, "/")
+	c.Server.PublicURL = strings.TrimRight(c.Server.PublicURL, "/")
+	c.Mail.Driver = strings.ToLower(c.Mail.Driver)
+
+	if c.Server.Port == "" {
+		problems = append(problems, "PORT is required")
+	}
+	if c.Server.PublicURL == "" {
+		problems = append(problems, "PUBLIC_URL is required")
+	}
+
+	c.Database.Driver = strings.ToLower(c.Database.Driver)
+	switch c.Database.Driver {
+	case "postgres":
+		if c.Database.Host == "" || c.Database.Name == "" || c.Database.User == "" {
+			problems = append(problems, "DB_HOST, DB_NAME and DB_USER are required")
+		}
+		switch c.Database.SSLMode {
+		case "disable", "allow", "prefer", "require", "verify-ca", "verify-full":
+		default:
+			problems = append(problems, fmt.Sprintf("DB_SSLMODE %q is not a valid sslmode", c.Database.SSLMode))
+		}
+	case "sqlite":
+		if c.Database.Path == "" {
+			problems = append(problems, "DB_PATH is required when DB_DRIVER=sqlite")
+		}
+		if c.IsProduction() {
+			problems = append(problems, "DB_DRIVER=sqlite is only supported for local development and tests")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown DB_DRIVER %q", c.Database.Driver))
+	}
+
+	if c.IsProduction() {
+		switch {
+		case c.Auth.JWTSecret == "":
+			problems = append(problems, "JWT_SECRET is required in production")
+		case isInsecureSecret(c.Auth.JWTSecret):
+			problems = append(problems, "JWT_SECRET is set to a placeholder value")
+		case len(c.Auth.JWTSecret) < minJWTSecretLength:
+			problems = append(problems, fmt.Sprintf("JWT_SECRET must be at least %d characters in production", minJWTSecretLength))
+		}
+	} else if c.Auth.JWTSecret == "" {
+		log.Println("⚠️  JWT_SECRET is not set, using an insecure development secret")
+		c.Auth.JWTSecret = devJWTSecret
+	}
+	if c.Tracking.LinkSigningSecret == "" {
+		c.Tracking.LinkSigningSecret = c.Auth.JWTSecret
+	}
+	if c.Tracking.QueueSize <= 0 || c.Tracking.Workers <= 0 || c.Tracking.BatchSize <= 0 {
+		problems = append(problems, "TRACKING_QUEUE_SIZE, TRACKING_WORKERS and TRACKING_BATCH_SIZE must be positive")
+	}
+	if c.Tracking.FlushInterval <= 0 || c.Tracking.DrainTimeout <= 0 {
+		problems = append(problems, "TRACKING_FLUSH_INTERVAL and TRACKING_DRAIN_TIMEOUT must be positive")
+	}
+	c.Tracking.BotPolicy = strings.ToLower(c.Tracking.BotPolicy)
+	if c.Tracking.BotPolicy != "flag" && c.Tracking.BotPolicy != "drop" {
+		problems = append(problems, fmt.Sprintf("unknown TRACKING_BOT_POLICY %q", c.Tracking.BotPolicy))
+	}
+	if c.Tracking.BotRateLimit < 0 {
+		problems = append(problems, "TRACKING_BOT_RATE_LIMIT must not be negative")
+	}
+	if c.Tracking.RetentionDays < 0 {
+		problems = append(problems, "TRACKING_RETENTION_DAYS must not be negative")
+	}
+	if c.Tracking.RetentionInterval <= 0 {
+		problems = append(problems, "TRACKING_RETENTION_INTERVAL must be positive")
+	}
+	if c.Tracking.LinkSignatureTTL <= 0 {
+		problems = append(problems, "LINK_SIGNATURE_TTL must be positive")
+	}
+	if c.Tracking.RollupInterval <= 0 {
+		problems = append(problems, "TRACKING_ROLLUP_INTERVAL must be positive")
+	}
+	if c.Auth.JWTExpiry <= 0 || c.Auth.RefreshTokenExpiry <= 0 {
+		problems = append(problems, "JWT_EXPIRY and REFRESH_TOKEN_EXPIRY must be positive")
+	}
+
+	switch c.Mail.Driver {
+	case "log":
+	case "smtp":
+		if c.Mail.SMTPHost == "" {
+			problems = append(problems, "SMTP_HOST is required when MAIL_DRIVER=smtp")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("unknown MAIL_DRIVER %q", c.Mail.Driver))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("invalid configuration: " + strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func isInsecureSecret(secret string) bool {
+	for _, s := range insecureJWTSecrets {
+		if secret == s {
+			return true
+		}
+	}
+	return false
+}
+
+func setString(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+// setList splits a comma-separated variable, ignoring blank entries
+func setList(dst *[]string, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	var list []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	*dst = list
+}
+
+func setBool(dst *bool, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	*dst = b
+	return nil
+}
+
+func setInt(dst *int, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	*dst = n
+	return nil
+}
+
+func setDuration(dst *time.Duration, key string) error {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q: %w", key, v, err)
+	}
+	*dst = d
+	return nil
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.User = "postgres"
+	cfg.Database.Name = "onedash"
+	return cfg
+}
+
+func TestValidateRejectsWeakSecretsInProduction(t *testing.T) {
+	for _, secret := range []string{"", "default-secret-key", "your-super-secret-key-change-in-production", "too-short"} {
+		cfg := validConfig()
+		cfg.Environment = "production"
+		cfg.Auth.JWTSecret = secret
+
+		assert.Error(t, cfg.Validate(), "secret %q should be rejected", secret)
+	}
+
+	cfg := validConfig()
+	cfg.Environment = "production"
+	cfg.Auth.JWTSecret = "0123456789abcdef0123456789abcdef"
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestValidateFallsBackToDevSecretOutsideProduction(t *testing.T) {
+	cfg := validConfig()
+	require.NoError(t, cfg.Validate())
+	assert.Equal(t, devJWTSecret, cfg.Auth.JWTSecret)
+}
+
+func TestValidateRejectsUnknownSSLMode(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.SSLMode = "sometimes"
+	assert.Error(t, cfg.Validate())
+}
+
+func TestLoadPrefersEnvOverFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+database:
+  host: db.internal
+  user: onedash
+  name: onedash
+  sslmode: disable
+auth:
+  jwt_expiry: 1h
+server:
+  port: "8080"
+`), 0600))
+
+	t.Setenv("CONFIG_FILE", path)
+	t.Setenv("ENVIRONMENT", "development")
+	t.Setenv("PORT", "9090")
+	t.Setenv("JWT_SECRET", "")
+	t.Setenv("DB_SSLMODE", "")
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.5, 172.16.0.0/12,")
+
+	cfg, err := Load()
+	require.NoError(t, err)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, "disable", cfg.Database.SSLMode)
+	assert.Equal(t, time.Hour, cfg.Auth.JWTExpiry)
+	assert.Equal(t, "9090", cfg.Server.Port)
+	assert.Equal(t, []string{"10.0.0.5", "172.16.0.0/12"}, cfg.Server.TrustedProxies)
+}
+
+func TestLoadRejectsInvalidDuration(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.yaml"))
+	_, err := Load()
+	assert.Error(t, err)
+
+	t.Setenv("CONFIG_FILE", "")
+	t.Setenv("JWT_EXPIRY", "one day")
+	_, err = Load()
+	assert.Error(t, err)
+}
@@ -3,38 +3,22 @@ package config
 import (
 	"fmt"
 	"log"
-	"os"
 
+	"github.com/glebarez/sqlite"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/logger"
-
-	"github.com/onedash/backend/internal/models"
 )
 
 var DB *gorm.DB
 
-func InitDB() (*gorm.DB, error) {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	password := os.Getenv("DB_PASSWORD")
-	dbname := os.Getenv("DB_NAME")
-
-	// Log connection attempt (hide password)
-	log.Printf("Attempting to connect to database: host=%s port=%s user=%s dbname=%s", host, port, user, dbname)
-
-	// Build DSN - simple mode for transaction pooler compatibility
-	// CRITICAL: No prepared statements allowed with Supabase transaction pooler
-	dsn := fmt.Sprintf(
-		"host=%s user=%s password=%s dbname=%s port=%s sslmode=require TimeZone=UTC",
-		host, user, password, dbname, port,
-	)
+func InitDB(cfg *Config) (*gorm.DB, error) {
+	dialector, err := openDialector(cfg.Database)
+	if err != nil {
+		return nil, err
+	}
 
-	db, err := gorm.Open(postgres.New(postgres.Config{
-		DSN:                  dsn,
-		PreferSimpleProtocol: true, // Force simple protocol (no prepared statements)
-	}), &gorm.Config{
+	db, err := gorm.Open(dialector, &gorm.Config{
 		Logger:      logger.Default.LogMode(logger.Info),
 		PrepareStmt: false,
 	})
@@ -42,25 +26,43 @@ func InitDB() (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
-	// Skip auto-migration if tables already exist (production safety)
-	// Run migrations manually via SQL scripts instead
-	env := os.Getenv("ENVIRONMENT")
-	if env != "production" {
-		// Auto migrate models (only in development)
-		if err := db.AutoMigrate(
-			&models.User{},
-			&models.Contact{},
-			&models.Link{},
-			&models.LinkClick{},
-			&models.PageView{},
-			&models.SocialClick{},
-			&models.CommissionRate{},
-		); err != nil {
-			return nil, fmt.Errorf("failed to migrate database: %w", err)
+	if cfg.Database.Driver == "sqlite" {
+		// SQLite allows a single writer, and every connection to ":memory:" is a separate database
+		sqlDB, err := db.DB()
+		if err != nil {
+			return nil, err
 		}
+		sqlDB.SetMaxOpenConns(1)
 	}
 
-	log.Println("✅ Database connected and migrated successfully")
+	log.Println("✅ Database connected successfully")
 	DB = db
 	return db, nil
 }
+
+func openDialector(dbCfg DatabaseConfig) (gorm.Dialector, error) {
+	switch dbCfg.Driver {
+	case "sqlite":
+		log.Printf("Attempting to open SQLite database: %s", dbCfg.Path)
+		return sqlite.Open(dbCfg.Path + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)"), nil
+
+	case "postgres", "":
+		// Log connection attempt (hide password)
+		log.Printf("Attempting to connect to database: host=%s port=%s user=%s dbname=%s sslmode=%s",
+			dbCfg.Host, dbCfg.Port, dbCfg.User, dbCfg.Name, dbCfg.SSLMode)
+
+		// Build DSN - simple mode for transaction pooler compatibility
+		// CRITICAL: No prepared statements allowed with Supabase transaction pooler
+		dsn := fmt.Sprintf(
+			"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s TimeZone=UTC",
+			dbCfg.Host, dbCfg.User, dbCfg.Password, dbCfg.Name, dbCfg.Port, dbCfg.SSLMode,
+		)
+		return postgres.New(postgres.Config{
+			DSN:                  dsn,
+			PreferSimpleProtocol: true, // Force simple protocol (no prepared statements)
+		}), nil
+
+	default:
+		return nil, fmt.Errorf("unknown database driver %q", dbCfg.Driver)
+	}
+}
@@ -0,0 +1,183 @@
+// Package botdetect classifies tracking requests as human or automated.
+//
+// A request is treated as a bot when its User-Agent matches a known crawler,
+// link-preview fetcher or HTTP library, when it carries headless-browser hints,
+// when it lacks headers every browser sends, or when its IP sends more events
+// than a person plausibly could.
+package botdetect
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Reasons reported in a Verdict
+const (
+	ReasonEmptyUserAgent    = "empty_user_agent"
+	ReasonUserAgent         = "user_agent"
+	ReasonHeadless          = "headless"
+	ReasonPrefetch          = "prefetch"
+	ReasonNoAcceptLanguage  = "no_accept_language"
+	ReasonRateLimitExceeded = "rate_limit"
+)
+
+// uaPatterns are lower-case User-Agent fragments of crawlers, link-preview
+// fetchers, monitoring tools and HTTP client libraries
+var uaPatterns = []string{
+	// generic
+	"bot", "crawler", "spider", "crawl", "slurp", "scraper", "preview",
+	// link previews in chat and social apps (their in-app browsers look like
+	// regular mobile browsers, so app names alone are not listed)
+	"facebookexternalhit", "facebookcatalog", "whatsapp", "skypeuripreview", "embedly",
+	// search engines and SEO tools
+	"google-inspectiontool", "mediapartners-google", "yandex", "baidu", "ahrefs",
+	"semrush", "mj12", "petalsearch", "bytespider",
+	// monitoring and audits
+	"lighthouse", "pingdom", "uptimerobot", "statuscake", "gtmetrix", "pagespeed",
+	// HTTP libraries and tools
+	"curl", "wget", "python-requests", "python-urllib", "aiohttp", "httpx",
+	"go-http-client", "okhttp", "axios", "node-fetch", "undici", "java/",
+	"libwww-perl", "apache-httpclient", "postman", "insomnia",
+}
+
+// humanFragments contain a bot pattern but belong to real devices (Cubot phones)
+var humanFragments = []string{"cubot"}
+
+// exactUserAgents are matched against the whole (lower-case) User-Agent
+var exactUserAgents = []string{"node", "mozilla/5.0", "-"}
+
+// headlessPatterns identify automated browsers
+var headlessPatterns = []string{"headlesschrome", "phantomjs", "puppeteer", "playwright", "selenium"}
+
+// Signals are the parts of a request the classifier looks at
+type Signals struct {
+	UserAgent      string
+	IP             string
+	AcceptLanguage string
+	ClientHints    string // Sec-CH-UA
+	Purpose        string // Sec-Purpose or Purpose, set on speculative prefetches
+}
+
+// Verdict is the outcome of Classify; Reason is empty for humans
+type Verdict struct {
+	Bot    bool
+	Reason string
+}
+
+type Options struct {
+	// RateLimit is the number of events one IP may send per RateWindow before
+	// its further events are classified as bots. Zero disables the check.
+	RateLimit  int
+	RateWindow time.Duration
+}
+
+// DefaultOptions allow a busy shared IP (office, carrier NAT) plenty of headroom
+var DefaultOptions = Options{RateLimit: 120, RateWindow: time.Minute}
+
+// Classifier is safe for concurrent use
+type Classifier struct {
+	opts Options
+	now  func() time.Time
+
+	mu        sync.Mutex
+	windows   map[string]*rateWindow
+	lastPrune time.Time
+}
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+func New(opts Options) *Classifier {
+	if opts.RateWindow <= 0 {
+		opts.RateWindow = DefaultOptions.RateWindow
+	}
+	return &Classifier{
+		opts:    opts,
+		now:     time.Now,
+		windows: make(map[string]*rateWindow),
+	}
+}
+
+// Classify decides whether a request comes from a bot. Every call counts
+// towards the rate limit of the request's IP.
+func (c *Classifier) Classify(s Signals) Verdict {
+	// Count first so bots keep using up their IP's budget
+	overLimit := c.countRequest(s.IP)
+
+	ua := strings.ToLower(strings.TrimSpace(s.UserAgent))
+	if ua == "" {
+		return Verdict{Bot: true, Reason: ReasonEmptyUserAgent}
+	}
+	for _, exact := range exactUserAgents {
+		if ua == exact {
+			return Verdict{Bot: true, Reason: ReasonUserAgent}
+		}
+	}
+	matchable := ua
+	for _, fragment := range humanFragments {
+		matchable = strings.ReplaceAll(matchable, fragment, "")
+	}
+	for _, pattern := range uaPatterns {
+		if strings.Contains(matchable, pattern) {
+			return Verdict{Bot: true, Reason: ReasonUserAgent}
+		}
+	}
+
+	hints := strings.ToLower(s.ClientHints)
+	for _, pattern := range headlessPatterns {
+		if strings.Contains(ua, pattern) || strings.Contains(hints, pattern) {
+			return Verdict{Bot: true, Reason: ReasonHeadless}
+		}
+	}
+
+	if strings.Contains(strings.ToLower(s.Purpose), "prefetch") {
+		return Verdict{Bot: true, Reason: ReasonPrefetch}
+	}
+
+	// Browsers send Accept-Language with every navigation, fetch and beacon
+	if strings.TrimSpace(s.AcceptLanguage) == "" {
+		return Verdict{Bot: true, Reason: ReasonNoAcceptLanguage}
+	}
+
+	if overLimit {
+		return Verdict{Bot: true, Reason: ReasonRateLimitExceeded}
+	}
+	return Verdict{}
+}
+
+// countRequest records a request from ip and reports whether the IP is over its limit
+func (c *Classifier) countRequest(ip string) bool {
+	if c.opts.RateLimit <= 0 || ip == "" {
+		return false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.prune(now)
+
+	w, ok := c.windows[ip]
+	if !ok || now.Sub(w.start) >= c.opts.RateWindow {
+		w = &rateWindow{start: now}
+		c.windows[ip] = w
+	}
+	w.count++
+	return w.count > c.opts.RateLimit
+}
+
+// prune forgets expired windows, at most once per window length
+func (c *Classifier) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < c.opts.RateWindow {
+		return
+	}
+	c.lastPrune = now
+	for ip, w := range c.windows {
+		if now.Sub(w.start) >= c.opts.RateWindow {
+			delete(c.windows, ip)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package botdetect
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const chromeAndroid = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36"
+
+func browser(ua string) Signals {
+	return Signals{UserAgent: ua, IP: "203.0.113.7", AcceptLanguage: "id-ID,id;q=0.9,en;q=0.8"}
+}
+
+func TestClassifyUserAgents(t *testing.T) {
+	tests := []struct {
+		name   string
+		ua     string
+		reason string
+	}{
+		{"chrome", chromeAndroid, ""},
+		{"safari", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", ""},
+		{"instagram in-app", "Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 335.0.3.17.108", ""},
+		{"tiktok in-app", "Mozilla/5.0 (Linux; Android 13; SM-A145F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36 musical_ly_2023405030 BytedanceWebview/d8a21c6", ""},
+		{"cubot phone", "Mozilla/5.0 (Linux; Android 12; CUBOT KINGKONG 7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0 Mobile Safari/537.36", ""},
+		{"empty", "", ReasonEmptyUserAgent},
+		{"googlebot", "Mozilla/5.0 (compatible; Googlebot/2.1; +http://www.google.com/bot.html)", ReasonUserAgent},
+		{"facebook preview", "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)", ReasonUserAgent},
+		{"whatsapp preview", "WhatsApp/2.23.20.0", ReasonUserAgent},
+		{"twitter card", "Twitterbot/1.0", ReasonUserAgent},
+		{"curl", "curl/8.4.0", ReasonUserAgent},
+		{"python", "python-requests/2.31.0", ReasonUserAgent},
+		{"next.js ssr", "node", ReasonUserAgent},
+		{"headless chrome", "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) HeadlessChrome/126.0.0.0 Safari/537.36", ReasonHeadless},
+	}
+
+	c := New(Options{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict := c.Classify(browser(tt.ua))
+			assert.Equal(t, tt.reason != "", verdict.Bot)
+			assert.Equal(t, tt.reason, verdict.Reason)
+		})
+	}
+}
+
+func TestClassifyRequestHints(t *testing.T) {
+	c := New(Options{})
+
+	s := browser(chromeAndroid)
+	s.ClientHints = `"Chromium";v="126", "HeadlessChrome";v="126"`
+	assert.Equal(t, Verdict{Bot: true, Reason: ReasonHeadless}, c.Classify(s))
+
+	s = browser(chromeAndroid)
+	s.Purpose = "prefetch;prerender"
+	assert.Equal(t, Verdict{Bot: true, Reason: ReasonPrefetch}, c.Classify(s))
+
+	s = browser(chromeAndroid)
+	s.AcceptLanguage = ""
+	assert.Equal(t, Verdict{Bot: true, Reason: ReasonNoAcceptLanguage}, c.Classify(s))
+}
+
+func TestClassifyRateLimitPerIP(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := New(Options{RateLimit: 3, RateWindow: time.Minute})
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 3; i++ {
+		assert.False(t, c.Classify(browser(chromeAndroid)).Bot)
+	}
+	assert.Equal(t, Verdict{Bot: true, Reason: ReasonRateLimitExceeded}, c.Classify(browser(chromeAndroid)))
+
+	// Other IPs have their own budget
+	other := browser(chromeAndroid)
+	other.IP = "198.51.100.1"
+	assert.False(t, c.Classify(other).Bot)
+
+	// A new window starts fresh and expired windows are forgotten
+	now = now.Add(time.Minute)
+	assert.False(t, c.Classify(browser(chromeAndroid)).Bot)
+	assert.Len(t, c.windows, 1)
+}
@@ -0,0 +1,75 @@
+// Package geoip resolves visitor IPs to a location using a local MaxMind-format
+// database (GeoLite2/GeoIP2 City or Country, or a compatible file). Lookups
+// never leave the process; the file is memory-mapped once at startup.
+package geoip
+
+import (
+	"net"
+
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// Location is where an IP address is registered. Fields the database does not
+// know are empty; a Country database only fills Country.
+type Location struct {
+	Country string // ISO 3166-1 alpha-2
+	Region  string // ISO 3166-2 subdivision code without the country, e.g. "JK"
+	City    string // English name
+}
+
+// record is the part of a GeoIP2 City/Country record we read
+type record struct {
+	Country struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"country"`
+	Subdivisions []struct {
+		ISOCode string `maxminddb:"iso_code"`
+	} `maxminddb:"subdivisions"`
+	City struct {
+		Names map[string]string `maxminddb:"names"`
+	} `maxminddb:"city"`
+}
+
+// DB is safe for concurrent use. A nil *DB finds nothing.
+type DB struct {
+	reader *maxminddb.Reader
+}
+
+// Open loads the database file at path
+func Open(path string) (*DB, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	return &DB{reader: reader}, nil
+}
+
+// Lookup returns the location of ip. Unparseable, private and unknown
+// addresses have an empty location.
+func (d *DB) Lookup(ip string) (Location, error) {
+	addr := net.ParseIP(ip)
+	if d == nil || addr == nil {
+		return Location{}, nil
+	}
+
+	var rec record
+	if err := d.reader.Lookup(addr, &rec); err != nil {
+		return Location{}, err
+	}
+	loc := Location{
+		Country: rec.Country.ISOCode,
+		City:    rec.City.Names["en"],
+	}
+	if len(rec.Subdivisions) > 0 {
+		loc.Region = rec.Subdivisions[0].ISOCode
+	}
+	return loc, nil
+}
+
+// Close unmaps the database file
+func (d *DB) Close() error {
+	if d == nil {
+		return nil
+	}
+	return d.reader.Close()
+}
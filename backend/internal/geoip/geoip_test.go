@@ -0,0 +1,189 @@
+package geoip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestDB writes a small IPv4 MaxMind DB mapping networks (CIDR) to records
+// and returns its path. It uses 24-bit records and stores each record once.
+func writeTestDB(t *testing.T, networks map[string]map[string]interface{}) string {
+	t.Helper()
+
+	// Search tree: a node holds two records, each a child node, a data offset or nothing
+	type record struct {
+		node int // > 0: child node
+		data int // >= 0 with node 0: data offset; -1: nothing
+	}
+	nodes := [][2]record{{{data: -1}, {data: -1}}}
+	var data bytes.Buffer
+	cidrs := make([]string, 0, len(networks))
+	for cidr := range networks {
+		cidrs = append(cidrs, cidr)
+	}
+	sort.Strings(cidrs)
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		require.NoError(t, err)
+		ones, _ := network.Mask.Size()
+		ip := network.IP.To4()
+
+		offset := data.Len()
+		encodeValue(t, &data, networks[cidr])
+		node := 0
+		for i := 0; i < ones; i++ {
+			bit := ip[i/8] >> (7 - i%8) & 1
+			if i == ones-1 {
+				nodes[node][bit] = record{data: offset}
+				break
+			}
+			if nodes[node][bit].node == 0 {
+				nodes = append(nodes, [2]record{{data: -1}, {data: -1}})
+				nodes[node][bit] = record{node: len(nodes) - 1}
+			}
+			node = nodes[node][bit].node
+		}
+	}
+
+	var file bytes.Buffer
+	nodeCount := len(nodes)
+	for _, n := range nodes {
+		for _, r := range n {
+			value := nodeCount // Nothing
+			switch {
+			case r.node > 0:
+				value = r.node
+			case r.data >= 0:
+				value = nodeCount + 16 + r.data
+			}
+			file.Write([]byte{byte(value >> 16), byte(value >> 8), byte(value)})
+		}
+	}
+	file.Write(make([]byte, 16)) // Data section separator
+	file.Write(data.Bytes())
+	file.WriteString("\xab\xcd\xefMaxMind.com")
+	encodeValue(t, &file, map[string]interface{}{
+		"node_count":                  uint32(nodeCount),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               "OneDash-Test-City",
+		"languages":                   []interface{}{"en"},
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"build_epoch":                 uint64(1700000000),
+		"description":                 map[string]interface{}{"en": "OneDash test database"},
+	})
+
+	path := filepath.Join(t.TempDir(), "test.mmdb")
+	require.NoError(t, os.WriteFile(path, file.Bytes(), 0644))
+	return path
+}
+
+// encodeValue writes v in the MaxMind DB data format; sizes stay below 29
+func encodeValue(t *testing.T, buf *bytes.Buffer, v interface{}) {
+	t.Helper()
+	control := func(typ, size int) {
+		require.Less(t, size, 29)
+		if typ <= 7 {
+			buf.WriteByte(byte(typ<<5 | size))
+		} else {
+			buf.Write([]byte{byte(size), byte(typ - 7)})
+		}
+	}
+	unsigned := func(typ int, n uint64) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], n)
+		trimmed := bytes.TrimLeft(b[:], "\x00")
+		control(typ, len(trimmed))
+		buf.Write(trimmed)
+	}
+
+	switch v := v.(type) {
+	case string:
+		control(2, len(v))
+		buf.WriteString(v)
+	case uint16:
+		unsigned(5, uint64(v))
+	case uint32:
+		unsigned(6, uint64(v))
+	case uint64:
+		unsigned(9, v)
+	case []interface{}:
+		control(11, len(v))
+		for _, item := range v {
+			encodeValue(t, buf, item)
+		}
+	case map[string]interface{}:
+		control(7, len(v))
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			encodeValue(t, buf, key)
+			encodeValue(t, buf, v[key])
+		}
+	default:
+		t.Fatalf("cannot encode %T", v)
+	}
+}
+
+func TestLookup(t *testing.T) {
+	db, err := Open(writeTestDB(t, map[string]map[string]interface{}{
+		"203.0.113.0/24": {
+			"country":      map[string]interface{}{"iso_code": "ID"},
+			"subdivisions": []interface{}{map[string]interface{}{"iso_code": "JK"}},
+			"city":         map[string]interface{}{"names": map[string]interface{}{"en": "Jakarta", "id": "Jakarta Raya"}},
+		},
+		"198.51.100.0/25": { // As in a Country database
+			"country": map[string]interface{}{"iso_code": "SG"},
+		},
+	}))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	for _, tc := range []struct {
+		ip   string
+		want Location
+	}{
+		{"203.0.113.42", Location{Country: "ID", Region: "JK", City: "Jakarta"}},
+		{"198.51.100.7", Location{Country: "SG"}},
+		{"198.51.100.200", Location{}}, // Outside the /25
+		{"192.0.2.1", Location{}},
+		{"", Location{}},
+		{"not-an-ip", Location{}},
+		{"203.0.113.999", Location{}},
+	} {
+		got, err := db.Lookup(tc.ip)
+		assert.NoError(t, err, tc.ip)
+		assert.Equal(t, tc.want, got, tc.ip)
+	}
+
+	// An IPv6 address cannot be looked up in an IPv4 database
+	_, err = db.Lookup("2001:db8::1")
+	assert.Error(t, err)
+}
+
+func TestNilDBFindsNothing(t *testing.T) {
+	var db *DB
+	loc, err := db.Lookup("203.0.113.42")
+	assert.NoError(t, err)
+	assert.Equal(t, Location{}, loc)
+	assert.NoError(t, db.Close())
+}
+
+func TestOpenRejectsOtherFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not.mmdb")
+	require.NoError(t, os.WriteFile(path, []byte("not a database"), 0644))
+	_, err := Open(path)
+	assert.Error(t, err)
+}
@@ -0,0 +1,421 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/ingest"
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/services"
+)
+
+// trackingStats reports the health of the tracking ingest pipeline
+type trackingStats interface {
+	Stats() ingest.Stats
+}
+
+type AdminHandler struct {
+	adminService *services.AdminService
+	tracking     trackingStats
+}
+
+func NewAdminHandler(adminService *services.AdminService, tracking trackingStats) *AdminHandler {
+	return &AdminHandler{adminService: adminService, tracking: tracking}
+}
+
+// pagination reads page/limit query params (defaults 1/20, limit capped at 100)
+func pagination(c *fiber.Ctx) (page, limit, offset int) {
+	page = c.QueryInt("page", 1)
+	if page < 1 {
+		page = 1
+	}
+	limit = c.QueryInt("limit", 20)
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit, (page - 1) * limit
+}
+
+// Users
+
+func (h *AdminHandler) SearchUsers(c *fiber.Ctx) error {
+	page, limit, offset := pagination(c)
+
+	users, total, err := h.adminService.SearchUsers(c.Query("q"), c.Query("status"), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search users",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"users": users,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+func (h *AdminHandler) GetUser(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, err := h.adminService.GetUser(userID)
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.JSON(user)
+}
+
+func (h *AdminHandler) SuspendUser(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var input services.SuspendUserInput
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&input); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	user, err := h.adminService.SuspendUser(actorID, userID, &input, clientInfo(c))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.JSON(user)
+}
+
+func (h *AdminHandler) UnsuspendUser(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, err := h.adminService.UnsuspendUser(actorID, userID, clientInfo(c))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.JSON(user)
+}
+
+// SetVerified grants or removes the creator verification badge
+func (h *AdminHandler) SetVerified(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	userID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var input services.SetVerifiedInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	user, err := h.adminService.SetVerified(actorID, userID, &input, clientInfo(c))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.JSON(user)
+}
+
+// Commission rates
+
+// GetCommissionRates lists every version of the rates; ?user_id= lists one creator's overrides
+func (h *AdminHandler) GetCommissionRates(c *fiber.Ctx) error {
+	var userID *uuid.UUID
+	if raw := c.Query("user_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid user ID",
+			})
+		}
+		userID = &id
+	}
+
+	rates, err := h.adminService.GetCommissionRates(c.Query("platform"), userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get commission rates",
+		})
+	}
+
+	return c.JSON(rates)
+}
+
+func (h *AdminHandler) CreateCommissionRate(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	input, message := parseCommissionRateInput(c)
+	if input == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+
+	rate, err := h.adminService.CreateCommissionRate(actorID, input, clientInfo(c))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(rate)
+}
+
+func (h *AdminHandler) UpdateCommissionRate(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	rateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid commission rate ID",
+		})
+	}
+
+	input, message := parseCommissionRateInput(c)
+	if input == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+
+	rate, err := h.adminService.UpdateCommissionRate(actorID, rateID, input, clientInfo(c))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.JSON(rate)
+}
+
+func (h *AdminHandler) DeleteCommissionRate(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	rateID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid commission rate ID",
+		})
+	}
+
+	if err := h.adminService.DeleteCommissionRate(actorID, rateID, clientInfo(c)); err != nil {
+		return adminError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// Category keywords
+
+func (h *AdminHandler) GetCategoryKeywords(c *fiber.Ctx) error {
+	keywords, err := h.adminService.GetCategoryKeywords(c.Query("category"))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get category keywords",
+		})
+	}
+
+	return c.JSON(keywords)
+}
+
+func (h *AdminHandler) CreateCategoryKeyword(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	input, message := parseCategoryKeywordInput(c)
+	if input == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+
+	keyword, err := h.adminService.CreateCategoryKeyword(actorID, input, clientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create category keyword",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(keyword)
+}
+
+func (h *AdminHandler) UpdateCategoryKeyword(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	keywordID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category keyword ID",
+		})
+	}
+
+	input, message := parseCategoryKeywordInput(c)
+	if input == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": message,
+		})
+	}
+
+	keyword, err := h.adminService.UpdateCategoryKeyword(actorID, keywordID, input, clientInfo(c))
+	if err != nil {
+		return adminError(c, err)
+	}
+
+	return c.JSON(keyword)
+}
+
+func (h *AdminHandler) DeleteCategoryKeyword(c *fiber.Ctx) error {
+	actorID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	keywordID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid category keyword ID",
+		})
+	}
+
+	if err := h.adminService.DeleteCategoryKeyword(actorID, keywordID, clientInfo(c)); err != nil {
+		return adminError(c, err)
+	}
+
+	return c.Status(fiber.StatusNoContent).Send(nil)
+}
+
+// Audit log
+
+func (h *AdminHandler) GetAuditLogs(c *fiber.Ctx) error {
+	page, limit, offset := pagination(c)
+
+	entries, total, err := h.adminService.GetAuditLogs(c.Query("target_type"), c.Query("target_id"), limit, offset)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get audit logs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"entries": entries,
+		"total":   total,
+		"page":    page,
+		"limit":   limit,
+	})
+}
+
+// parseCommissionRateInput returns nil and an error message when the body is invalid
+func parseCommissionRateInput(c *fiber.Ctx) (*services.CommissionRateInput, string) {
+	var input services.CommissionRateInput
+	if err := c.BodyParser(&input); err != nil {
+		return nil, "Invalid request body"
+	}
+
+	if input.Platform == "" || input.Category == "" {
+		return nil, "Platform and category are required"
+	}
+	if input.RatePercent < 0 || input.RatePercent > 100 {
+		return nil, "Rate must be between 0 and 100 percent"
+	}
+	if input.MaxCommission != nil && *input.MaxCommission < 0 {
+		return nil, "Max commission cannot be negative"
+	}
+
+	return &input, ""
+}
+
+// parseCategoryKeywordInput returns nil and an error message when the body is invalid
+func parseCategoryKeywordInput(c *fiber.Ctx) (*services.CategoryKeywordInput, string) {
+	var input services.CategoryKeywordInput
+	if err := c.BodyParser(&input); err != nil {
+		return nil, "Invalid request body"
+	}
+
+	if input.Category == "" || input.Keyword == "" {
+		return nil, "Category and keyword are required"
+	}
+	if input.Source != "" && input.Source != "title" && input.Source != "breadcrumb" {
+		return nil, "Source must be 'title' or 'breadcrumb'"
+	}
+
+	return &input, ""
+}
+
+func adminError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, services.ErrUserNotFound),
+		errors.Is(err, services.ErrCommissionRateNotFound),
+		errors.Is(err, services.ErrCategoryKeywordNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrCommissionRateOverlap),
+		errors.Is(err, services.ErrCommissionRateStarted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, services.ErrCannotSuspendSelf),
+		errors.Is(err, services.ErrInvalidEffectivePeriod):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to complete admin action",
+	})
+}
+
+// Tracking
+
+// GetTrackingStats reports queue depth, drops and write failures of the tracking pipeline
+func (h *AdminHandler) GetTrackingStats(c *fiber.Ctx) error {
+	return c.JSON(h.tracking.Stats())
+}
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+// adminFixture serves the admin user routes behind RequireAdmin
+type adminFixture struct {
+	app                   *fiber.App
+	db                    *gorm.DB
+	admin, suspendedAdmin *models.User
+	creator               *models.User
+}
+
+func newAdminFixture(t *testing.T) *adminFixture {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+
+	suspendedAt := time.Now()
+	f := &adminFixture{
+		db:             db,
+		admin:          &models.User{Email: "hana@example.com", Username: "hana", Role: models.RoleAdmin},
+		suspendedAdmin: &models.User{Email: "ivan@example.com", Username: "ivan", Role: models.RoleAdmin, SuspendedAt: &suspendedAt},
+		creator:        &models.User{Email: "joko@example.com", Username: "joko"},
+	}
+	for _, user := range []*models.User{f.admin, f.suspendedAdmin, f.creator} {
+		user.PasswordHash = "hash"
+		require.NoError(t, userRepo.Create(user))
+	}
+
+	adminService := services.NewAdminService(
+		userRepo,
+		repository.NewCommissionRateRepository(db),
+		repository.NewCategoryKeywordRepository(db),
+		repository.NewAuditLogRepository(db),
+		services.NewSessionService(repository.NewSessionRepository(db), repository.NewRefreshTokenRepository(db)),
+		nil,
+	)
+	handler := NewAdminHandler(adminService, nil)
+
+	// Stand-in for AuthMiddleware: the test picks the authenticated user
+	app := fiber.New()
+	admin := app.Group("/api/admin", func(c *fiber.Ctx) error {
+		userID, err := uuid.Parse(c.Get("X-Test-User"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		c.Locals("userID", userID)
+		return c.Next()
+	}, middleware.RequireAdmin(userRepo))
+	admin.Get("/users", handler.SearchUsers)
+	admin.Post("/users/:id/suspend", handler.SuspendUser)
+	admin.Post("/users/:id/unsuspend", handler.UnsuspendUser)
+	f.app = app
+
+	return f
+}
+
+func (f *adminFixture) do(t *testing.T, method, path string, as uuid.UUID) int {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(""))
+	req.Header.Set("X-Test-User", as.String())
+	resp, err := f.app.Test(req)
+	require.NoError(t, err)
+	return resp.StatusCode
+}
+
+func TestAdminRoutesRequireAnActiveAdmin(t *testing.T) {
+	f := newAdminFixture(t)
+
+	assert.Equal(t, http.StatusUnauthorized, f.do(t, http.MethodGet, "/api/admin/users", uuid.New()), "unknown user")
+	assert.Equal(t, http.StatusForbidden, f.do(t, http.MethodGet, "/api/admin/users", f.creator.ID), "creator")
+	assert.Equal(t, http.StatusForbidden, f.do(t, http.MethodGet, "/api/admin/users", f.suspendedAdmin.ID), "suspended admin")
+	assert.Equal(t, http.StatusOK, f.do(t, http.MethodGet, "/api/admin/users", f.admin.ID))
+}
+
+func TestAdminChangesAreStoredWithTheirAuditEntry(t *testing.T) {
+	f := newAdminFixture(t)
+
+	suspend := "/api/admin/users/" + f.creator.ID.String() + "/suspend"
+	require.Equal(t, http.StatusOK, f.do(t, http.MethodPost, suspend, f.admin.ID))
+
+	var entries []models.AuditLog
+	require.NoError(t, f.db.Find(&entries).Error)
+	require.Len(t, entries, 1)
+	assert.Equal(t, services.AuditUserSuspend, entries[0].Action)
+	assert.Equal(t, f.admin.ID, entries[0].ActorID)
+	assert.Equal(t, f.creator.ID.String(), entries[0].TargetID)
+
+	// Without an audit log the change fails and is rolled back
+	require.NoError(t, f.db.Migrator().DropTable(&models.AuditLog{}))
+	unsuspend := "/api/admin/users/" + f.creator.ID.String() + "/unsuspend"
+	assert.Equal(t, http.StatusInternalServerError, f.do(t, http.MethodPost, unsuspend, f.admin.ID))
+
+	var creator models.User
+	require.NoError(t, f.db.First(&creator, "id = ?", f.creator.ID).Error)
+	assert.True(t, creator.IsSuspended())
+}
@@ -1,21 +1,52 @@
 package handlers
 
 import (
+	"errors"
+	"log"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
 	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/repository"
 	"github.com/onedash/backend/internal/services"
 )
 
 type AnalyticsHandler struct {
 	analyticsService *services.AnalyticsService
+	visitors         VisitorCookie
 }
 
-func NewAnalyticsHandler(analyticsService *services.AnalyticsService) *AnalyticsHandler {
-	return &AnalyticsHandler{analyticsService: analyticsService}
+func NewAnalyticsHandler(analyticsService *services.AnalyticsService, visitors VisitorCookie) *AnalyticsHandler {
+	return &AnalyticsHandler{analyticsService: analyticsService, visitors: visitors}
+}
+
+// reportService honours ?include_bots=true; reports show human traffic by default
+func (h *AnalyticsHandler) reportService(c *fiber.Ctx) *services.AnalyticsService {
+	return h.analyticsService.WithBots(c.QueryBool("include_bots"))
+}
+
+// visitContext collects the request details used for tracking and bot detection
+func visitContext(c *fiber.Ctx, visitorID, source string) services.VisitContext {
+	purpose := c.Get("Sec-Purpose")
+	if purpose == "" {
+		purpose = c.Get("Purpose")
+	}
+	country, region := proxyLocation(c)
+	return services.VisitContext{
+		VisitorID:      visitorID,
+		Source:         source,
+		VisitorIP:      c.IP(),
+		Country:        country,
+		Region:         region,
+		UserAgent:      c.Get(fiber.HeaderUserAgent),
+		RequestedWith:  c.Get(fiber.HeaderXRequestedWith),
+		Referer:        c.Get(fiber.HeaderReferer),
+		AcceptLanguage: c.Get(fiber.HeaderAcceptLanguage),
+		ClientHints:    c.Get("Sec-CH-UA"),
+		Purpose:        purpose,
+	}
 }
 
 func (h *AnalyticsHandler) GetOverview(c *fiber.Ctx) error {
@@ -23,10 +54,11 @@ func (h *AnalyticsHandler) GetOverview(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	analyticsService := h.reportService(c)
 
 	// Pass zero times for lifetime stats
 	var zeroTime time.Time
-	stats, err := h.analyticsService.GetOverview(userID, zeroTime, zeroTime)
+	stats, err := analyticsService.GetOverview(userID, zeroTime, zeroTime)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get analytics",
@@ -41,6 +73,7 @@ func (h *AnalyticsHandler) GetClicks(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	analyticsService := h.reportService(c)
 
 	// Parse date range from query params
 	fromStr := c.Query("from", time.Now().AddDate(0, 0, -7).Format("2006-01-02"))
@@ -56,14 +89,14 @@ func (h *AnalyticsHandler) GetClicks(c *fiber.Ctx) error {
 		to = time.Now()
 	}
 
-	clicks, err := h.analyticsService.GetClicks(userID, from, to)
+	clicks, err := analyticsService.GetClicks(userID, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get clicks",
 		})
 	}
 
-	topLinks, err := h.analyticsService.GetTopLinks(userID, 5)
+	topLinks, err := analyticsService.GetTopLinks(userID, 5)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get top links",
@@ -76,50 +109,6 @@ func (h *AnalyticsHandler) GetClicks(c *fiber.Ctx) error {
 	})
 }
 
-// TrackClick - PUBLIC endpoint for tracking product clicks
-func (h *AnalyticsHandler) TrackClick(c *fiber.Ctx) error {
-	var input struct {
-		LinkID    uuid.UUID `json:"link_id"`
-		UserID    uuid.UUID `json:"user_id"`
-		VisitorID string    `json:"visitor_id"`
-		Source    string    `json:"source"`
-		Platform  string    `json:"platform"`
-		Category  string    `json:"category"`
-	}
-
-	if err := c.BodyParser(&input); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Invalid request body",
-		})
-	}
-
-	if input.LinkID == uuid.Nil || input.UserID == uuid.Nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"error": "Link ID and User ID are required",
-		})
-	}
-
-	err := h.analyticsService.TrackClick(
-		input.LinkID,
-		input.UserID,
-		input.VisitorID,
-		input.Source,
-		input.Platform,
-		input.Category,
-		c.IP(),
-		c.Get("User-Agent"),
-		c.Get("Referer"),
-	)
-
-	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to track click",
-		})
-	}
-
-	return c.JSON(fiber.Map{"message": "Click tracked"})
-}
-
 // TrackSocialClick - PUBLIC endpoint for tracking social icon clicks
 func (h *AnalyticsHandler) TrackSocialClick(c *fiber.Ctx) error {
 	var input struct {
@@ -143,11 +132,15 @@ func (h *AnalyticsHandler) TrackSocialClick(c *fiber.Ctx) error {
 
 	err := h.analyticsService.TrackSocialClick(
 		input.UserID,
-		input.VisitorID,
-		input.Source,
 		input.SocialType,
+		visitContext(c, h.visitors.Resolve(c, input.VisitorID), input.Source),
 	)
 
+	if errors.Is(err, services.ErrTrackingOwnerNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Profile not found",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to track social click",
@@ -157,12 +150,15 @@ func (h *AnalyticsHandler) TrackSocialClick(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{"message": "Social click tracked"})
 }
 
-// TrackPageView - PUBLIC endpoint for tracking page views
+// TrackPageView - PUBLIC endpoint for the page view beacon. Views of
+// server-rendered navigations carry the page_view_id the profile endpoint
+// returned and are not counted twice.
 func (h *AnalyticsHandler) TrackPageView(c *fiber.Ctx) error {
 	var input struct {
-		UserID    uuid.UUID `json:"user_id"`
-		VisitorID string    `json:"visitor_id"`
-		Source    string    `json:"source"`
+		UserID     uuid.UUID `json:"user_id"`
+		VisitorID  string    `json:"visitor_id"`
+		PageViewID string    `json:"page_view_id"`
+		Source     string    `json:"source"`
 	}
 
 	if err := c.BodyParser(&input); err != nil {
@@ -177,14 +173,17 @@ func (h *AnalyticsHandler) TrackPageView(c *fiber.Ctx) error {
 		})
 	}
 
-	err := h.analyticsService.TrackPageView(
+	err := h.analyticsService.TrackPageViewBeacon(
 		input.UserID,
-		input.VisitorID,
-		input.Source,
-		c.IP(),
-		c.Get("User-Agent"),
+		input.PageViewID,
+		visitContext(c, h.visitors.Resolve(c, input.VisitorID), input.Source),
 	)
 
+	if errors.Is(err, services.ErrTrackingOwnerNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Profile not found",
+		})
+	}
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to track page view",
@@ -200,6 +199,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	if err != nil {
 		return err
 	}
+	analyticsService := h.reportService(c)
 
 	// Get filter params
 	source := c.Query("source", "all")
@@ -219,8 +219,20 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 		to = to.Add(24 * time.Hour)
 	}
 
+	// Optional comparison with the previous period or year
+	compare := c.Query("compare", "")
+	var compareFrom, compareTo time.Time
+	if compare != "" {
+		compareFrom, compareTo, err = services.ComparisonPeriod(compare, from, to)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	// Get overview stats
-	overview, err := h.analyticsService.GetOverview(userID, from, to)
+	overview, err := analyticsService.GetOverview(userID, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get overview",
@@ -228,7 +240,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get top links with filters
-	topLinks, err := h.analyticsService.GetFilteredTopLinks(userID, source, platform, category, from, to, 5)
+	topLinks, err := analyticsService.GetFilteredTopLinks(userID, source, platform, category, from, to, 5)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get top links",
@@ -236,7 +248,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get social click stats (for pie chart)
-	socialStats, err := h.analyticsService.GetSocialClickStats(userID)
+	socialStats, err := analyticsService.GetSocialClickStats(userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get social stats",
@@ -244,7 +256,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get clicks by source
-	clicksBySource, err := h.analyticsService.GetClicksBySource(userID, source, platform, from, to)
+	clicksBySource, err := analyticsService.GetClicksBySource(userID, source, platform, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get clicks by source",
@@ -252,7 +264,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get clicks by platform
-	clicksByPlatform, err := h.analyticsService.GetClicksByPlatform(userID, source, platform, from, to)
+	clicksByPlatform, err := analyticsService.GetClicksByPlatform(userID, source, platform, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get clicks by platform",
@@ -260,15 +272,43 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get clicks by category
-	clicksByCategory, err := h.analyticsService.GetClicksByCategory(userID, source, platform, from, to)
+	clicksByCategory, err := analyticsService.GetClicksByCategory(userID, source, platform, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get clicks by category",
 		})
 	}
 
+	// Get clicks by device type, OS and browser (in-app browsers count as their app)
+	clicksByDevice, err := analyticsService.GetClicksByDevice(userID, source, platform, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get clicks by device",
+		})
+	}
+	clicksByOS, err := analyticsService.GetClicksByOS(userID, source, platform, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get clicks by OS",
+		})
+	}
+	clicksByBrowser, err := analyticsService.GetClicksByBrowser(userID, source, platform, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get clicks by browser",
+		})
+	}
+
+	// Get clicks by country
+	clicksByCountry, err := analyticsService.GetClicksByLocation(userID, repository.GeoCountry, "", source, platform, category, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get clicks by country",
+		})
+	}
+
 	// Get views by source
-	viewsBySource, err := h.analyticsService.GetViewsBySource(userID)
+	viewsBySource, err := analyticsService.GetViewsBySource(userID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get views by source",
@@ -276,7 +316,7 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get daily clicks for chart
-	dailyClicks, err := h.analyticsService.GetDailyClicks(userID, source, platform, from, to)
+	dailyClicks, err := analyticsService.GetDailyClicks(userID, source, platform, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get daily clicks",
@@ -284,31 +324,147 @@ func (h *AnalyticsHandler) GetDashboardStats(c *fiber.Ctx) error {
 	}
 
 	// Get estimated revenue
-	estimatedRevenue, err := h.analyticsService.GetEstimatedRevenue(userID, from, to)
+	// Revenue is optional: a failed estimate is reported as 0 and left out of the comparison
+	var currentRevenue *float64
+	estimatedRevenue, err := analyticsService.GetEstimatedRevenue(userID, source, platform, category, from, to)
 	if err != nil {
-		// Log error but don't fail the request - revenue is optional
+		log.Printf("⚠️  Failed to estimate revenue of user %s: %v", userID, err)
 		estimatedRevenue = 0
+	} else {
+		currentRevenue = &estimatedRevenue
 	}
 
-	return c.JSON(fiber.Map{
+	response := fiber.Map{
 		"overview":           overview,
 		"top_links":          topLinks,
 		"social_stats":       socialStats,
 		"clicks_by_source":   clicksBySource,
 		"clicks_by_platform": clicksByPlatform,
 		"clicks_by_category": clicksByCategory,
+		"clicks_by_country":  clicksByCountry,
+		"clicks_by_device":   clicksByDevice,
+		"clicks_by_os":       clicksByOS,
+		"clicks_by_browser":  clicksByBrowser,
 		"views_by_source":    viewsBySource,
 		"daily_clicks":       dailyClicks,
 		"estimated_revenue":  estimatedRevenue,
+	}
+
+	if compare != "" {
+		previous, err := analyticsService.GetDashboardSnapshot(userID, source, platform, category, compareFrom, compareTo)
+		if err != nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get comparison period",
+			})
+		}
+		comparison := services.CompareDashboards(&services.DashboardSnapshot{
+			Overview:         overview,
+			TopLinks:         topLinks,
+			ClicksBySource:   clicksBySource,
+			ClicksByPlatform: clicksByPlatform,
+			ClicksByCategory: clicksByCategory,
+			ClicksByCountry:  clicksByCountry,
+			ClicksByDevice:   clicksByDevice,
+			ClicksByOS:       clicksByOS,
+			ClicksByBrowser:  clicksByBrowser,
+			EstimatedRevenue: currentRevenue,
+		}, previous)
+		comparison.Mode = compare
+		comparison.From = compareFrom.Format("2006-01-02")
+		comparison.To = compareTo.Add(-24 * time.Hour).Format("2006-01-02")
+		response["comparison"] = comparison
+	}
+
+	return c.JSON(response)
+}
+
+// GetGeoBreakdown - PROTECTED endpoint for clicks by country, region or city
+func (h *AnalyticsHandler) GetGeoBreakdown(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	analyticsService := h.reportService(c)
+
+	// Get query params
+	level := c.Query("level", repository.GeoCountry) // country, region, city
+	country := c.Query("country", "")                // only clicks from this country
+	source := c.Query("source", "all")
+	platform := c.Query("platform", "all")
+	category := c.Query("category", "all")
+	fromStr := c.Query("from", "")
+	toStr := c.Query("to", "")
+
+	// Parse date range
+	var from, to time.Time
+	if fromStr != "" {
+		from, _ = time.Parse("2006-01-02", fromStr)
+	}
+	if toStr != "" {
+		to, _ = time.Parse("2006-01-02", toStr)
+		to = to.Add(24 * time.Hour)
+	}
+
+	data, err := analyticsService.GetClicksByLocation(userID, level, country, source, platform, category, from, to)
+	if errors.Is(err, services.ErrInvalidGeoLevel) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get geo breakdown",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"data":  data,
+		"level": level,
 	})
 }
 
+// GetRevenue - PROTECTED endpoint for the estimated commission by link, platform, category, source and day
+func (h *AnalyticsHandler) GetRevenue(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+	analyticsService := h.reportService(c)
+
+	// Get filter params
+	source := c.Query("source", "all")
+	platform := c.Query("platform", "all")
+	category := c.Query("category", "all")
+	fromStr := c.Query("from", "")
+	toStr := c.Query("to", "")
+
+	// Parse date range
+	var from, to time.Time
+	if fromStr != "" {
+		from, _ = time.Parse("2006-01-02", fromStr)
+	}
+	if toStr != "" {
+		to, _ = time.Parse("2006-01-02", toStr)
+		to = to.Add(24 * time.Hour)
+	}
+
+	revenue, err := analyticsService.GetRevenueBreakdown(userID, source, platform, category, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get estimated revenue",
+		})
+	}
+
+	return c.JSON(revenue)
+}
+
 // GetTimelineChart - PROTECTED endpoint for timeline chart with grouping
 func (h *AnalyticsHandler) GetTimelineChart(c *fiber.Ctx) error {
 	userID, err := middleware.GetUserID(c)
 	if err != nil {
 		return err
 	}
+	analyticsService := h.reportService(c)
 
 	// Get query params
 	timeGroup := c.Query("time_group", "daily") // daily, weekly, monthly
@@ -329,7 +485,7 @@ func (h *AnalyticsHandler) GetTimelineChart(c *fiber.Ctx) error {
 		to = to.Add(24 * time.Hour)
 	}
 
-	data, err := h.analyticsService.GetTimelineClicksByGroup(userID, timeGroup, groupBy, source, platform, category, from, to)
+	data, err := analyticsService.GetTimelineClicksByGroup(userID, timeGroup, groupBy, source, platform, category, from, to)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get timeline data",
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+func TestDashboardComparesWithPreviousPeriod(t *testing.T) {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	linkRepo := repository.NewLinkRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+
+	user := &models.User{Email: "eka@example.com", Username: "eka", PasswordHash: "hash"}
+	require.NoError(t, userRepo.Create(user))
+	bag := &models.Link{UserID: user.ID, Title: "Bag", URL: "https://shopee.co.id/bag", Platform: "shopee", Price: 100000, IsActive: true}
+	require.NoError(t, linkRepo.Create(bag))
+
+	// Three clicks this week, the first right at its start, two the week before and one a year ago
+	week := time.Date(2024, 6, 10, 0, 0, 0, 0, time.UTC)
+	for _, click := range []struct {
+		source, country string
+		at              time.Time
+	}{
+		{"instagram", "ID", week},
+		{"instagram", "ID", week.AddDate(0, 0, 2)},
+		{"tiktok", "SG", week.AddDate(0, 0, 6)},
+		{"instagram", "ID", week.AddDate(0, 0, -3)},
+		{"whatsapp", "MY", week.AddDate(0, 0, -7)},
+		{"instagram", "ID", week.AddDate(-1, 0, 1)},
+	} {
+		require.NoError(t, analyticsRepo.CreateClick(&models.LinkClick{
+			LinkID: &bag.ID, UserID: user.ID, Source: click.source, Platform: "shopee", Country: click.country,
+			DeviceType: "mobile", ClickedAt: click.at,
+		}))
+	}
+
+	analyticsService := services.NewAnalyticsService(analyticsRepo, linkRepo, nil, nil, services.BotFilter{}, nil, nil)
+	handler := NewAnalyticsHandler(analyticsService, VisitorCookie{})
+	app := fiber.New()
+	app.Get("/api/analytics/dashboard", func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.MustParse(c.Get("X-Test-User")))
+		return c.Next()
+	}, handler.GetDashboardStats)
+
+	dashboard := func(query string) (int, map[string]json.RawMessage) {
+		req := httptest.NewRequest(http.MethodGet, "/api/analytics/dashboard?from=2024-06-10&to=2024-06-16"+query, nil)
+		req.Header.Set("X-Test-User", user.ID.String())
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		var body map[string]json.RawMessage
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return resp.StatusCode, body
+	}
+
+	status, body := dashboard("")
+	require.Equal(t, http.StatusOK, status)
+	assert.NotContains(t, body, "comparison")
+
+	status, body = dashboard("&compare=previous_period")
+	require.Equal(t, http.StatusOK, status)
+	var comparison services.DashboardComparison
+	require.NoError(t, json.Unmarshal(body["comparison"], &comparison))
+	assert.Equal(t, "2024-06-03", comparison.From)
+	assert.Equal(t, "2024-06-09", comparison.To)
+
+	fifty := 50.0
+	assert.Equal(t, services.Delta{Current: 3, Previous: 2, DeltaPercent: &fifty}, comparison.Overview.TotalClicks)
+	assert.Equal(t, &services.Delta{Current: 6000, Previous: 4000, DeltaPercent: &fifty}, comparison.Overview.EstimatedRevenue)
+	assert.Equal(t, []services.Delta{{Key: bag.ID.String(), Label: "Bag", Current: 3, Previous: 2, DeltaPercent: &fifty}}, comparison.TopLinks)
+
+	hundred := 100.0
+	minusHundred := -100.0
+	assert.Equal(t, []services.Delta{
+		{Key: "instagram", Current: 2, Previous: 1, DeltaPercent: &hundred},
+		{Key: "tiktok", Current: 1}, // New this period
+		{Key: "whatsapp", Previous: 1, DeltaPercent: &minusHundred},
+	}, comparison.ClicksBySource)
+	assert.Equal(t, []services.Delta{
+		{Key: "ID", Current: 2, Previous: 1, DeltaPercent: &hundred},
+		{Key: "SG", Current: 1},
+		{Key: "MY", Previous: 1, DeltaPercent: &minusHundred},
+	}, comparison.ClicksByCountry)
+	assert.Equal(t, []services.Delta{{Key: "mobile", Current: 3, Previous: 2, DeltaPercent: &fifty}}, comparison.ClicksByDevice)
+
+	status, body = dashboard("&compare=previous_year")
+	require.Equal(t, http.StatusOK, status)
+	require.NoError(t, json.Unmarshal(body["comparison"], &comparison))
+	assert.Equal(t, "2023-06-10", comparison.From)
+	assert.Equal(t, float64(1), comparison.Overview.TotalClicks.Previous)
+
+	status, _ = dashboard("&compare=yesterday")
+	assert.Equal(t, http.StatusBadRequest, status)
+
+	// Without a revenue estimate the dashboard still loads and compares the rest
+	require.NoError(t, db.Migrator().DropTable(&models.CommissionRate{}))
+	status, body = dashboard("&compare=previous_period")
+	require.Equal(t, http.StatusOK, status)
+	assert.JSONEq(t, "0", string(body["estimated_revenue"]))
+	var withoutRevenue struct {
+		Overview map[string]json.RawMessage `json:"overview"`
+	}
+	require.NoError(t, json.Unmarshal(body["comparison"], &withoutRevenue))
+	assert.NotContains(t, withoutRevenue.Overview, "estimated_revenue")
+	assert.Contains(t, withoutRevenue.Overview, "total_clicks")
+}
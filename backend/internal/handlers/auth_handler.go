@@ -1,11 +1,15 @@
 package handlers
 
 import (
+	"errors"
+	"math"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
+	"github.com/onedash/backend/internal/middleware"
 	"github.com/onedash/backend/internal/services"
 )
 
@@ -61,7 +65,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.authService.Register(&input)
+	response, err := h.authService.Register(&input, clientInfo(c))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
@@ -85,8 +89,53 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
-	response, err := h.authService.Login(&input)
+	response, err := h.authService.Login(&input, clientInfo(c))
 	if err != nil {
+		var locked *services.LockedError
+		if errors.As(err, &locked) {
+			return lockedResponse(c, locked)
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+				"code":  "account_suspended",
+			})
+		}
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// VerifyTwoFactor completes a login for accounts with two-factor authentication
+func (h *AuthHandler) VerifyTwoFactor(c *fiber.Ctx) error {
+	var input services.VerifyTwoFactorInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if input.ChallengeToken == "" || input.Code == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Challenge token and code are required",
+		})
+	}
+
+	response, err := h.authService.VerifyTwoFactor(&input, clientInfo(c))
+	if err != nil {
+		var locked *services.LockedError
+		if errors.As(err, &locked) {
+			return lockedResponse(c, locked)
+		}
+		if errors.Is(err, services.ErrAccountSuspended) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": err.Error(),
+				"code":  "account_suspended",
+			})
+		}
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -96,8 +145,61 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 }
 
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
-	// TODO: Implement refresh token logic
-	return c.Status(fiber.StatusNotImplemented).JSON(fiber.Map{
-		"error": "Not implemented",
+	var input services.RefreshInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if input.RefreshToken == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Refresh token is required",
+		})
+	}
+
+	response, err := h.authService.Refresh(&input, clientInfo(c))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(response)
+}
+
+// GetLoginAttempts - PROTECTED endpoint listing failed logins on the user's account
+func (h *AuthHandler) GetLoginAttempts(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	attempts, err := h.authService.GetFailedLoginAttempts(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get login attempts",
+		})
+	}
+
+	return c.JSON(attempts)
+}
+
+// lockedResponse tells the client how long to wait before trying again
+func lockedResponse(c *fiber.Ctx, locked *services.LockedError) error {
+	retryAfter := int(math.Ceil(locked.RetryAfter.Seconds()))
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(retryAfter))
+	return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       locked.Error(),
+		"code":        "account_locked",
+		"retry_after": retryAfter,
 	})
 }
+
+// clientInfo captures the device details stored on a session
+func clientInfo(c *fiber.Ctx) services.ClientInfo {
+	return services.ClientInfo{
+		IP:        c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}
+}
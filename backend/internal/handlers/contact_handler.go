@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
 	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/repository"
 	"github.com/onedash/backend/internal/services"
 )
 
@@ -62,6 +65,11 @@ func (h *ContactHandler) CreateContact(c *fiber.Ctx) error {
 }
 
 func (h *ContactHandler) UpdateContact(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
 	contactID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -76,8 +84,13 @@ func (h *ContactHandler) UpdateContact(c *fiber.Ctx) error {
 		})
 	}
 
-	contact, err := h.contactService.UpdateContact(contactID, &input)
+	contact, err := h.contactService.UpdateContact(userID, contactID, &input)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Contact not found",
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -87,6 +100,11 @@ func (h *ContactHandler) UpdateContact(c *fiber.Ctx) error {
 }
 
 func (h *ContactHandler) DeleteContact(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
 	contactID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -94,7 +112,12 @@ func (h *ContactHandler) DeleteContact(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.contactService.DeleteContact(contactID); err != nil {
+	if err := h.contactService.DeleteContact(userID, contactID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Contact not found",
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"errors"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/orderreport"
+	"github.com/onedash/backend/internal/services"
+)
+
+type ConversionHandler struct {
+	conversionService *services.ConversionService
+}
+
+func NewConversionHandler(conversionService *services.ConversionService) *ConversionHandler {
+	return &ConversionHandler{conversionService: conversionService}
+}
+
+// ImportReport - PROTECTED endpoint for uploading a marketplace affiliate
+// report (multipart "file" and "platform")
+func (h *ConversionHandler) ImportReport(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.FormFile("file")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Report file is required",
+		})
+	}
+	if file.Size > orderreport.MaxSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": orderreport.ErrTooLarge.Error(),
+		})
+	}
+
+	report, err := file.Open()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read report",
+		})
+	}
+	defer report.Close()
+
+	imp, err := h.conversionService.Import(userID, c.FormValue("platform"), file.Filename, report)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidReportPlatform) ||
+			errors.Is(err, orderreport.ErrUnsupportedFormat) ||
+			errors.Is(err, orderreport.ErrTooLarge) ||
+			errors.Is(err, orderreport.ErrNoHeader) ||
+			errors.Is(err, orderreport.ErrUnreadable) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to import report",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(imp)
+}
+
+// GetImports - PROTECTED endpoint listing the latest report imports
+func (h *ConversionHandler) GetImports(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	imports, err := h.conversionService.GetImports(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get imports",
+		})
+	}
+
+	return c.JSON(imports)
+}
+
+// GetCommissionReport - PROTECTED endpoint comparing actual and estimated commission
+func (h *ConversionHandler) GetCommissionReport(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	// Get query params
+	timeGroup := c.Query("time_group", "daily") // daily, weekly, monthly
+	fromStr := c.Query("from", "")
+	toStr := c.Query("to", "")
+
+	// Parse date range
+	var from, to time.Time
+	if fromStr != "" {
+		from, _ = time.Parse("2006-01-02", fromStr)
+	}
+	if toStr != "" {
+		to, _ = time.Parse("2006-01-02", toStr)
+		to = to.Add(24 * time.Hour)
+	}
+
+	report, err := h.conversionService.GetCommissionReport(userID, timeGroup, from, to)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get commission report",
+		})
+	}
+
+	return c.JSON(report)
+}
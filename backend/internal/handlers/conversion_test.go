@@ -0,0 +1,171 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/orderreport"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+	"github.com/onedash/backend/internal/subid"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+// reportRequest uploads an affiliate report as the creator userID
+func reportRequest(t *testing.T, userID uuid.UUID, platform, filename, report string) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	require.NoError(t, form.WriteField("platform", platform))
+	file, err := form.CreateFormFile("file", filename)
+	require.NoError(t, err)
+	_, err = file.Write([]byte(report))
+	require.NoError(t, err)
+	require.NoError(t, form.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/api/conversions/import", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Test-User", userID.String())
+	return req
+}
+
+func TestImportedConversionsAreMatchedAndCompared(t *testing.T) {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	linkRepo := repository.NewLinkRepository(db)
+
+	user := &models.User{Email: "dina@example.com", Username: "dina", PasswordHash: "hash"}
+	require.NoError(t, userRepo.Create(user))
+	serum := &models.Link{UserID: user.ID, Title: "Serum", URL: "https://shopee.co.id/Serum-i.123.111", Platform: "shopee", Price: 100000, IsActive: true}
+	require.NoError(t, linkRepo.Create(serum))
+	toner := &models.Link{UserID: user.ID, Title: "Toner", URL: "https://id.shp.ee/abc", Platform: "shopee", Price: 50000, IsActive: true, Position: 1}
+	require.NoError(t, linkRepo.Create(toner))
+
+	day := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 2; i++ {
+		require.NoError(t, db.Create(&models.LinkClick{LinkID: &serum.ID, UserID: user.ID, Platform: "shopee", ClickedAt: day}).Error)
+	}
+
+	handler := NewConversionHandler(services.NewConversionService(
+		repository.NewConversionRepository(db), repository.NewAnalyticsRepository(db), linkRepo))
+	app := fiber.New()
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.MustParse(c.Get("X-Test-User")))
+		return c.Next()
+	})
+	api.Post("/conversions/import", handler.ImportReport)
+	api.Get("/analytics/commission", handler.GetCommissionReport)
+
+	// Matched by product ID, by sub-ID (short links carry no product ID), and not at all
+	report := `Order id,Order Status,Order Time,Item id,Net Commission(Rp),Sub_id1,Sub_id2
+A1,Completed,2024-03-01 12:00:00,111,5000,,
+A2,Cancelled,2024-03-01 13:00:00,111,4000,,
+B1,Pending,2024-03-01 14:00:00,222,2000,` + subid.LinkValue(toner.ID) + `,ig_bio
+C1,Completed,2024-03-01 15:00:00,999,1000,,
+`
+	resp, err := app.Test(reportRequest(t, user.ID, "shopee", "conversions.csv", report))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var imp models.ConversionImport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&imp))
+	assert.Equal(t, 4, imp.RowCount)
+	assert.Equal(t, 3, imp.Matched)
+
+	// A newer report updates the orders instead of adding them again
+	resp, err = app.Test(reportRequest(t, user.ID, "shopee", "conversions.csv",
+		`Order id,Order Status,Order Time,Item id,Net Commission(Rp),Sub_id1,Sub_id2
+B1,Completed,2024-03-01 14:00:00,222,2000,`+subid.LinkValue(toner.ID)+`,ig_bio
+`))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+	var stored []models.Conversion
+	require.NoError(t, db.Order("order_id").Find(&stored).Error)
+	require.Len(t, stored, 4)
+	assert.Equal(t, models.ConversionCompleted, stored[2].Status)
+	assert.Equal(t, "ig_bio", stored[2].Source)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/analytics/commission?from=2024-03-01&to=2024-03-01", nil)
+	req.Header.Set("X-Test-User", user.ID.String())
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var got services.CommissionReport
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+
+	// Two clicks on a 100,000 product at the default 2%
+	assert.Equal(t, services.CommissionComparison{Clicks: 2, Orders: 3, Estimated: 4000, Actual: 8000}, got.Total)
+	assert.Equal(t, services.CommissionComparison{Orders: 1, Actual: 1000}, got.Unmatched)
+	require.Len(t, got.ByLink, 2)
+	assert.Equal(t, services.CommissionComparison{Key: serum.ID.String(), Label: "Serum", Clicks: 2, Orders: 1, Estimated: 4000, Actual: 5000}, got.ByLink[0])
+	assert.Equal(t, services.CommissionComparison{Key: toner.ID.String(), Label: "Toner", Orders: 1, Actual: 2000}, got.ByLink[1])
+	require.Len(t, got.ByPeriod, 1)
+	assert.Equal(t, "2024-03-01", got.ByPeriod[0].Key)
+
+	// Unknown platforms and formats are rejected
+	resp, err = app.Test(reportRequest(t, user.ID, "ebay", "conversions.csv", report))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	resp, err = app.Test(reportRequest(t, user.ID, "shopee", "conversions.pdf", report))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
+
+func TestOnlyReportImportsAcceptLargeBodies(t *testing.T) {
+	db := testutil.NewDB(t)
+	user := &models.User{Email: "dina@example.com", Username: "dina", PasswordHash: "hash"}
+	require.NoError(t, repository.NewUserRepository(db).Create(user))
+	handler := NewConversionHandler(services.NewConversionService(
+		repository.NewConversionRepository(db), repository.NewAnalyticsRepository(db), repository.NewLinkRepository(db)))
+
+	// Configured like the server
+	app := fiber.New(fiber.Config{StreamRequestBody: true, DisablePreParseMultipartForm: true})
+	app.Use(middleware.BodyLimit(fiber.DefaultBodyLimit, map[string]int{
+		"/api/conversions/import": orderreport.MaxSize + 1<<20,
+	}))
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		c.Locals("userID", uuid.MustParse(c.Get("X-Test-User")))
+		return c.Next()
+	})
+	api.Post("/conversions/import", handler.ImportReport)
+	api.Post("/links", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"size": len(c.Body())})
+	})
+
+	// A report over the default limit is imported
+	large := strings.Repeat("x", fiber.DefaultBodyLimit)
+	resp, err := app.Test(reportRequest(t, user.ID, "shopee", "conversions.csv",
+		"Order id,Order Status,Order Time,Item id,Net Commission(Rp),Note\nA1,Completed,2024-03-01 12:00:00,111,5000,"+large+"\n"), -1)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	post := func(body *strings.Reader, contentLength int64) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/links", body)
+		req.ContentLength = contentLength
+		if contentLength < 0 {
+			req.TransferEncoding = []string{"chunked"}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("X-Test-User", user.ID.String())
+		resp, err := app.Test(req, -1)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// Other routes keep the default limit, whether or not the size is announced
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(strings.NewReader(large+"x"), int64(len(large)+1)))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, post(strings.NewReader(large+"x"), -1))
+	assert.Equal(t, http.StatusOK, post(strings.NewReader(`{"title":"Serum"}`), -1))
+	assert.Equal(t, http.StatusOK, post(strings.NewReader(large), int64(len(large))))
+}
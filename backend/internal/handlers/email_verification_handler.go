@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/services"
+)
+
+type EmailVerificationHandler struct {
+	emailVerificationService *services.EmailVerificationService
+}
+
+func NewEmailVerificationHandler(emailVerificationService *services.EmailVerificationService) *EmailVerificationHandler {
+	return &EmailVerificationHandler{emailVerificationService: emailVerificationService}
+}
+
+// VerifyEmail - PUBLIC endpoint opened from the link in the verification email
+func (h *EmailVerificationHandler) VerifyEmail(c *fiber.Ctx) error {
+	var input services.VerifyEmailInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if input.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Token is required",
+		})
+	}
+
+	user, err := h.emailVerificationService.VerifyEmail(&input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidVerificationToken) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		if errors.Is(err, services.ErrEmailTaken) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to verify email",
+		})
+	}
+
+	return c.JSON(user)
+}
+
+// ResendVerification - PROTECTED endpoint to request a new verification email
+func (h *EmailVerificationHandler) ResendVerification(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	if err := h.emailVerificationService.ResendVerification(userID); err != nil {
+		if errors.Is(err, services.ErrEmailAlreadyVerified) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to send verification email",
+		})
+	}
+
+	return c.JSON(fiber.Map{"message": "Verification email sent"})
+}
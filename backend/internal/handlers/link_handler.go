@@ -1,10 +1,13 @@
 package handlers
 
 import (
+	"errors"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 
 	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/repository"
 	"github.com/onedash/backend/internal/services"
 	"github.com/onedash/backend/internal/services/scraper"
 )
@@ -67,6 +70,11 @@ func (h *LinkHandler) CreateLink(c *fiber.Ctx) error {
 }
 
 func (h *LinkHandler) UpdateLink(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
 	linkID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -81,8 +89,13 @@ func (h *LinkHandler) UpdateLink(c *fiber.Ctx) error {
 		})
 	}
 
-	link, err := h.linkService.UpdateLink(linkID, &input)
+	link, err := h.linkService.UpdateLink(userID, linkID, &input)
 	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Link not found",
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -92,6 +105,11 @@ func (h *LinkHandler) UpdateLink(c *fiber.Ctx) error {
 }
 
 func (h *LinkHandler) DeleteLink(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
 	linkID, err := uuid.Parse(c.Params("id"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -99,7 +117,12 @@ func (h *LinkHandler) DeleteLink(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.linkService.DeleteLink(linkID); err != nil {
+	if err := h.linkService.DeleteLink(userID, linkID); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Link not found",
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
@@ -122,6 +145,11 @@ func (h *LinkHandler) ReorderLinks(c *fiber.Ctx) error {
 	}
 
 	if err := h.linkService.ReorderLinks(userID, &input); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Link not found",
+			})
+		}
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
 		})
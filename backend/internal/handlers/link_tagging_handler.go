@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/services"
+)
+
+type LinkTaggingHandler struct {
+	taggingService *services.LinkTaggingService
+}
+
+func NewLinkTaggingHandler(taggingService *services.LinkTaggingService) *LinkTaggingHandler {
+	return &LinkTaggingHandler{taggingService: taggingService}
+}
+
+func (h *LinkTaggingHandler) GetSettings(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	settings, err := h.taggingService.GetSettings(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get link tagging settings",
+		})
+	}
+
+	return c.JSON(fiber.Map{"platforms": settings})
+}
+
+func (h *LinkTaggingHandler) UpdateSettings(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input services.UpdateLinkTaggingInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	settings, err := h.taggingService.UpdateSettings(userID, &input)
+	if err != nil {
+		if errors.Is(err, services.ErrUnsupportedTaggingPlatform) || errors.Is(err, services.ErrInvalidAffiliateID) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update link tagging settings",
+		})
+	}
+
+	return c.JSON(fiber.Map{"platforms": settings})
+}
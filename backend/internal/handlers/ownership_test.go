@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+// ownershipFixture has two creators, each owning one link and one contact
+type ownershipFixture struct {
+	app                      *fiber.App
+	db                       *gorm.DB
+	alice, bob               *models.User
+	aliceLink, bobLink       *models.Link
+	aliceContact, bobContact *models.Contact
+}
+
+func newOwnershipFixture(t *testing.T) *ownershipFixture {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	linkRepo := repository.NewLinkRepository(db)
+	contactRepo := repository.NewContactRepository(db)
+
+	f := &ownershipFixture{db: db}
+	for _, name := range []string{"alice", "bob"} {
+		user := &models.User{Email: name + "@example.com", Username: name, PasswordHash: "hash"}
+		require.NoError(t, userRepo.Create(user))
+
+		link := &models.Link{UserID: user.ID, Title: name + " link", URL: "https://shopee.co.id/" + name, IsActive: true}
+		require.NoError(t, linkRepo.Create(link))
+
+		contact := &models.Contact{UserID: user.ID, Type: "instagram", URL: "https://instagram.com/" + name}
+		require.NoError(t, contactRepo.Create(contact))
+
+		if name == "alice" {
+			f.alice, f.aliceLink, f.aliceContact = user, link, contact
+		} else {
+			f.bob, f.bobLink, f.bobContact = user, link, contact
+		}
+	}
+
+	linkHandler := NewLinkHandler(services.NewLinkService(linkRepo), nil)
+	contactHandler := NewContactHandler(services.NewContactService(contactRepo))
+
+	// Stand-in for AuthMiddleware: the test picks the authenticated user
+	app := fiber.New()
+	api := app.Group("/api", func(c *fiber.Ctx) error {
+		userID, err := uuid.Parse(c.Get("X-Test-User"))
+		if err != nil {
+			return fiber.ErrUnauthorized
+		}
+		c.Locals("userID", userID)
+		return c.Next()
+	})
+	api.Get("/links", linkHandler.GetLinks)
+	api.Put("/links/:id", linkHandler.UpdateLink)
+	api.Delete("/links/:id", linkHandler.DeleteLink)
+	api.Post("/links/reorder", linkHandler.ReorderLinks)
+	api.Get("/contacts", contactHandler.GetContacts)
+	api.Put("/contacts/:id", contactHandler.UpdateContact)
+	api.Delete("/contacts/:id", contactHandler.DeleteContact)
+	f.app = app
+
+	return f
+}
+
+func (f *ownershipFixture) do(t *testing.T, as *models.User, method, path, body string) (int, string) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-User", as.ID.String())
+
+	resp, err := f.app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	return resp.StatusCode, string(data)
+}
+
+func TestCrossAccountMutationsReturnNotFound(t *testing.T) {
+	f := newOwnershipFixture(t)
+
+	cases := []struct {
+		name, method, path, body string
+	}{
+		{"update link", http.MethodPut, "/api/links/" + f.bobLink.ID.String(), `{"title":"hijacked"}`},
+		{"delete link", http.MethodDelete, "/api/links/" + f.bobLink.ID.String(), ""},
+		{"reorder links", http.MethodPost, "/api/links/reorder", fmt.Sprintf(
+			`{"links":[{"id":"%s","position":5},{"id":"%s","position":0}]}`, f.aliceLink.ID, f.bobLink.ID)},
+		{"update contact", http.MethodPut, "/api/contacts/" + f.bobContact.ID.String(), `{"url":"https://evil.example"}`},
+		{"delete contact", http.MethodDelete, "/api/contacts/" + f.bobContact.ID.String(), ""},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			status, body := f.do(t, f.alice, tc.method, tc.path, tc.body)
+			assert.Equal(t, http.StatusNotFound, status, body)
+		})
+	}
+
+	// Bob's data is untouched, and the rejected reorder did not move Alice's link either
+	var bobLink models.Link
+	require.NoError(t, f.db.First(&bobLink, "id = ?", f.bobLink.ID).Error)
+	assert.Equal(t, f.bobLink.Title, bobLink.Title)
+	assert.Equal(t, f.bobLink.Position, bobLink.Position)
+
+	var aliceLink models.Link
+	require.NoError(t, f.db.First(&aliceLink, "id = ?", f.aliceLink.ID).Error)
+	assert.Equal(t, f.aliceLink.Position, aliceLink.Position)
+
+	var bobContact models.Contact
+	require.NoError(t, f.db.First(&bobContact, "id = ?", f.bobContact.ID).Error)
+	assert.Equal(t, f.bobContact.URL, bobContact.URL)
+}
+
+func TestOwnerCanMutateOwnResources(t *testing.T) {
+	f := newOwnershipFixture(t)
+
+	status, body := f.do(t, f.alice, http.MethodPut, "/api/links/"+f.aliceLink.ID.String(), `{"title":"renamed"}`)
+	assert.Equal(t, http.StatusOK, status, body)
+
+	status, body = f.do(t, f.alice, http.MethodPost, "/api/links/reorder",
+		fmt.Sprintf(`{"links":[{"id":"%s","position":3}]}`, f.aliceLink.ID))
+	assert.Equal(t, http.StatusOK, status, body)
+
+	status, body = f.do(t, f.alice, http.MethodPut, "/api/contacts/"+f.aliceContact.ID.String(), `{"url":"https://instagram.com/alice2"}`)
+	assert.Equal(t, http.StatusOK, status, body)
+
+	status, _ = f.do(t, f.alice, http.MethodDelete, "/api/contacts/"+f.aliceContact.ID.String(), "")
+	assert.Equal(t, http.StatusNoContent, status)
+
+	status, _ = f.do(t, f.alice, http.MethodDelete, "/api/links/"+f.aliceLink.ID.String(), "")
+	assert.Equal(t, http.StatusNoContent, status)
+}
+
+func TestListingsOnlyContainOwnResources(t *testing.T) {
+	f := newOwnershipFixture(t)
+
+	status, body := f.do(t, f.alice, http.MethodGet, "/api/links", "")
+	require.Equal(t, http.StatusOK, status)
+	var links []models.Link
+	require.NoError(t, json.Unmarshal([]byte(body), &links))
+	require.Len(t, links, 1)
+	assert.Equal(t, f.aliceLink.ID, links[0].ID)
+
+	status, body = f.do(t, f.alice, http.MethodGet, "/api/contacts", "")
+	require.Equal(t, http.StatusOK, status)
+	var contacts []models.Contact
+	require.NoError(t, json.Unmarshal([]byte(body), &contacts))
+	require.Len(t, contacts, 1)
+	assert.Equal(t, f.aliceContact.ID, contacts[0].ID)
+}
@@ -37,6 +37,11 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 		return err
 	}
 
+	sessionID, err := middleware.GetSessionID(c)
+	if err != nil {
+		return err
+	}
+
 	var input services.UpdateProfileInput
 	if err := c.BodyParser(&input); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
@@ -44,7 +49,7 @@ func (h *ProfileHandler) UpdateProfile(c *fiber.Ctx) error {
 		})
 	}
 
-	profile, err := h.profileService.UpdateProfile(userID, &input)
+	profile, err := h.profileService.UpdateProfile(userID, sessionID, &input)
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": err.Error(),
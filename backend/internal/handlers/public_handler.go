@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"log"
 	"strconv"
 
 	"github.com/gofiber/fiber/v2"
@@ -14,6 +15,9 @@ type PublicHandler struct {
 	linkRepo      *repository.LinkRepository
 	contactRepo   *repository.ContactRepository
 	analyticsRepo *repository.AnalyticsRepository
+	analytics     *services.AnalyticsService
+	linkSigner    *services.LinkSigner
+	visitors      VisitorCookie
 }
 
 func NewPublicHandler(
@@ -21,12 +25,18 @@ func NewPublicHandler(
 	linkRepo *repository.LinkRepository,
 	contactRepo *repository.ContactRepository,
 	analyticsRepo *repository.AnalyticsRepository,
+	analytics *services.AnalyticsService,
+	linkSigner *services.LinkSigner,
+	visitors VisitorCookie,
 ) *PublicHandler {
 	return &PublicHandler{
 		userRepo:      userRepo,
 		linkRepo:      linkRepo,
 		contactRepo:   contactRepo,
 		analyticsRepo: analyticsRepo,
+		analytics:     analytics,
+		linkSigner:    linkSigner,
+		visitors:      visitors,
 	}
 }
 
@@ -66,6 +76,7 @@ type LinkResponse struct {
 	Rating        float64 `json:"rating"`
 	Sold          int     `json:"sold"`
 	Category      string  `json:"category"`
+	Platform      string  `json:"platform"`
 }
 
 type PublicStatsResponse struct {
@@ -84,15 +95,25 @@ func (h *PublicHandler) GetPublicProfile(c *fiber.Ctx) error {
 
 	// Get user
 	user, err := h.userRepo.FindByUsername(username)
-	if err != nil {
+	if err != nil || user.IsSuspended() {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "User not found",
 		})
 	}
 
-	// Track page view (visitor tracking will be done client-side)
-	analyticsService := services.NewAnalyticsService(h.analyticsRepo, h.linkRepo)
-	analyticsService.TrackPageView(user.ID, "", "", c.IP(), c.Get("User-Agent"))
+	// A browser navigation is counted here; the page's beacon then reports the
+	// returned page view ID and is not counted again. Other fetches leave the
+	// view to the beacon.
+	visitorID := h.visitors.Resolve(c, c.Get(visitorIDHeader))
+	c.Set(visitorIDHeader, visitorID)
+	if isBrowserNavigation(c) {
+		pageViewID, err := h.analytics.TrackNavigation(user.ID, visitContext(c, visitorID, c.Query("utm_source", "direct")))
+		if err != nil {
+			log.Printf("⚠️  Failed to track page view of %s: %v", user.ID, err)
+		} else {
+			c.Set(pageViewIDHeader, pageViewID)
+		}
+	}
 
 	// Get contacts
 	contacts, _ := h.contactRepo.FindByUserID(user.ID)
@@ -117,7 +138,7 @@ func (h *PublicHandler) GetPublicProfile(c *fiber.Ctx) error {
 			ID:            link.ID.String(),
 			Title:         link.Title,
 			Subtitle:      link.Subtitle,
-			URL:           link.URL,
+			URL:           h.linkSigner.RedirectURL(link.ID), // clicks are recorded by the redirect
 			Image:         link.ImageURL,
 			Price:         link.Price,
 			OriginalPrice: link.OriginalPrice,
@@ -126,6 +147,7 @@ func (h *PublicHandler) GetPublicProfile(c *fiber.Ctx) error {
 			Rating:        link.Rating,
 			Sold:          link.Sold,
 			Category:      link.Category,
+			Platform:      link.Platform,
 		}
 
 		if link.Category != "" {
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+)
+
+type RedirectHandler struct {
+	redirectService *services.RedirectService
+	visitors        VisitorCookie
+}
+
+func NewRedirectHandler(redirectService *services.RedirectService, visitors VisitorCookie) *RedirectHandler {
+	return &RedirectHandler{redirectService: redirectService, visitors: visitors}
+}
+
+// Redirect - PUBLIC endpoint behind every product link: records the click and
+// sends the visitor on to the marketplace
+func (h *RedirectHandler) Redirect(c *fiber.Ctx) error {
+	linkID, err := uuid.Parse(c.Params("linkID"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Link not found",
+		})
+	}
+
+	visitorID := h.visitors.Resolve(c, c.Query("vid"))
+	target, err := h.redirectService.Resolve(linkID, c.Query("exp"), c.Query("sig"), visitContext(c, visitorID, c.Query("utm_source", "direct")))
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Link not found",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to open link",
+		})
+	}
+
+	// Every visit must reach the server to be counted
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Redirect(target, fiber.StatusFound)
+}
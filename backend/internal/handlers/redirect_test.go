@@ -0,0 +1,298 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/botdetect"
+	"github.com/onedash/backend/internal/geoip"
+	"github.com/onedash/backend/internal/ingest"
+	"github.com/onedash/backend/internal/iphash"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/services"
+	"github.com/onedash/backend/internal/subid"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+type redirectFixture struct {
+	app      *fiber.App
+	db       *gorm.DB
+	pipeline *ingest.Pipeline
+	signer   *services.LinkSigner
+	settings *services.TrackingSettingsService
+	tagging  *services.LinkTaggingService
+	geo      *stubGeo
+	user     *models.User
+	link     *models.Link
+}
+
+func newRedirectFixture(t *testing.T) *redirectFixture {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	linkRepo := repository.NewLinkRepository(db)
+	contactRepo := repository.NewContactRepository(db)
+	analyticsRepo := repository.NewAnalyticsRepository(db)
+
+	user := &models.User{Email: "carol@example.com", Username: "carol", PasswordHash: "hash"}
+	require.NoError(t, userRepo.Create(user))
+	link := &models.Link{UserID: user.ID, Title: "Sneakers", URL: "https://shopee.co.id/sneakers", Platform: "shopee", IsActive: true}
+	require.NoError(t, linkRepo.Create(link))
+
+	signer := services.NewLinkSigner("test-secret", "https://api.example.com", time.Hour)
+	pipeline := ingest.New(analyticsRepo, ingest.Options{FlushInterval: time.Hour})
+	t.Cleanup(func() { pipeline.Shutdown(context.Background()) })
+	bots := services.BotFilter{Classifier: botdetect.New(botdetect.Options{})}
+	settings := services.NewTrackingSettingsService(repository.NewTrackingSettingsRepository(db))
+	geo := &stubGeo{}
+	analyticsService := services.NewAnalyticsService(analyticsRepo, linkRepo, settings, pipeline, bots,
+		iphash.New(repository.NewVisitorSaltRepository(db)), geo)
+	visitors := VisitorCookie{}
+	tagging := services.NewLinkTaggingService(repository.NewLinkTaggingRepository(db))
+	redirectHandler := NewRedirectHandler(services.NewRedirectService(linkRepo, userRepo, analyticsService, signer, tagging), visitors)
+	publicHandler := NewPublicHandler(userRepo, linkRepo, contactRepo, analyticsRepo, analyticsService, signer, visitors)
+	analyticsHandler := NewAnalyticsHandler(analyticsService, visitors)
+
+	app := fiber.New()
+	app.Get("/go/:linkID", redirectHandler.Redirect)
+	app.Get("/api/u/:username", publicHandler.GetPublicProfile)
+	app.Post("/api/analytics/pageview", analyticsHandler.TrackPageView)
+
+	return &redirectFixture{app: app, db: db, pipeline: pipeline, signer: signer, settings: settings, tagging: tagging, geo: geo, user: user, link: link}
+}
+
+// stubGeo places every visitor IP at one location, empty until a test sets it
+type stubGeo struct {
+	location geoip.Location
+}
+
+func (g *stubGeo) Lookup(ip string) (geoip.Location, error) {
+	return g.location, nil
+}
+
+// testVisitorID stands for an ID a browser kept from an earlier visit
+const testVisitorID = "0b6c4a1e-3d5f-4c2a-9e8b-7f1d2c3b4a59"
+
+const browserUA = "Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0 Mobile Safari/537.36"
+
+func (f *redirectFixture) get(t *testing.T, target string) *http.Response {
+	return f.getAs(t, target, browserUA)
+}
+
+func (f *redirectFixture) getAs(t *testing.T, target, userAgent string) *http.Response {
+	req := browserRequest(http.MethodGet, target, nil)
+	req.Header.Set("User-Agent", userAgent)
+	return f.do(t, req)
+}
+
+// browserRequest builds a request with the headers a mobile browser sends
+func browserRequest(method, target string, body io.Reader) *http.Request {
+	req := httptest.NewRequest(method, target, body)
+	req.Header.Set("User-Agent", browserUA)
+	req.Header.Set("Accept-Language", "id-ID,id;q=0.9")
+	req.Header.Set("Referer", "https://instagram.com/")
+	return req
+}
+
+// do sends req and waits until the events it queued are written
+func (f *redirectFixture) do(t *testing.T, req *http.Request) *http.Response {
+	resp, err := f.app.Test(req)
+	require.NoError(t, err)
+	require.NoError(t, f.pipeline.Flush(context.Background()))
+	return resp
+}
+
+func (f *redirectFixture) clicks(t *testing.T) []models.LinkClick {
+	var clicks []models.LinkClick
+	require.NoError(t, f.db.Find(&clicks).Error)
+	return clicks
+}
+
+func TestRedirectRecordsClickAndRedirects(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	resp := f.get(t, f.signer.RedirectURL(f.link.ID)+"&utm_source=ig_bio&vid="+testVisitorID)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, f.link.URL, resp.Header.Get("Location"))
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+
+	clicks := f.clicks(t)
+	require.Len(t, clicks, 1)
+	assert.Equal(t, f.user.ID, clicks[0].UserID)
+	assert.Equal(t, f.link.ID, *clicks[0].LinkID)
+	assert.Equal(t, "ig_bio", clicks[0].Source)
+	assert.Equal(t, "shopee", clicks[0].Platform)
+	assert.Equal(t, "https://instagram.com/", clicks[0].Referer)
+	assert.Equal(t, browserUA, clicks[0].UserAgent)
+	assert.False(t, clicks[0].IsBot)
+
+	// The same visitor clicking again within the dedup window is not counted twice
+	resp = f.get(t, f.signer.RedirectURL(f.link.ID)+"&utm_source=ig_bio&vid="+testVisitorID)
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Len(t, f.clicks(t), 1)
+}
+
+func TestRedirectDedupUsesEarlierStoredClicks(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	// A click written before this process started is only in the database
+	linkID := f.link.ID
+	require.NoError(t, f.db.Create(&models.LinkClick{
+		LinkID: &linkID, UserID: f.user.ID, VisitorID: testVisitorID, ClickedAt: time.Now().Add(-time.Hour),
+	}).Error)
+
+	f.get(t, f.signer.RedirectURL(f.link.ID)+"&vid="+testVisitorID)
+	assert.Len(t, f.clicks(t), 1)
+}
+
+func TestRedirectAppliesCreatorDedupPolicy(t *testing.T) {
+	f := newRedirectFixture(t)
+	target := f.signer.RedirectURL(f.link.ID) + "&vid=" + testVisitorID
+
+	_, err := f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{DedupKey: models.DedupKeyNone})
+	require.NoError(t, err)
+	f.get(t, target)
+	f.get(t, target)
+	assert.Len(t, f.clicks(t), 2, "without dedup every click counts")
+
+	_, err = f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{DedupKey: models.DedupKeyVisitor})
+	require.NoError(t, err)
+	f.get(t, target)
+	assert.Len(t, f.clicks(t), 2, "the earlier clicks are within the default window")
+
+	_, err = f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{DedupKey: "cookie"})
+	assert.ErrorIs(t, err, services.ErrInvalidDedupKey)
+	_, err = f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{ClickDedupWindowSeconds: 5})
+	assert.ErrorIs(t, err, services.ErrInvalidDedupWindow)
+}
+
+func TestRedirectFlagsCrawlerClicks(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	// Link previews still get redirected, but the click is stored as a bot
+	resp := f.getAs(t, f.signer.RedirectURL(f.link.ID), "facebookexternalhit/1.1 (+http://www.facebook.com/externalhit_uatext.php)")
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+
+	clicks := f.clicks(t)
+	require.Len(t, clicks, 1)
+	assert.True(t, clicks[0].IsBot)
+
+	var user models.User
+	require.NoError(t, f.db.First(&user, "id = ?", f.user.ID).Error)
+	assert.Zero(t, user.TotalClicks)
+}
+
+func TestRedirectTagsURLsOfEnabledMarketplaces(t *testing.T) {
+	f := newRedirectFixture(t)
+	target := f.signer.RedirectURL(f.link.ID) + "&utm_source=ig_bio&vid=" + testVisitorID
+
+	// Untagged until the creator enables their marketplace
+	resp := f.get(t, target)
+	assert.Equal(t, f.link.URL, resp.Header.Get("Location"))
+
+	_, err := f.tagging.UpdateSettings(f.user.ID, &services.UpdateLinkTaggingInput{
+		Platforms: []services.LinkTaggingInput{{Platform: "shopee", Enabled: true, AffiliateID: "12345"}},
+	})
+	require.NoError(t, err)
+
+	resp = f.get(t, target)
+	require.Equal(t, http.StatusFound, resp.StatusCode)
+	location, err := url.Parse(resp.Header.Get("Location"))
+	require.NoError(t, err)
+	assert.Equal(t, "an_12345", location.Query().Get("utm_source"))
+
+	// The sub-IDs reported with the order name the link and source of the click
+	assert.Equal(t, []subid.Tag{{LinkID: f.link.ID, Source: "ig_bio"}}, subid.Parse(location.Query().Get("utm_content")))
+}
+
+func TestRedirectOfUnsignedLinksRecordsNoClick(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	expires := time.Now().Add(time.Hour).Unix()
+	signed := func(signer *services.LinkSigner, exp int64) string {
+		return fmt.Sprintf("/go/%s?exp=%d&sig=%s", f.link.ID, exp, signer.Sign(f.link.ID, exp))
+	}
+	for _, target := range []string{
+		"/go/" + f.link.ID.String(),
+		"/go/" + f.link.ID.String() + "?sig=AAAAAAAAAAAAAAAAAAAAAA",
+		"/go/" + f.link.ID.String() + "?sig=" + f.signer.Sign(f.link.ID, expires), // Without its expiry
+		signed(services.NewLinkSigner("other-secret", "", time.Hour), expires),
+		strings.Replace(signed(f.signer, expires), fmt.Sprint(expires), fmt.Sprint(expires+3600), 1), // Extended
+	} {
+		resp := f.get(t, target)
+		assert.Equal(t, http.StatusFound, resp.StatusCode, target)
+		assert.Equal(t, f.link.URL, resp.Header.Get("Location"), target)
+	}
+	assert.Empty(t, f.clicks(t))
+}
+
+func TestRedirectOfExpiredLinkRecordsNoClick(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	resp := f.get(t, fmt.Sprintf("/go/%s?exp=%d&sig=%s", f.link.ID, expired, f.signer.Sign(f.link.ID, expired)))
+	assert.Equal(t, http.StatusFound, resp.StatusCode)
+	assert.Equal(t, f.link.URL, resp.Header.Get("Location"))
+	assert.Empty(t, f.clicks(t))
+
+	// The same link still records clicks from a fresh URL
+	f.get(t, f.signer.RedirectURL(f.link.ID))
+	assert.Len(t, f.clicks(t), 1)
+}
+
+func TestRedirectHidesUnavailableLinks(t *testing.T) {
+	t.Run("inactive link", func(t *testing.T) {
+		f := newRedirectFixture(t)
+		require.NoError(t, f.db.Model(f.link).Update("is_active", false).Error)
+
+		resp := f.get(t, f.signer.RedirectURL(f.link.ID))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Empty(t, f.clicks(t))
+	})
+
+	t.Run("suspended creator", func(t *testing.T) {
+		f := newRedirectFixture(t)
+		require.NoError(t, f.db.Model(f.user).Update("suspended_at", time.Now()).Error)
+
+		resp := f.get(t, f.signer.RedirectURL(f.link.ID))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Empty(t, f.clicks(t))
+	})
+
+	t.Run("non-http target", func(t *testing.T) {
+		f := newRedirectFixture(t)
+		require.NoError(t, f.db.Model(f.link).Update("url", "javascript:alert(1)").Error)
+
+		resp := f.get(t, f.signer.RedirectURL(f.link.ID))
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	})
+}
+
+func TestPublicProfileReturnsRedirectURLs(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	resp := f.get(t, "/api/u/carol")
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var profile PublicProfileResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&profile))
+	require.Len(t, profile.Links, 1)
+	target, err := url.Parse(profile.Links[0].URL)
+	require.NoError(t, err)
+	assert.Equal(t, "https://api.example.com/go/"+f.link.ID.String(), target.Scheme+"://"+target.Host+target.Path)
+	assert.True(t, f.signer.Verify(f.link.ID, target.Query().Get("exp"), target.Query().Get("sig")))
+	assert.Equal(t, "shopee", profile.Links[0].Platform)
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/onedash/backend/internal/middleware"
+	"github.com/onedash/backend/internal/services"
+)
+
+type TrackingSettingsHandler struct {
+	settingsService *services.TrackingSettingsService
+}
+
+func NewTrackingSettingsHandler(settingsService *services.TrackingSettingsService) *TrackingSettingsHandler {
+	return &TrackingSettingsHandler{settingsService: settingsService}
+}
+
+func (h *TrackingSettingsHandler) GetSettings(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	settings, err := h.settingsService.GetSettings(userID)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get tracking settings",
+		})
+	}
+
+	return c.JSON(settings)
+}
+
+func (h *TrackingSettingsHandler) UpdateSettings(c *fiber.Ctx) error {
+	userID, err := middleware.GetUserID(c)
+	if err != nil {
+		return err
+	}
+
+	var input services.UpdateTrackingSettingsInput
+	if err := c.BodyParser(&input); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	settings, err := h.settingsService.UpdateSettings(userID, &input)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidDedupKey) || errors.Is(err, services.ErrInvalidDedupWindow) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update tracking settings",
+		})
+	}
+
+	return c.JSON(settings)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+const (
+	// visitorCookieName is the first-party cookie identifying a visitor across
+	// profile views, product clicks and social clicks
+	visitorCookieName   = "od_vid"
+	visitorCookieMaxAge = 365 * 24 * time.Hour
+
+	// Server-rendered pages cannot pass Set-Cookie on to the browser, so the
+	// profile endpoint also returns the visitor and page view in headers
+	visitorIDHeader  = "X-Visitor-ID"
+	pageViewIDHeader = "X-Page-View-ID"
+)
+
+// VisitorCookie issues the visitor cookie
+type VisitorCookie struct {
+	Domain string // empty for a cookie on the API host only
+	Secure bool
+}
+
+// Resolve returns the visitor ID of the request and (re)issues its cookie.
+// The cookie wins; otherwise claimed is adopted when it is a UUID (an ID kept
+// by the client from before the cookie, or handed to a server-rendered page),
+// and a new ID is created as a last resort.
+func (v VisitorCookie) Resolve(c *fiber.Ctx, claimed string) string {
+	visitorID := c.Cookies(visitorCookieName)
+	if _, err := uuid.Parse(visitorID); err != nil {
+		visitorID = claimed
+		if _, err := uuid.Parse(visitorID); err != nil {
+			visitorID = uuid.NewString()
+		}
+	}
+
+	c.Cookie(&fiber.Cookie{
+		Name:     visitorCookieName,
+		Value:    visitorID,
+		Path:     "/",
+		Domain:   v.Domain,
+		MaxAge:   int(visitorCookieMaxAge / time.Second),
+		Secure:   v.Secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	})
+	return visitorID
+}
+
+// isBrowserNavigation reports whether the request is a browser loading a page,
+// as opposed to a fetch, prefetch or server-to-server call. Browsers set the
+// Sec-Fetch headers themselves; server renderers forward the visitor's.
+func isBrowserNavigation(c *fiber.Ctx) bool {
+	return c.Get("Sec-Fetch-Mode") == "navigate" && c.Get("Sec-Fetch-Dest") == "document"
+}
+
+// Location headers set by edge proxies and CDNs, most specific first
+var (
+	countryHeaders = []string{"CF-IPCountry", "X-Vercel-IP-Country", "CloudFront-Viewer-Country", "X-Country-Code"}
+	regionHeaders  = []string{"X-Vercel-IP-Country-Region", "CloudFront-Viewer-Country-Region", "CF-Region-Code", "X-Region-Code"}
+)
+
+// maxRegionLength matches the region column
+const maxRegionLength = 64
+
+// proxyLocation returns the visitor's country (ISO 3166-1 alpha-2) and region
+// as reported by a trusted proxy. Requests from elsewhere could claim any
+// location, so their headers are ignored.
+func proxyLocation(c *fiber.Ctx) (country, region string) {
+	if !c.IsProxyTrusted() {
+		return "", ""
+	}
+	for _, header := range countryHeaders {
+		value := strings.ToUpper(strings.TrimSpace(c.Get(header)))
+		// XX is unknown and T1 is Tor at Cloudflare
+		if len(value) == 2 && value != "XX" && value != "T1" && isLetters(value) {
+			country = value
+			break
+		}
+	}
+	if country == "" {
+		return "", ""
+	}
+	for _, header := range regionHeaders {
+		if value := strings.TrimSpace(c.Get(header)); value != "" {
+			if len(value) > maxRegionLength {
+				value = value[:maxRegionLength]
+			}
+			region = value
+			break
+		}
+	}
+	return country, region
+}
+
+func isLetters(s string) bool {
+	for _, r := range s {
+		if r < 'A' || r > 'Z' {
+			return false
+		}
+	}
+	return true
+}
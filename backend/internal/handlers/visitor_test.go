@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/geoip"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/services"
+)
+
+func (f *redirectFixture) pageViews(t *testing.T) []models.PageView {
+	var views []models.PageView
+	require.NoError(t, f.db.Find(&views).Error)
+	return views
+}
+
+func navigationRequest(target string) *http.Request {
+	req := browserRequest(http.MethodGet, target, nil)
+	req.Header.Set("Sec-Fetch-Mode", "navigate")
+	req.Header.Set("Sec-Fetch-Dest", "document")
+	return req
+}
+
+func beaconRequest(body string, visitorCookie *http.Cookie) *http.Request {
+	req := browserRequest(http.MethodPost, "/api/analytics/pageview", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if visitorCookie != nil {
+		req.AddCookie(visitorCookie)
+	}
+	return req
+}
+
+func visitorCookieOf(t *testing.T, resp *http.Response) *http.Cookie {
+	for _, cookie := range resp.Cookies() {
+		if cookie.Name == visitorCookieName {
+			return cookie
+		}
+	}
+	t.Fatal("response has no visitor cookie")
+	return nil
+}
+
+func TestProfileNavigationCountsOneViewWithItsBeacon(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	resp := f.do(t, navigationRequest("/api/u/carol?utm_source=ig_bio"))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	visitorID := resp.Header.Get(visitorIDHeader)
+	pageViewID := resp.Header.Get(pageViewIDHeader)
+	require.NotEmpty(t, pageViewID)
+	cookie := visitorCookieOf(t, resp)
+	assert.Equal(t, visitorID, cookie.Value)
+	assert.True(t, cookie.HttpOnly)
+
+	views := f.pageViews(t)
+	require.Len(t, views, 1)
+	assert.Equal(t, visitorID, views[0].VisitorID)
+	assert.Equal(t, "ig_bio", views[0].Source)
+
+	// The rendered page reports the same view; it is not counted again
+	body := `{"user_id":"` + f.user.ID.String() + `","visitor_id":"` + visitorID + `","page_view_id":"` + pageViewID + `"}`
+	resp = f.do(t, beaconRequest(body, nil))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, visitorID, visitorCookieOf(t, resp).Value, "the beacon hands the rendered page's visitor ID to the browser")
+	assert.Len(t, f.pageViews(t), 1)
+
+	// Without dedup a beacon of an unknown page is counted on its own
+	_, err := f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{DedupKey: models.DedupKeyNone})
+	require.NoError(t, err)
+	f.do(t, beaconRequest(body, nil))
+	assert.Len(t, f.pageViews(t), 1, "the known page view ID still suppresses the beacon")
+	f.do(t, beaconRequest(`{"user_id":"`+f.user.ID.String()+`","page_view_id":"`+uuid.NewString()+`"}`, cookie))
+	assert.Len(t, f.pageViews(t), 2)
+}
+
+func TestProfileFetchWithoutNavigationLeavesViewToBeacon(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	resp := f.do(t, browserRequest(http.MethodGet, "/api/u/carol", nil))
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(pageViewIDHeader))
+	assert.Empty(t, f.pageViews(t))
+
+	cookie := visitorCookieOf(t, resp)
+	f.do(t, beaconRequest(`{"user_id":"`+f.user.ID.String()+`","visitor_id":"legacy"}`, cookie))
+	views := f.pageViews(t)
+	require.Len(t, views, 1)
+	assert.Equal(t, cookie.Value, views[0].VisitorID, "the cookie wins over a client-supplied ID")
+}
+
+func TestBeaconOfUnknownProfileIsRejected(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	resp := f.do(t, beaconRequest(`{"user_id":"`+uuid.NewString()+`"}`, nil))
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Empty(t, f.pageViews(t))
+}
+
+func TestClicksStoreHashedIPAndProxyLocation(t *testing.T) {
+	f := newRedirectFixture(t)
+	target := f.signer.RedirectURL(f.link.ID)
+
+	req := browserRequest(http.MethodGet, target, nil)
+	req.Header.Set("CF-IPCountry", "id")
+	req.Header.Set("X-Vercel-IP-Country-Region", "JB")
+	f.do(t, req)
+
+	clicks := f.clicks(t)
+	require.Len(t, clicks, 1)
+	assert.Len(t, clicks[0].IPHash, 32)
+	assert.Equal(t, "ID", clicks[0].Country)
+	assert.Equal(t, "JB", clicks[0].Region)
+
+	captureIP := false
+	_, err := f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{
+		DedupKey:  models.DedupKeyNone,
+		CaptureIP: &captureIP,
+	})
+	require.NoError(t, err)
+	f.get(t, target)
+
+	require.Len(t, f.clicks(t), 2)
+	var latest models.LinkClick
+	require.NoError(t, f.db.Order("clicked_at DESC").First(&latest).Error)
+	assert.Empty(t, latest.IPHash, "no IP is kept once capture is off")
+}
+
+func TestGeoIPFillsLocationMissingFromProxy(t *testing.T) {
+	f := newRedirectFixture(t)
+	f.geo.location = geoip.Location{Country: "ID", Region: "JK", City: "Jakarta"}
+	target := f.signer.RedirectURL(f.link.ID)
+
+	f.get(t, target+"&vid="+testVisitorID)
+
+	req := browserRequest(http.MethodGet, target, nil)
+	req.Header.Set("CF-IPCountry", "ID")
+	req.Header.Set("X-Vercel-IP-Country-Region", "JB")
+	f.do(t, req)
+
+	req = browserRequest(http.MethodGet, target, nil)
+	req.Header.Set("CF-IPCountry", "SG")
+	f.do(t, req)
+
+	var clicks []models.LinkClick
+	require.NoError(t, f.db.Order("clicked_at").Find(&clicks).Error)
+	require.Len(t, clicks, 3)
+	assert.Equal(t, [3]string{"ID", "JK", "Jakarta"}, [3]string{clicks[0].Country, clicks[0].Region, clicks[0].City})
+	assert.Equal(t, [3]string{"ID", "JB", ""}, [3]string{clicks[1].Country, clicks[1].Region, clicks[1].City},
+		"the proxy's region wins and the database city is not in it")
+	assert.Equal(t, [3]string{"SG", "", ""}, [3]string{clicks[2].Country, clicks[2].Region, clicks[2].City},
+		"the database is ignored when it disagrees with the proxy's country")
+}
+
+func TestGeoIPIsSkippedWhenIPCaptureIsOff(t *testing.T) {
+	f := newRedirectFixture(t)
+	f.geo.location = geoip.Location{Country: "ID", Region: "JK", City: "Jakarta"}
+	captureIP := false
+	_, err := f.settings.UpdateSettings(f.user.ID, &services.UpdateTrackingSettingsInput{CaptureIP: &captureIP})
+	require.NoError(t, err)
+
+	req := browserRequest(http.MethodGet, f.signer.RedirectURL(f.link.ID), nil)
+	req.Header.Set("CF-IPCountry", "ID")
+	f.do(t, req)
+
+	clicks := f.clicks(t)
+	require.Len(t, clicks, 1)
+	assert.Equal(t, [3]string{"ID", "", ""}, [3]string{clicks[0].Country, clicks[0].Region, clicks[0].City},
+		"only the proxy's location is kept")
+}
+
+func TestClicksStoreClientFromUserAgent(t *testing.T) {
+	f := newRedirectFixture(t)
+
+	// WhatsApp's Android webview is a plain webview that names the app in X-Requested-With
+	req := browserRequest(http.MethodGet, f.signer.RedirectURL(f.link.ID), nil)
+	req.Header.Set("User-Agent", "Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP2A.240605.024; wv) AppleWebKit/537.36 "+
+		"(KHTML, like Gecko) Version/4.0 Chrome/126.0.6478.71 Mobile Safari/537.36")
+	req.Header.Set("X-Requested-With", "com.whatsapp")
+	f.do(t, req)
+
+	clicks := f.clicks(t)
+	require.Len(t, clicks, 1)
+	assert.Equal(t, "mobile", clicks[0].DeviceType)
+	assert.Equal(t, "Android", clicks[0].OS)
+	assert.Equal(t, "WhatsApp", clicks[0].Browser)
+}
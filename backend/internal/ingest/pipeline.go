@@ -0,0 +1,333 @@
+// Package ingest writes tracking events asynchronously.
+//
+// Handlers enqueue events into a bounded in-process queue and return
+// immediately. Worker goroutines collect events into batches and write each
+// batch in one transaction, so a burst of traffic on one profile costs one
+// counter update per batch instead of one per event. When the queue is full
+// new events are rejected and counted rather than blocking the visitor.
+package ingest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+)
+
+var (
+	ErrQueueFull = errors.New("tracking queue is full")
+	ErrClosed    = errors.New("tracking queue is shut down")
+)
+
+// Store persists a batch of events
+type Store interface {
+	InsertEvents(batch repository.EventBatch) error
+}
+
+// Event is one tracking event; exactly one field is set
+type Event struct {
+	Click       *models.LinkClick
+	PageView    *models.PageView
+	SocialClick *models.SocialClick
+}
+
+type Options struct {
+	QueueSize     int           // events buffered before Enqueue rejects new ones
+	Workers       int           // goroutines writing batches
+	BatchSize     int           // events per write
+	FlushInterval time.Duration // a partial batch is written at least this often
+	MaxRetries    int           // extra attempts for a failed batch before it is dropped
+	RetryBackoff  time.Duration // wait before the first retry, doubled for every further one
+}
+
+// DefaultOptions fill in any zero size, count or interval passed to New
+var DefaultOptions = Options{
+	QueueSize:     10000,
+	Workers:       4,
+	BatchSize:     200,
+	FlushInterval: time.Second,
+	MaxRetries:    3,
+	RetryBackoff:  100 * time.Millisecond,
+}
+
+// Stats are the pipeline counters since startup
+type Stats struct {
+	Enqueued      uint64 `json:"enqueued"`
+	Dropped       uint64 `json:"dropped"` // rejected because the queue was full
+	Written       uint64 `json:"written"`
+	Failed        uint64 `json:"failed"` // lost after all retries
+	Batches       uint64 `json:"batches"`
+	Retries       uint64 `json:"retries"`
+	QueueDepth    int    `json:"queue_depth"`
+	QueueCapacity int    `json:"queue_capacity"`
+}
+
+type Pipeline struct {
+	store Store
+	opts  Options
+	queue chan Event
+
+	// flushRequests has one channel per worker, see Flush
+	flushRequests []chan chan struct{}
+
+	mu     sync.RWMutex // guards closed against concurrent Enqueue
+	closed bool
+	wg     sync.WaitGroup
+
+	enqueued atomic.Uint64
+	dropped  atomic.Uint64
+	written  atomic.Uint64
+	failed   atomic.Uint64
+	batches  atomic.Uint64
+	retries  atomic.Uint64
+}
+
+// New starts a pipeline writing to store
+func New(store Store, opts Options) *Pipeline {
+	opts = opts.withDefaults()
+	p := &Pipeline{
+		store:         store,
+		opts:          opts,
+		queue:         make(chan Event, opts.QueueSize),
+		flushRequests: make([]chan chan struct{}, opts.Workers),
+	}
+
+	p.wg.Add(opts.Workers)
+	for i := range p.flushRequests {
+		p.flushRequests[i] = make(chan chan struct{})
+		go p.worker(p.flushRequests[i])
+	}
+	return p
+}
+
+func (o Options) withDefaults() Options {
+	if o.QueueSize <= 0 {
+		o.QueueSize = DefaultOptions.QueueSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = DefaultOptions.Workers
+	}
+	if o.BatchSize <= 0 {
+		o.BatchSize = DefaultOptions.BatchSize
+	}
+	if o.FlushInterval <= 0 {
+		o.FlushInterval = DefaultOptions.FlushInterval
+	}
+	if o.MaxRetries < 0 {
+		o.MaxRetries = 0
+	}
+	if o.RetryBackoff <= 0 {
+		o.RetryBackoff = DefaultOptions.RetryBackoff
+	}
+	return o
+}
+
+// Enqueue hands an event to the workers without blocking. It returns
+// ErrQueueFull when the queue is at capacity and ErrClosed after Shutdown.
+func (p *Pipeline) Enqueue(e Event) error {
+	// Stamp the event now so batching does not shift its time
+	now := time.Now()
+	switch {
+	case e.Click != nil && e.Click.ClickedAt.IsZero():
+		e.Click.ClickedAt = now
+	case e.PageView != nil && e.PageView.ViewedAt.IsZero():
+		e.PageView.ViewedAt = now
+	case e.SocialClick != nil && e.SocialClick.ClickedAt.IsZero():
+		e.SocialClick.ClickedAt = now
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return ErrClosed
+	}
+
+	select {
+	case p.queue <- e:
+		p.enqueued.Add(1)
+		return nil
+	default:
+		p.dropped.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Stats returns a snapshot of the pipeline counters
+func (p *Pipeline) Stats() Stats {
+	return Stats{
+		Enqueued:      p.enqueued.Load(),
+		Dropped:       p.dropped.Load(),
+		Written:       p.written.Load(),
+		Failed:        p.failed.Load(),
+		Batches:       p.batches.Load(),
+		Retries:       p.retries.Load(),
+		QueueDepth:    len(p.queue),
+		QueueCapacity: cap(p.queue),
+	}
+}
+
+// Flush blocks until every event enqueued before the call has been written
+func (p *Pipeline) Flush(ctx context.Context) error {
+	p.mu.RLock()
+	closed := p.closed
+	p.mu.RUnlock()
+	if closed {
+		return p.wait(ctx)
+	}
+
+	// Wait for the workers to take everything off the queue...
+	for len(p.queue) > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	// ...then have each of them write its partial batch
+	for _, requests := range p.flushRequests {
+		done := make(chan struct{})
+		select {
+		case requests <- done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Shutdown stops accepting events and waits until the queue is drained
+func (p *Pipeline) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+
+	return p.wait(ctx)
+}
+
+func (p *Pipeline) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d tracking events not written: %w", len(p.queue), ctx.Err())
+	}
+}
+
+func (p *Pipeline) worker(flushRequests chan chan struct{}) {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(p.opts.FlushInterval)
+	defer ticker.Stop()
+
+	var batch repository.EventBatch
+	flush := func() {
+		if batch.Len() > 0 {
+			p.write(batch)
+			batch = repository.EventBatch{}
+		}
+	}
+
+	for {
+		select {
+		case e, ok := <-p.queue:
+			if !ok {
+				flush()
+				return
+			}
+			switch {
+			case e.Click != nil:
+				batch.Clicks = append(batch.Clicks, e.Click)
+			case e.PageView != nil:
+				batch.PageViews = append(batch.PageViews, e.PageView)
+			case e.SocialClick != nil:
+				batch.SocialClicks = append(batch.SocialClicks, e.SocialClick)
+			}
+			if batch.Len() >= p.opts.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case done := <-flushRequests:
+			flush()
+			close(done)
+		}
+	}
+}
+
+// write stores a batch, retrying with exponential backoff. A batch that still
+// fails is written event by event, so one bad event (e.g. of a deleted
+// account) costs only itself rather than the events written with it.
+func (p *Pipeline) write(batch repository.EventBatch) {
+	backoff := p.opts.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := p.store.InsertEvents(batch)
+		if err == nil {
+			p.written.Add(uint64(batch.Len()))
+			p.batches.Add(1)
+			return
+		}
+		if attempt >= p.opts.MaxRetries {
+			if batch.Len() > 1 {
+				p.writeEach(batch)
+				return
+			}
+			p.failed.Add(uint64(batch.Len()))
+			log.Printf("❌ Dropped %d tracking events after %d attempts: %v", batch.Len(), attempt+1, err)
+			return
+		}
+		p.retries.Add(1)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// writeEach stores the events of a failed batch one at a time, once each
+func (p *Pipeline) writeEach(batch repository.EventBatch) {
+	var singles []repository.EventBatch
+	for _, click := range batch.Clicks {
+		singles = append(singles, repository.EventBatch{Clicks: []*models.LinkClick{click}})
+	}
+	for _, view := range batch.PageViews {
+		singles = append(singles, repository.EventBatch{PageViews: []*models.PageView{view}})
+	}
+	for _, click := range batch.SocialClicks {
+		singles = append(singles, repository.EventBatch{SocialClicks: []*models.SocialClick{click}})
+	}
+
+	var failed uint64
+	var lastErr error
+	for _, single := range singles {
+		if err := p.store.InsertEvents(single); err != nil {
+			failed++
+			lastErr = err
+			continue
+		}
+		p.written.Add(1)
+	}
+	p.batches.Add(1)
+	if failed > 0 {
+		p.failed.Add(failed)
+		log.Printf("❌ Dropped %d of %d tracking events of a failed batch: %v", failed, batch.Len(), lastErr)
+	}
+}
@@ -0,0 +1,232 @@
+package ingest
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+func createUsers(t *testing.T, repo *repository.UserRepository, n int) []*models.User {
+	users := make([]*models.User, n)
+	for i := range users {
+		name := uuid.NewString()[:8]
+		users[i] = &models.User{Email: name + "@example.com", Username: name, PasswordHash: "hash"}
+		require.NoError(t, repo.Create(users[i]))
+	}
+	return users
+}
+
+func TestShutdownWritesEveryEvent(t *testing.T) {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	users := createUsers(t, userRepo, 3)
+
+	p := New(repository.NewAnalyticsRepository(db), Options{
+		QueueSize:     5000,
+		Workers:       4,
+		BatchSize:     50,
+		FlushInterval: time.Hour, // only full batches and the final drain write
+	})
+
+	const producers, perProducer = 8, 250
+	var wg sync.WaitGroup
+	for i := 0; i < producers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perProducer; j++ {
+				user := users[(i+j)%len(users)]
+				var err error
+				if j%2 == 0 {
+					err = p.Enqueue(Event{Click: &models.LinkClick{UserID: user.ID, Source: "test"}})
+				} else {
+					err = p.Enqueue(Event{PageView: &models.PageView{UserID: user.ID, Source: "test"}})
+				}
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	require.NoError(t, p.Shutdown(context.Background()))
+	assert.ErrorIs(t, p.Enqueue(Event{Click: &models.LinkClick{UserID: users[0].ID}}), ErrClosed)
+
+	var clicks, views int64
+	require.NoError(t, db.Model(&models.LinkClick{}).Count(&clicks).Error)
+	require.NoError(t, db.Model(&models.PageView{}).Count(&views).Error)
+	assert.Equal(t, int64(producers*perProducer/2), clicks)
+	assert.Equal(t, int64(producers*perProducer/2), views)
+
+	// The batched counter deltas add up to the rows written
+	var totalClicks, totalViews int64
+	for _, u := range users {
+		user, err := userRepo.FindByID(u.ID)
+		require.NoError(t, err)
+		totalClicks += user.TotalClicks
+		totalViews += user.TotalViews
+	}
+	assert.Equal(t, clicks, totalClicks)
+	assert.Equal(t, views, totalViews)
+
+	stats := p.Stats()
+	assert.Equal(t, uint64(producers*perProducer), stats.Enqueued)
+	assert.Equal(t, uint64(producers*perProducer), stats.Written)
+	assert.Zero(t, stats.Dropped)
+	assert.Zero(t, stats.Failed)
+}
+
+func TestFlushWritesPartialBatches(t *testing.T) {
+	db := testutil.NewDB(t)
+	users := createUsers(t, repository.NewUserRepository(db), 1)
+
+	p := New(repository.NewAnalyticsRepository(db), Options{Workers: 2, BatchSize: 100, FlushInterval: time.Hour})
+	defer p.Shutdown(context.Background())
+
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Enqueue(Event{SocialClick: &models.SocialClick{UserID: users[0].ID, SocialType: "instagram"}}))
+	}
+	require.NoError(t, p.Flush(context.Background()))
+
+	var count int64
+	require.NoError(t, db.Model(&models.SocialClick{}).Count(&count).Error)
+	assert.Equal(t, int64(5), count)
+}
+
+// flakyStore fails the first failures calls, then records what it is given
+type flakyStore struct {
+	failures atomic.Int32
+	mu       sync.Mutex
+	written  int
+}
+
+func (s *flakyStore) InsertEvents(batch repository.EventBatch) error {
+	if s.failures.Add(-1) >= 0 {
+		return errors.New("database unavailable")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written += batch.Len()
+	return nil
+}
+
+func TestFailedBatchesAreRetried(t *testing.T) {
+	store := &flakyStore{}
+	store.failures.Store(2)
+
+	p := New(store, Options{Workers: 1, BatchSize: 10, MaxRetries: 3, RetryBackoff: time.Millisecond})
+	for i := 0; i < 10; i++ {
+		require.NoError(t, p.Enqueue(Event{Click: &models.LinkClick{}}))
+	}
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	assert.Equal(t, 10, store.written)
+	stats := p.Stats()
+	assert.Equal(t, uint64(2), stats.Retries)
+	assert.Equal(t, uint64(10), stats.Written)
+	assert.Zero(t, stats.Failed)
+}
+
+func TestBatchIsDroppedAfterMaxRetries(t *testing.T) {
+	store := &flakyStore{}
+	store.failures.Store(100)
+
+	p := New(store, Options{Workers: 1, BatchSize: 5, MaxRetries: 2, RetryBackoff: time.Millisecond})
+	for i := 0; i < 5; i++ {
+		require.NoError(t, p.Enqueue(Event{Click: &models.LinkClick{}}))
+	}
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	stats := p.Stats()
+	assert.Equal(t, uint64(5), stats.Failed)
+	assert.Zero(t, stats.Written)
+}
+
+func TestBadEventDoesNotCostItsBatch(t *testing.T) {
+	db := testutil.NewDB(t)
+	users := createUsers(t, repository.NewUserRepository(db), 1)
+
+	p := New(repository.NewAnalyticsRepository(db), Options{
+		Workers: 1, BatchSize: 5, FlushInterval: time.Hour, MaxRetries: 1, RetryBackoff: time.Millisecond,
+	})
+	for i := 0; i < 5; i++ {
+		owner := users[0].ID
+		if i == 2 {
+			owner = uuid.New() // No such account: violates the foreign key
+		}
+		require.NoError(t, p.Enqueue(Event{PageView: &models.PageView{UserID: owner, Source: "test"}}))
+	}
+	require.NoError(t, p.Shutdown(context.Background()))
+
+	var views int64
+	require.NoError(t, db.Model(&models.PageView{}).Count(&views).Error)
+	assert.Equal(t, int64(4), views)
+	stats := p.Stats()
+	assert.Equal(t, uint64(4), stats.Written)
+	assert.Equal(t, uint64(1), stats.Failed)
+}
+
+// blockingStore holds every write until release is closed
+type blockingStore struct {
+	release chan struct{}
+}
+
+func (s *blockingStore) InsertEvents(repository.EventBatch) error {
+	<-s.release
+	return nil
+}
+
+func TestFullQueueRejectsEvents(t *testing.T) {
+	store := &blockingStore{release: make(chan struct{})}
+	p := New(store, Options{QueueSize: 2, Workers: 1, BatchSize: 1})
+
+	// One event is held by the blocked worker, two fill the queue
+	var rejected int
+	for i := 0; i < 10; i++ {
+		if err := p.Enqueue(Event{Click: &models.LinkClick{}}); errors.Is(err, ErrQueueFull) {
+			rejected++
+		}
+	}
+	assert.GreaterOrEqual(t, rejected, 7)
+
+	stats := p.Stats()
+	assert.Equal(t, uint64(rejected), stats.Dropped)
+	assert.Equal(t, 2, stats.QueueCapacity)
+
+	close(store.release)
+	require.NoError(t, p.Shutdown(context.Background()))
+	assert.Equal(t, stats.Enqueued, p.Stats().Written)
+}
+
+func TestShutdownHonoursDeadline(t *testing.T) {
+	store := &blockingStore{release: make(chan struct{})}
+	defer close(store.release)
+
+	p := New(store, Options{Workers: 1, BatchSize: 1})
+	require.NoError(t, p.Enqueue(Event{Click: &models.LinkClick{}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, p.Shutdown(ctx), context.DeadlineExceeded)
+}
+
+func TestEnqueueStampsEventTime(t *testing.T) {
+	store := &flakyStore{}
+	p := New(store, Options{Workers: 1})
+	defer p.Shutdown(context.Background())
+
+	click := &models.LinkClick{}
+	before := time.Now()
+	require.NoError(t, p.Enqueue(Event{Click: click}))
+	assert.False(t, click.ClickedAt.Before(before))
+}
@@ -0,0 +1,79 @@
+// Package iphash turns visitor IPs into hashes that recognise a visitor for
+// one UTC day only.
+//
+// Each day has its own random salt, shared by all instances through the
+// database. Once a day's salt is deleted its hashes can no longer be linked
+// back to an IP, not even by trying every address.
+package iphash
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// DayLayout formats the UTC day a salt belongs to
+const DayLayout = "2006-01-02"
+
+// SaltStore keeps one salt per day. GetOrCreate stores salt unless the day
+// already has one, and returns the stored salt either way.
+type SaltStore interface {
+	GetOrCreate(day, salt string) (string, error)
+}
+
+// Hasher is safe for concurrent use
+type Hasher struct {
+	store SaltStore
+	now   func() time.Time
+
+	mu   sync.Mutex
+	day  string
+	salt []byte
+}
+
+func New(store SaltStore) *Hasher {
+	return &Hasher{store: store, now: time.Now}
+}
+
+// Hash returns the hex-encoded, salted hash of ip for the current day.
+// An empty ip hashes to an empty string.
+func (h *Hasher) Hash(ip string) (string, error) {
+	if ip == "" {
+		return "", nil
+	}
+	salt, err := h.currentSalt()
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write([]byte(ip))
+	return hex.EncodeToString(mac.Sum(nil)[:16]), nil
+}
+
+func (h *Hasher) currentSalt() ([]byte, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	day := h.now().UTC().Format(DayLayout)
+	if day == h.day {
+		return h.salt, nil
+	}
+
+	fresh := make([]byte, 32)
+	if _, err := rand.Read(fresh); err != nil {
+		return nil, err
+	}
+	stored, err := h.store.GetOrCreate(day, hex.EncodeToString(fresh))
+	if err != nil {
+		return nil, err
+	}
+	salt, err := hex.DecodeString(stored)
+	if err != nil {
+		return nil, err
+	}
+	h.day, h.salt = day, salt
+	return salt, nil
+}
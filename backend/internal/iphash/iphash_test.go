@@ -0,0 +1,77 @@
+package iphash
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type memoryStore struct {
+	salts map[string]string
+	err   error
+}
+
+func (s *memoryStore) GetOrCreate(day, salt string) (string, error) {
+	if s.err != nil {
+		return "", s.err
+	}
+	if stored, ok := s.salts[day]; ok {
+		return stored, nil
+	}
+	s.salts[day] = salt
+	return salt, nil
+}
+
+func newHasher(store SaltStore, now *time.Time) *Hasher {
+	h := New(store)
+	h.now = func() time.Time { return *now }
+	return h
+}
+
+func TestHashIsStableWithinADayAndAcrossInstances(t *testing.T) {
+	store := &memoryStore{salts: map[string]string{}}
+	now := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	a, b := newHasher(store, &now), newHasher(store, &now)
+
+	first, err := a.Hash("203.0.113.7")
+	require.NoError(t, err)
+	assert.Len(t, first, 32)
+	assert.NotContains(t, first, "203")
+
+	again, err := b.Hash("203.0.113.7")
+	require.NoError(t, err)
+	assert.Equal(t, first, again, "instances share the day's salt")
+
+	other, err := a.Hash("203.0.113.8")
+	require.NoError(t, err)
+	assert.NotEqual(t, first, other)
+
+	empty, err := a.Hash("")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestHashRotatesAtMidnightUTC(t *testing.T) {
+	store := &memoryStore{salts: map[string]string{}}
+	now := time.Date(2024, 5, 1, 23, 59, 0, 0, time.UTC)
+	h := newHasher(store, &now)
+
+	before, err := h.Hash("203.0.113.7")
+	require.NoError(t, err)
+	now = now.Add(2 * time.Minute)
+	after, err := h.Hash("203.0.113.7")
+	require.NoError(t, err)
+
+	assert.NotEqual(t, before, after)
+	assert.Len(t, store.salts, 2)
+}
+
+func TestHashReportsStoreErrors(t *testing.T) {
+	now := time.Now()
+	h := newHasher(&memoryStore{err: errors.New("database is down")}, &now)
+	_, err := h.Hash("203.0.113.7")
+	assert.Error(t, err)
+}
@@ -0,0 +1,34 @@
+package mailer
+
+import (
+	"fmt"
+
+	"github.com/onedash/backend/config"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends transactional emails (password reset, verification, ...)
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// New builds the mailer selected by the mail driver ("smtp" or "log")
+func New(cfg config.MailConfig) (Mailer, error) {
+	switch cfg.Driver {
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP host is required for the smtp mail driver")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "", "log":
+		return NewLogMailer(cfg.LogDir), nil
+	default:
+		return nil, fmt.Errorf("unknown mail driver %q", cfg.Driver)
+	}
+}
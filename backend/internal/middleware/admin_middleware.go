@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/onedash/backend/internal/repository"
+)
+
+// RequireAdmin restricts the route to users with the admin role.
+// Must be registered after AuthMiddleware.
+func RequireAdmin(userRepo *repository.UserRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := GetUserID(c)
+		if err != nil {
+			return err
+		}
+
+		user, err := userRepo.FindByID(userID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+
+		if !user.IsAdmin() || user.IsSuspended() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Admin access required",
+			})
+		}
+
+		return c.Next()
+	}
+}
@@ -1,17 +1,15 @@
 package middleware
 
 import (
-	"os"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 
 	"github.com/onedash/backend/internal/services"
 )
 
-func AuthMiddleware() fiber.Handler {
+func AuthMiddleware(authService *services.AuthService) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Get token from Authorization header
 		authHeader := c.Get("Authorization")
@@ -31,32 +29,18 @@ func AuthMiddleware() fiber.Handler {
 
 		tokenString := tokenParts[1]
 
-		// Validate token
-		secret := os.Getenv("JWT_SECRET")
-		if secret == "" {
-			secret = "default-secret-key"
-		}
-
-		token, err := jwt.ParseWithClaims(tokenString, &services.JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-			return []byte(secret), nil
-		})
-
-		if err != nil || !token.Valid {
+		// Validate token signature, expiry and session
+		claims, err := authService.ValidateToken(tokenString)
+		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid or expired token",
 			})
 		}
 
-		claims, ok := token.Claims.(*services.JWTClaims)
-		if !ok {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid token claims",
-			})
-		}
-
 		// Set user info in context
 		c.Locals("userID", claims.UserID)
 		c.Locals("username", claims.Username)
+		c.Locals("sessionID", claims.SessionID)
 
 		return c.Next()
 	}
@@ -70,3 +54,12 @@ func GetUserID(c *fiber.Ctx) (uuid.UUID, error) {
 	}
 	return userID, nil
 }
+
+// GetSessionID extracts the current session ID from context
+func GetSessionID(c *fiber.Ctx) (uuid.UUID, error) {
+	sessionID, ok := c.Locals("sessionID").(uuid.UUID)
+	if !ok {
+		return uuid.Nil, fiber.NewError(fiber.StatusUnauthorized, "User not authenticated")
+	}
+	return sessionID, nil
+}
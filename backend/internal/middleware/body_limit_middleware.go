@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// BodyLimit rejects request bodies larger than limit, or than the limit raised
+// for the request's path in raised. The server streams bodies over its own
+// BodyLimit (StreamRequestBody) instead of refusing them, so the raised limits
+// can be reached; this is then the only limit and has to run before every route.
+func BodyLimit(limit int, raised map[string]int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		max := limit
+		if n, ok := raised[strings.TrimSuffix(c.Path(), "/")]; ok {
+			max = n
+		}
+
+		req := c.Request()
+		length := req.Header.ContentLength()
+		if length > max {
+			return tooLarge(c)
+		}
+
+		// A chunked body's size is only known once it is read
+		if length == -1 && req.IsBodyStream() {
+			body, err := io.ReadAll(io.LimitReader(req.BodyStream(), int64(max)+1))
+			if err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+					"error": "Invalid request body",
+				})
+			}
+			if len(body) > max {
+				return tooLarge(c)
+			}
+			req.SetBody(body)
+		}
+
+		return c.Next()
+	}
+}
+
+// tooLarge answers 413 and closes the connection, as the rest of the body is not read
+func tooLarge(c *fiber.Ctx) error {
+	c.Context().SetConnectionClose()
+	return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+		"error": "Request body too large",
+	})
+}
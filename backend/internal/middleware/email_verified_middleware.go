@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/onedash/backend/internal/repository"
+)
+
+// RequireVerifiedEmail blocks the route until the user has confirmed their email address.
+// Must be registered after AuthMiddleware.
+func RequireVerifiedEmail(userRepo *repository.UserRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, err := GetUserID(c)
+		if err != nil {
+			return err
+		}
+
+		user, err := userRepo.FindByID(userID)
+		if err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+
+		if !user.HasVerifiedEmail() {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Please verify your email address first",
+				"code":  "email_not_verified",
+			})
+		}
+
+		return c.Next()
+	}
+}
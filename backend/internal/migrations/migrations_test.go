@@ -0,0 +1,96 @@
+package migrations
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/glebarez/sqlite"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+)
+
+func TestEmbeddedMigrationsAreOrderedAndPaired(t *testing.T) {
+	postgres, err := load(files, "postgres")
+	require.NoError(t, err)
+	require.NotEmpty(t, postgres)
+
+	sqlite, err := load(files, "sqlite")
+	require.NoError(t, err)
+	require.Len(t, sqlite, len(postgres), "every migration needs a SQLite variant")
+
+	for i, m := range postgres {
+		assert.Equal(t, int64(i+1), m.Version, "versions must be contiguous")
+		assert.NotEmpty(t, m.Up)
+		assert.NotEmpty(t, m.Down)
+		assert.Equal(t, m.Version, sqlite[i].Version)
+		assert.Equal(t, m.Name, sqlite[i].Name)
+	}
+}
+
+func TestUpAndDownOnSQLite(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+
+	migrator, err := New(db)
+	require.NoError(t, err)
+	assert.ErrorIs(t, migrator.EnsureCurrent(), ErrSchemaBehind)
+
+	applied, err := migrator.Up()
+	require.NoError(t, err)
+	assert.Len(t, applied, len(migrator.migrations))
+	assert.NoError(t, migrator.EnsureCurrent())
+
+	rolledBack, err := migrator.Down(len(migrator.migrations))
+	require.NoError(t, err)
+	assert.Len(t, rolledBack, len(migrator.migrations))
+	assert.False(t, db.Migrator().HasTable("users"))
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	statuses, err := migrator.Status()
+	require.NoError(t, err)
+	for _, s := range statuses {
+		assert.NotNil(t, s.AppliedAt, "%04d_%s", s.Version, s.Name)
+	}
+}
+
+func TestLoadRejectsInvalidFiles(t *testing.T) {
+	_, err := load(fstest.MapFS{
+		"sql/test/0001_init.up.sql": {Data: []byte("SELECT 1;")},
+	}, "test")
+	assert.Error(t, err, "missing down file")
+
+	_, err = load(fstest.MapFS{
+		"sql/test/init.up.sql": {Data: []byte("SELECT 1;")},
+	}, "test")
+	assert.Error(t, err, "missing version")
+
+	_, err = load(fstest.MapFS{
+		"sql/test/0001_init.up.sql":    {Data: []byte("SELECT 1;")},
+		"sql/test/0001_other.down.sql": {Data: []byte("SELECT 1;")},
+	}, "test")
+	assert.Error(t, err, "conflicting names")
+}
+
+func TestExistingAccountsAreGrandfatheredAsVerified(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	migrator, err := New(db)
+	require.NoError(t, err)
+
+	// An account created before email verification (0003) existed
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	_, err = migrator.Down(len(migrator.migrations) - 2)
+	require.NoError(t, err)
+	require.NoError(t, db.Exec(`INSERT INTO users (id, email, username, password_hash, created_at)
+		VALUES ('11111111-1111-1111-1111-111111111111', 'old@example.com', 'old', 'hash', '2024-01-02 03:04:05+00:00')`).Error)
+
+	_, err = migrator.Up()
+	require.NoError(t, err)
+	var verifiedAt *string
+	require.NoError(t, db.Raw("SELECT email_verified_at FROM users WHERE username = 'old'").Scan(&verifiedAt).Error)
+	require.NotNil(t, verifiedAt)
+	assert.Contains(t, *verifiedAt, "2024-01-02")
+}
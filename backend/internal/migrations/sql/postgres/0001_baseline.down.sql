@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS category_keywords;
+DROP TABLE IF EXISTS commission_rates;
+DROP TABLE IF EXISTS social_clicks;
+DROP TABLE IF EXISTS page_views;
+DROP TABLE IF EXISTS link_clicks;
+DROP TABLE IF EXISTS links;
+DROP TABLE IF EXISTS contacts;
+DROP TABLE IF EXISTS users;
@@ -0,0 +1,113 @@
+-- Schema as it existed before versioned migrations. Uses IF NOT EXISTS so
+-- databases created by the old AutoMigrate or manual scripts are adopted as-is.
+
+CREATE TABLE IF NOT EXISTS users (
+    id            UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    email         VARCHAR(255) NOT NULL UNIQUE,
+    username      VARCHAR(50)  NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    display_name  VARCHAR(100),
+    location      VARCHAR(100),
+    bio           TEXT,
+    avatar_url    VARCHAR(500),
+    banner_url    VARCHAR(500),
+    banner_color  VARCHAR(7)  DEFAULT '#FF6B35',
+    theme         VARCHAR(50) DEFAULT 'sunset',
+    is_verified   BOOLEAN DEFAULT FALSE,
+    total_views   BIGINT  DEFAULT 0,
+    total_clicks  BIGINT  DEFAULT 0,
+    created_at    TIMESTAMPTZ,
+    updated_at    TIMESTAMPTZ
+);
+
+CREATE TABLE IF NOT EXISTS contacts (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id),
+    type       VARCHAR(50)  NOT NULL,
+    url        VARCHAR(500) NOT NULL,
+    position   BIGINT DEFAULT 0,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_contacts_user_id ON contacts (user_id);
+
+CREATE TABLE IF NOT EXISTS links (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id        UUID NOT NULL REFERENCES users(id),
+    title          VARCHAR(255)  NOT NULL,
+    subtitle       VARCHAR(255),
+    url            VARCHAR(1000) NOT NULL,
+    image_url      VARCHAR(500),
+    price          DECIMAL,
+    original_price DECIMAL,
+    discount       VARCHAR(10),
+    badge          VARCHAR(20),
+    rating         DECIMAL(3,2),
+    sold           BIGINT,
+    category       VARCHAR(50),
+    platform       VARCHAR(50),
+    position       BIGINT  DEFAULT 0,
+    is_active      BOOLEAN DEFAULT TRUE,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_links_user_id ON links (user_id);
+
+CREATE TABLE IF NOT EXISTS link_clicks (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    link_id    UUID REFERENCES links(id) ON DELETE SET NULL,
+    user_id    UUID NOT NULL REFERENCES users(id),
+    visitor_id VARCHAR(36),
+    source     VARCHAR(50),
+    platform   VARCHAR(50),
+    category   VARCHAR(50),
+    visitor_ip VARCHAR(45),
+    user_agent TEXT,
+    referer    VARCHAR(500),
+    clicked_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_link_clicks_link_id ON link_clicks (link_id);
+CREATE INDEX IF NOT EXISTS idx_link_clicks_user_id ON link_clicks (user_id);
+CREATE INDEX IF NOT EXISTS idx_link_clicks_visitor_id ON link_clicks (visitor_id);
+
+CREATE TABLE IF NOT EXISTS page_views (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id),
+    visitor_id VARCHAR(36),
+    source     VARCHAR(50),
+    visitor_ip VARCHAR(45),
+    user_agent TEXT,
+    viewed_at  TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_page_views_user_id ON page_views (user_id);
+CREATE INDEX IF NOT EXISTS idx_page_views_visitor_id ON page_views (visitor_id);
+
+CREATE TABLE IF NOT EXISTS social_clicks (
+    id          UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id     UUID NOT NULL REFERENCES users(id),
+    visitor_id  VARCHAR(36),
+    source      VARCHAR(50),
+    social_type VARCHAR(50),
+    clicked_at  TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_social_clicks_user_id ON social_clicks (user_id);
+CREATE INDEX IF NOT EXISTS idx_social_clicks_visitor_id ON social_clicks (visitor_id);
+
+CREATE TABLE IF NOT EXISTS commission_rates (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    platform       VARCHAR(50)  NOT NULL,
+    category       VARCHAR(100) NOT NULL,
+    rate_percent   DECIMAL(5,2) NOT NULL,
+    max_commission BIGINT,
+    notes          TEXT,
+    created_at     TIMESTAMPTZ,
+    updated_at     TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_platform_category ON commission_rates (platform, category);
+
+CREATE TABLE IF NOT EXISTS category_keywords (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    category   VARCHAR(50)  NOT NULL,
+    keyword    VARCHAR(100) NOT NULL,
+    source     VARCHAR(20)  NOT NULL DEFAULT 'title',
+    created_at TIMESTAMPTZ
+);
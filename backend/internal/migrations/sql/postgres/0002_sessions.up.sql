@@ -0,0 +1,31 @@
+-- Revocable sessions and rotating refresh tokens
+
+CREATE TABLE IF NOT EXISTS sessions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device       VARCHAR(100),
+    ip_address   VARCHAR(45),
+    user_agent   TEXT,
+    created_at   TIMESTAMPTZ,
+    last_seen_at TIMESTAMPTZ,
+    expires_at   TIMESTAMPTZ NOT NULL,
+    revoked_at   TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX IF NOT EXISTS idx_sessions_revoked_at ON sessions (revoked_at);
+
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    id             UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id        UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id      UUID NOT NULL,
+    token_hash     VARCHAR(64) NOT NULL,
+    expires_at     TIMESTAMPTZ NOT NULL,
+    rotated_at     TIMESTAMPTZ,
+    replaced_by_id UUID,
+    revoked_at     TIMESTAMPTZ,
+    created_at     TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_revoked_at ON refresh_tokens (revoked_at);
@@ -0,0 +1,15 @@
+-- Login history used for lockout and the security page
+
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID REFERENCES users(id) ON DELETE CASCADE,
+    identifier VARCHAR(255),
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    success    BOOLEAN DEFAULT FALSE,
+    reason     VARCHAR(50),
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_user_id ON login_attempts (user_id);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_ip_address ON login_attempts (ip_address);
+CREATE INDEX IF NOT EXISTS idx_login_attempts_created_at ON login_attempts (created_at);
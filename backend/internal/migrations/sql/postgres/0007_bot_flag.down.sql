@@ -0,0 +1,3 @@
+ALTER TABLE social_clicks DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE page_views DROP COLUMN IF EXISTS is_bot;
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS is_bot;
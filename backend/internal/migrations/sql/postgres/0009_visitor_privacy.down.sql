@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS visitor_salts;
+
+ALTER TABLE tracking_settings DROP COLUMN IF EXISTS capture_ip;
+
+ALTER TABLE page_views ADD COLUMN IF NOT EXISTS visitor_ip VARCHAR(45);
+ALTER TABLE page_views DROP COLUMN IF EXISTS region;
+ALTER TABLE page_views DROP COLUMN IF EXISTS country;
+ALTER TABLE page_views DROP COLUMN IF EXISTS ip_hash;
+
+ALTER TABLE link_clicks ADD COLUMN IF NOT EXISTS visitor_ip VARCHAR(45);
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS region;
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS country;
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS ip_hash;
@@ -0,0 +1,21 @@
+-- Visitor IPs are no longer stored: events keep a salted hash that rotates daily
+-- and the coarse location reported by the edge proxy
+
+ALTER TABLE link_clicks ADD COLUMN IF NOT EXISTS ip_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE link_clicks ADD COLUMN IF NOT EXISTS country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE link_clicks ADD COLUMN IF NOT EXISTS region VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS visitor_ip;
+
+ALTER TABLE page_views ADD COLUMN IF NOT EXISTS ip_hash VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE page_views ADD COLUMN IF NOT EXISTS country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE page_views ADD COLUMN IF NOT EXISTS region VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE page_views DROP COLUMN IF EXISTS visitor_ip;
+
+ALTER TABLE tracking_settings ADD COLUMN IF NOT EXISTS capture_ip BOOLEAN NOT NULL DEFAULT TRUE;
+
+-- One random salt per UTC day; salts of past days are deleted so old hashes cannot be reversed
+CREATE TABLE IF NOT EXISTS visitor_salts (
+    day        VARCHAR(10) PRIMARY KEY,
+    salt       VARCHAR(64) NOT NULL,
+    created_at TIMESTAMPTZ
+);
@@ -0,0 +1,32 @@
+-- Daily click and view counts per creator (UTC days). Reports read closed days
+-- from here and only the days after rollup_watermarks.rolled_through from the raw events.
+
+CREATE TABLE IF NOT EXISTS click_rollups_daily (
+    day      VARCHAR(10) NOT NULL,
+    user_id  UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    link_id  UUID,
+    source   VARCHAR(50) NOT NULL DEFAULT '',
+    platform VARCHAR(50) NOT NULL DEFAULT '',
+    category VARCHAR(50) NOT NULL DEFAULT '',
+    is_bot   BOOLEAN NOT NULL DEFAULT FALSE,
+    clicks   BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_click_rollups_daily_user_day ON click_rollups_daily (user_id, day);
+CREATE INDEX IF NOT EXISTS idx_click_rollups_daily_day ON click_rollups_daily (day);
+
+CREATE TABLE IF NOT EXISTS view_rollups_daily (
+    day     VARCHAR(10) NOT NULL,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source  VARCHAR(50) NOT NULL DEFAULT '',
+    is_bot  BOOLEAN NOT NULL DEFAULT FALSE,
+    views   BIGINT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_view_rollups_daily_user_day ON view_rollups_daily (user_id, day);
+CREATE INDEX IF NOT EXISTS idx_view_rollups_daily_day ON view_rollups_daily (day);
+
+-- Last day included in the rollups
+CREATE TABLE IF NOT EXISTS rollup_watermarks (
+    name           VARCHAR(50) PRIMARY KEY,
+    rolled_through VARCHAR(10) NOT NULL,
+    updated_at     TIMESTAMPTZ
+);
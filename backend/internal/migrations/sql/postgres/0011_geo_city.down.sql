@@ -0,0 +1,10 @@
+DELETE FROM rollup_watermarks;
+DELETE FROM click_rollups_daily;
+DELETE FROM view_rollups_daily;
+
+ALTER TABLE click_rollups_daily DROP COLUMN IF EXISTS city;
+ALTER TABLE click_rollups_daily DROP COLUMN IF EXISTS region;
+ALTER TABLE click_rollups_daily DROP COLUMN IF EXISTS country;
+
+ALTER TABLE page_views DROP COLUMN IF EXISTS city;
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS city;
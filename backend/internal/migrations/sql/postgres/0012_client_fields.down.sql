@@ -0,0 +1,15 @@
+DELETE FROM rollup_watermarks;
+DELETE FROM click_rollups_daily;
+DELETE FROM view_rollups_daily;
+
+ALTER TABLE click_rollups_daily DROP COLUMN IF EXISTS browser;
+ALTER TABLE click_rollups_daily DROP COLUMN IF EXISTS os;
+ALTER TABLE click_rollups_daily DROP COLUMN IF EXISTS device_type;
+
+ALTER TABLE page_views DROP COLUMN IF EXISTS browser;
+ALTER TABLE page_views DROP COLUMN IF EXISTS os;
+ALTER TABLE page_views DROP COLUMN IF EXISTS device_type;
+
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS browser;
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS os;
+ALTER TABLE link_clicks DROP COLUMN IF EXISTS device_type;
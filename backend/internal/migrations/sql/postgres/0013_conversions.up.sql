@@ -0,0 +1,34 @@
+-- Orders imported from marketplace affiliate reports, matched back to links
+
+CREATE TABLE IF NOT EXISTS conversion_imports (
+    id         UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id    UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform   VARCHAR(50) NOT NULL,
+    filename   VARCHAR(255) NOT NULL DEFAULT '',
+    row_count  INTEGER NOT NULL DEFAULT 0,
+    matched    INTEGER NOT NULL DEFAULT 0,
+    skipped    INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ
+);
+CREATE INDEX IF NOT EXISTS idx_conversion_imports_user_id ON conversion_imports (user_id, created_at);
+
+CREATE TABLE IF NOT EXISTS conversions (
+    id           UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id      UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    link_id      UUID REFERENCES links(id) ON DELETE SET NULL,
+    import_id    UUID REFERENCES conversion_imports(id) ON DELETE SET NULL,
+    platform     VARCHAR(50) NOT NULL,
+    order_id     VARCHAR(100) NOT NULL,
+    item_id      VARCHAR(100) NOT NULL DEFAULT '',
+    item_name    VARCHAR(255) NOT NULL DEFAULT '',
+    sub_id       VARCHAR(100) NOT NULL DEFAULT '',
+    status       VARCHAR(20) NOT NULL,
+    order_amount DECIMAL(14,2) NOT NULL DEFAULT 0,
+    commission   DECIMAL(14,2) NOT NULL DEFAULT 0,
+    ordered_at   TIMESTAMPTZ NOT NULL,
+    created_at   TIMESTAMPTZ,
+    updated_at   TIMESTAMPTZ
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_conversions_order_item ON conversions (user_id, platform, order_id, item_id);
+CREATE INDEX IF NOT EXISTS idx_conversions_user_ordered_at ON conversions (user_id, ordered_at);
+CREATE INDEX IF NOT EXISTS idx_conversions_link_id ON conversions (link_id);
@@ -0,0 +1,2 @@
+ALTER TABLE conversions DROP COLUMN IF EXISTS source;
+DROP TABLE IF EXISTS link_tagging;
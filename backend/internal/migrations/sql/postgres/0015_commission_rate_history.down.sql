@@ -0,0 +1,12 @@
+-- Keeps one rate per platform and category: the platform rates without an end date
+DELETE FROM commission_rates WHERE user_id IS NOT NULL OR effective_to IS NOT NULL;
+DELETE FROM commission_rates a USING commission_rates b
+    WHERE a.platform = b.platform AND a.category = b.category AND a.effective_from < b.effective_from;
+
+DROP INDEX IF EXISTS idx_commission_rates_user;
+DROP INDEX IF EXISTS idx_commission_rates_lookup;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_platform_category ON commission_rates (platform, category);
+
+ALTER TABLE commission_rates DROP COLUMN IF EXISTS effective_to;
+ALTER TABLE commission_rates DROP COLUMN IF EXISTS effective_from;
+ALTER TABLE commission_rates DROP COLUMN IF EXISTS user_id;
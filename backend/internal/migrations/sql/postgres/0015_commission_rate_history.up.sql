@@ -0,0 +1,12 @@
+-- Commission rates are versioned: a rate is in force from effective_from until
+-- effective_to (exclusive, NULL while current), so editing rates no longer changes
+-- the revenue estimated for earlier clicks. Rates with a user_id override the
+-- platform rates for one creator on a special program.
+
+ALTER TABLE commission_rates ADD COLUMN IF NOT EXISTS user_id UUID REFERENCES users(id) ON DELETE CASCADE;
+ALTER TABLE commission_rates ADD COLUMN IF NOT EXISTS effective_from TIMESTAMPTZ NOT NULL DEFAULT '1970-01-01 00:00:00+00';
+ALTER TABLE commission_rates ADD COLUMN IF NOT EXISTS effective_to TIMESTAMPTZ;
+
+DROP INDEX IF EXISTS idx_platform_category;
+CREATE INDEX IF NOT EXISTS idx_commission_rates_lookup ON commission_rates (platform, category, effective_from);
+CREATE INDEX IF NOT EXISTS idx_commission_rates_user ON commission_rates (user_id);
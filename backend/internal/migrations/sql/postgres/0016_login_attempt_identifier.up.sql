@@ -0,0 +1,4 @@
+-- Account lockout counts failed logins per identifier as typed, so usernames
+-- that match no account lock out the same way as existing ones.
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_identifier ON login_attempts (identifier, created_at);
@@ -0,0 +1,113 @@
+-- SQLite variant used for local development and tests.
+-- IDs are generated by the application (BeforeCreate hooks).
+
+CREATE TABLE users (
+    id            TEXT PRIMARY KEY,
+    email         VARCHAR(255) NOT NULL UNIQUE,
+    username      VARCHAR(50)  NOT NULL UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    display_name  VARCHAR(100),
+    location      VARCHAR(100),
+    bio           TEXT,
+    avatar_url    VARCHAR(500),
+    banner_url    VARCHAR(500),
+    banner_color  VARCHAR(7)  DEFAULT '#FF6B35',
+    theme         VARCHAR(50) DEFAULT 'sunset',
+    is_verified   BOOLEAN DEFAULT FALSE,
+    total_views   INTEGER DEFAULT 0,
+    total_clicks  INTEGER DEFAULT 0,
+    created_at    DATETIME,
+    updated_at    DATETIME
+);
+
+CREATE TABLE contacts (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id),
+    type       VARCHAR(50)  NOT NULL,
+    url        VARCHAR(500) NOT NULL,
+    position   INTEGER DEFAULT 0,
+    created_at DATETIME
+);
+CREATE INDEX idx_contacts_user_id ON contacts (user_id);
+
+CREATE TABLE links (
+    id             TEXT PRIMARY KEY,
+    user_id        TEXT NOT NULL REFERENCES users(id),
+    title          VARCHAR(255)  NOT NULL,
+    subtitle       VARCHAR(255),
+    url            VARCHAR(1000) NOT NULL,
+    image_url      VARCHAR(500),
+    price          REAL,
+    original_price REAL,
+    discount       VARCHAR(10),
+    badge          VARCHAR(20),
+    rating         REAL,
+    sold           INTEGER,
+    category       VARCHAR(50),
+    platform       VARCHAR(50),
+    position       INTEGER DEFAULT 0,
+    is_active      BOOLEAN DEFAULT TRUE,
+    created_at     DATETIME,
+    updated_at     DATETIME
+);
+CREATE INDEX idx_links_user_id ON links (user_id);
+
+CREATE TABLE link_clicks (
+    id         TEXT PRIMARY KEY,
+    link_id    TEXT REFERENCES links(id) ON DELETE SET NULL,
+    user_id    TEXT NOT NULL REFERENCES users(id),
+    visitor_id VARCHAR(36),
+    source     VARCHAR(50),
+    platform   VARCHAR(50),
+    category   VARCHAR(50),
+    visitor_ip VARCHAR(45),
+    user_agent TEXT,
+    referer    VARCHAR(500),
+    clicked_at DATETIME
+);
+CREATE INDEX idx_link_clicks_link_id ON link_clicks (link_id);
+CREATE INDEX idx_link_clicks_user_id ON link_clicks (user_id);
+CREATE INDEX idx_link_clicks_visitor_id ON link_clicks (visitor_id);
+
+CREATE TABLE page_views (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id),
+    visitor_id VARCHAR(36),
+    source     VARCHAR(50),
+    visitor_ip VARCHAR(45),
+    user_agent TEXT,
+    viewed_at  DATETIME
+);
+CREATE INDEX idx_page_views_user_id ON page_views (user_id);
+CREATE INDEX idx_page_views_visitor_id ON page_views (visitor_id);
+
+CREATE TABLE social_clicks (
+    id          TEXT PRIMARY KEY,
+    user_id     TEXT NOT NULL REFERENCES users(id),
+    visitor_id  VARCHAR(36),
+    source      VARCHAR(50),
+    social_type VARCHAR(50),
+    clicked_at  DATETIME
+);
+CREATE INDEX idx_social_clicks_user_id ON social_clicks (user_id);
+CREATE INDEX idx_social_clicks_visitor_id ON social_clicks (visitor_id);
+
+CREATE TABLE commission_rates (
+    id             TEXT PRIMARY KEY,
+    platform       VARCHAR(50)  NOT NULL,
+    category       VARCHAR(100) NOT NULL,
+    rate_percent   REAL NOT NULL,
+    max_commission INTEGER,
+    notes          TEXT,
+    created_at     DATETIME,
+    updated_at     DATETIME
+);
+CREATE UNIQUE INDEX idx_platform_category ON commission_rates (platform, category);
+
+CREATE TABLE category_keywords (
+    id         TEXT PRIMARY KEY,
+    category   VARCHAR(50)  NOT NULL,
+    keyword    VARCHAR(100) NOT NULL,
+    source     VARCHAR(20)  NOT NULL DEFAULT 'title',
+    created_at DATETIME
+);
@@ -0,0 +1,31 @@
+-- Revocable sessions and rotating refresh tokens
+
+CREATE TABLE sessions (
+    id           TEXT PRIMARY KEY,
+    user_id      TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    device       VARCHAR(100),
+    ip_address   VARCHAR(45),
+    user_agent   TEXT,
+    created_at   DATETIME,
+    last_seen_at DATETIME,
+    expires_at   DATETIME NOT NULL,
+    revoked_at   DATETIME
+);
+CREATE INDEX idx_sessions_user_id ON sessions (user_id);
+CREATE INDEX idx_sessions_revoked_at ON sessions (revoked_at);
+
+CREATE TABLE refresh_tokens (
+    id             TEXT PRIMARY KEY,
+    user_id        TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    family_id      TEXT NOT NULL,
+    token_hash     VARCHAR(64) NOT NULL,
+    expires_at     DATETIME NOT NULL,
+    rotated_at     DATETIME,
+    replaced_by_id TEXT,
+    revoked_at     DATETIME,
+    created_at     DATETIME
+);
+CREATE INDEX idx_refresh_tokens_user_id ON refresh_tokens (user_id);
+CREATE INDEX idx_refresh_tokens_family_id ON refresh_tokens (family_id);
+CREATE UNIQUE INDEX idx_refresh_tokens_token_hash ON refresh_tokens (token_hash);
+CREATE INDEX idx_refresh_tokens_revoked_at ON refresh_tokens (revoked_at);
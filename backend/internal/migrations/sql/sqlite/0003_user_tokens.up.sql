@@ -0,0 +1,21 @@
+-- Single-use tokens for password reset and email verification
+
+CREATE TABLE user_tokens (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    purpose    VARCHAR(30) NOT NULL,
+    token_hash VARCHAR(64) NOT NULL,
+    email      VARCHAR(255),
+    expires_at DATETIME NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME
+);
+CREATE INDEX idx_user_tokens_user_id ON user_tokens (user_id);
+CREATE INDEX idx_user_tokens_purpose ON user_tokens (purpose);
+CREATE UNIQUE INDEX idx_user_tokens_token_hash ON user_tokens (token_hash);
+
+ALTER TABLE users ADD COLUMN email_verified_at DATETIME;
+
+-- Accounts created before verification existed are grandfathered as verified,
+-- so they can keep creating and editing links
+UPDATE users SET email_verified_at = COALESCE(created_at, CURRENT_TIMESTAMP) WHERE email_verified_at IS NULL;
@@ -0,0 +1,14 @@
+-- Optional TOTP two-factor authentication
+
+ALTER TABLE users ADD COLUMN two_factor_enabled BOOLEAN DEFAULT FALSE;
+ALTER TABLE users ADD COLUMN totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN totp_last_used_step INTEGER DEFAULT 0;
+
+CREATE TABLE recovery_codes (
+    id         TEXT PRIMARY KEY,
+    user_id    TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    code_hash  VARCHAR(64) NOT NULL,
+    used_at    DATETIME,
+    created_at DATETIME
+);
+CREATE INDEX idx_recovery_codes_user_id ON recovery_codes (user_id);
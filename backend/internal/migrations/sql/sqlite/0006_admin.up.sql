@@ -0,0 +1,19 @@
+-- Roles, suspension and the admin audit log
+
+ALTER TABLE users ADD COLUMN role VARCHAR(20) NOT NULL DEFAULT 'creator';
+ALTER TABLE users ADD COLUMN suspended_at DATETIME;
+
+CREATE TABLE audit_logs (
+    id          TEXT PRIMARY KEY,
+    actor_id    TEXT NOT NULL,
+    action      VARCHAR(50) NOT NULL,
+    target_type VARCHAR(50) NOT NULL,
+    target_id   VARCHAR(64),
+    details     TEXT,
+    ip_address  VARCHAR(45),
+    created_at  DATETIME
+);
+CREATE INDEX idx_audit_logs_actor_id ON audit_logs (actor_id);
+CREATE INDEX idx_audit_logs_action ON audit_logs (action);
+CREATE INDEX idx_audit_logs_target_id ON audit_logs (target_id);
+CREATE INDEX idx_audit_logs_created_at ON audit_logs (created_at);
@@ -0,0 +1,3 @@
+ALTER TABLE social_clicks DROP COLUMN is_bot;
+ALTER TABLE page_views DROP COLUMN is_bot;
+ALTER TABLE link_clicks DROP COLUMN is_bot;
@@ -0,0 +1,5 @@
+-- Tracking events classified as bots are kept but excluded from dashboards by default
+
+ALTER TABLE link_clicks ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE page_views ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE social_clicks ADD COLUMN is_bot BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,10 @@
+-- Per-creator tracking preferences (dedup policy); creators without a row use the defaults
+
+CREATE TABLE tracking_settings (
+    user_id                     TEXT PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+    dedup_key                   VARCHAR(20) NOT NULL DEFAULT 'visitor',
+    click_dedup_window_seconds  INTEGER NOT NULL,
+    view_dedup_window_seconds   INTEGER NOT NULL,
+    social_dedup_window_seconds INTEGER NOT NULL,
+    updated_at                  DATETIME
+);
@@ -0,0 +1,13 @@
+DROP TABLE IF EXISTS visitor_salts;
+
+ALTER TABLE tracking_settings DROP COLUMN capture_ip;
+
+ALTER TABLE page_views ADD COLUMN visitor_ip VARCHAR(45);
+ALTER TABLE page_views DROP COLUMN region;
+ALTER TABLE page_views DROP COLUMN country;
+ALTER TABLE page_views DROP COLUMN ip_hash;
+
+ALTER TABLE link_clicks ADD COLUMN visitor_ip VARCHAR(45);
+ALTER TABLE link_clicks DROP COLUMN region;
+ALTER TABLE link_clicks DROP COLUMN country;
+ALTER TABLE link_clicks DROP COLUMN ip_hash;
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS rollup_watermarks;
+DROP TABLE IF EXISTS view_rollups_daily;
+DROP TABLE IF EXISTS click_rollups_daily;
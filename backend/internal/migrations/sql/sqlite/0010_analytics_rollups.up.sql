@@ -0,0 +1,32 @@
+-- Daily click and view counts per creator (UTC days). Reports read closed days
+-- from here and only the days after rollup_watermarks.rolled_through from the raw events.
+
+CREATE TABLE click_rollups_daily (
+    day      VARCHAR(10) NOT NULL,
+    user_id  TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    link_id  TEXT,
+    source   VARCHAR(50) NOT NULL DEFAULT '',
+    platform VARCHAR(50) NOT NULL DEFAULT '',
+    category VARCHAR(50) NOT NULL DEFAULT '',
+    is_bot   BOOLEAN NOT NULL DEFAULT FALSE,
+    clicks   BIGINT NOT NULL
+);
+CREATE INDEX idx_click_rollups_daily_user_day ON click_rollups_daily (user_id, day);
+CREATE INDEX idx_click_rollups_daily_day ON click_rollups_daily (day);
+
+CREATE TABLE view_rollups_daily (
+    day     VARCHAR(10) NOT NULL,
+    user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    source  VARCHAR(50) NOT NULL DEFAULT '',
+    is_bot  BOOLEAN NOT NULL DEFAULT FALSE,
+    views   BIGINT NOT NULL
+);
+CREATE INDEX idx_view_rollups_daily_user_day ON view_rollups_daily (user_id, day);
+CREATE INDEX idx_view_rollups_daily_day ON view_rollups_daily (day);
+
+-- Last day included in the rollups
+CREATE TABLE rollup_watermarks (
+    name           VARCHAR(50) PRIMARY KEY,
+    rolled_through VARCHAR(10) NOT NULL,
+    updated_at     DATETIME
+);
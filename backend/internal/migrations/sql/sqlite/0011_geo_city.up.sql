@@ -0,0 +1,13 @@
+-- City resolved from the GeoIP database at ingest, and location in the click rollups
+
+ALTER TABLE link_clicks ADD COLUMN city VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE page_views ADD COLUMN city VARCHAR(100) NOT NULL DEFAULT '';
+
+ALTER TABLE click_rollups_daily ADD COLUMN country VARCHAR(2) NOT NULL DEFAULT '';
+ALTER TABLE click_rollups_daily ADD COLUMN region VARCHAR(64) NOT NULL DEFAULT '';
+ALTER TABLE click_rollups_daily ADD COLUMN city VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Existing rollups have no location: drop them so the rollup job rebuilds them from the events
+DELETE FROM rollup_watermarks;
+DELETE FROM click_rollups_daily;
+DELETE FROM view_rollups_daily;
@@ -0,0 +1,15 @@
+DELETE FROM rollup_watermarks;
+DELETE FROM click_rollups_daily;
+DELETE FROM view_rollups_daily;
+
+ALTER TABLE click_rollups_daily DROP COLUMN browser;
+ALTER TABLE click_rollups_daily DROP COLUMN os;
+ALTER TABLE click_rollups_daily DROP COLUMN device_type;
+
+ALTER TABLE page_views DROP COLUMN browser;
+ALTER TABLE page_views DROP COLUMN os;
+ALTER TABLE page_views DROP COLUMN device_type;
+
+ALTER TABLE link_clicks DROP COLUMN browser;
+ALTER TABLE link_clicks DROP COLUMN os;
+ALTER TABLE link_clicks DROP COLUMN device_type;
@@ -0,0 +1,18 @@
+-- Device type, OS and browser parsed from the user agent at ingest (see internal/useragent)
+
+ALTER TABLE link_clicks ADD COLUMN device_type VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE link_clicks ADD COLUMN os VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE link_clicks ADD COLUMN browser VARCHAR(30) NOT NULL DEFAULT '';
+
+ALTER TABLE page_views ADD COLUMN device_type VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE page_views ADD COLUMN os VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE page_views ADD COLUMN browser VARCHAR(30) NOT NULL DEFAULT '';
+
+ALTER TABLE click_rollups_daily ADD COLUMN device_type VARCHAR(10) NOT NULL DEFAULT '';
+ALTER TABLE click_rollups_daily ADD COLUMN os VARCHAR(20) NOT NULL DEFAULT '';
+ALTER TABLE click_rollups_daily ADD COLUMN browser VARCHAR(30) NOT NULL DEFAULT '';
+
+-- Rebuild the click rollups with the new columns; run `useragent backfill` to classify older events
+DELETE FROM rollup_watermarks;
+DELETE FROM click_rollups_daily;
+DELETE FROM view_rollups_daily;
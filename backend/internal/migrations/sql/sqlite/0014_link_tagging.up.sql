@@ -0,0 +1,13 @@
+-- Per-creator, per-marketplace tagging of outbound URLs with affiliate sub-IDs (see internal/subid)
+
+CREATE TABLE link_tagging (
+    user_id      TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    platform     VARCHAR(50) NOT NULL,
+    enabled      BOOLEAN NOT NULL DEFAULT FALSE,
+    affiliate_id VARCHAR(100) NOT NULL DEFAULT '',
+    updated_at   DATETIME,
+    PRIMARY KEY (user_id, platform)
+);
+
+-- Traffic source read back from the sub-IDs of tagged orders
+ALTER TABLE conversions ADD COLUMN source VARCHAR(50) NOT NULL DEFAULT '';
@@ -0,0 +1,24 @@
+-- Keeps one rate per platform and category: the platform rates without an end date.
+-- SQLite cannot drop a column with a foreign key, so the table is rebuilt.
+DELETE FROM commission_rates WHERE user_id IS NOT NULL OR effective_to IS NOT NULL;
+DELETE FROM commission_rates WHERE EXISTS (
+    SELECT 1 FROM commission_rates b
+    WHERE b.platform = commission_rates.platform AND b.category = commission_rates.category
+      AND b.effective_from > commission_rates.effective_from
+);
+
+CREATE TABLE commission_rates_old (
+    id             TEXT PRIMARY KEY,
+    platform       VARCHAR(50)  NOT NULL,
+    category       VARCHAR(100) NOT NULL,
+    rate_percent   REAL NOT NULL,
+    max_commission INTEGER,
+    notes          TEXT,
+    created_at     DATETIME,
+    updated_at     DATETIME
+);
+INSERT INTO commission_rates_old (id, platform, category, rate_percent, max_commission, notes, created_at, updated_at)
+    SELECT id, platform, category, rate_percent, max_commission, notes, created_at, updated_at FROM commission_rates;
+DROP TABLE commission_rates;
+ALTER TABLE commission_rates_old RENAME TO commission_rates;
+CREATE UNIQUE INDEX idx_platform_category ON commission_rates (platform, category);
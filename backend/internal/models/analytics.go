@@ -9,17 +9,24 @@ import (
 
 // LinkClick tracks clicks on affiliate links
 type LinkClick struct {
-	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	LinkID    *uuid.UUID `gorm:"type:uuid;index" json:"link_id"`          // Nullable - preserved when link deleted
-	UserID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // Owner of the link
-	VisitorID string     `gorm:"size:36;index" json:"visitor_id"`         // For deduplication
-	Source    string     `gorm:"size:50" json:"source"`                   // utm_source (instagram, tiktok, etc)
-	Platform  string     `gorm:"size:50" json:"platform"`                 // shopee, tokopedia, etc
-	Category  string     `gorm:"size:50" json:"category"`                 // product category
-	VisitorIP string     `gorm:"size:45" json:"visitor_ip"`
-	UserAgent string     `gorm:"type:text" json:"user_agent"`
-	Referer   string     `gorm:"size:500" json:"referer"`
-	ClickedAt time.Time  `gorm:"autoCreateTime" json:"clicked_at"`
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LinkID     *uuid.UUID `gorm:"type:uuid;index" json:"link_id"`          // Nullable - preserved when link deleted
+	UserID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"` // Owner of the link
+	VisitorID  string     `gorm:"size:36;index" json:"visitor_id"`         // For deduplication
+	Source     string     `gorm:"size:50" json:"source"`                   // utm_source (instagram, tiktok, etc)
+	Platform   string     `gorm:"size:50" json:"platform"`                 // shopee, tokopedia, etc
+	Category   string     `gorm:"size:50" json:"category"`                 // product category
+	IPHash     string     `gorm:"size:64" json:"-"`                        // Salted hash of the IP, rotated daily
+	Country    string     `gorm:"size:2" json:"country"`                   // ISO code from the edge proxy or GeoIP database
+	Region     string     `gorm:"size:64" json:"region"`
+	City       string     `gorm:"size:100" json:"city"` // From the GeoIP database
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	DeviceType string     `gorm:"size:10" json:"device_type"` // mobile, tablet or desktop, parsed from the user agent
+	OS         string     `gorm:"size:20" json:"os"`
+	Browser    string     `gorm:"size:30" json:"browser"` // the app for in-app browsers (Instagram, TikTok, ...)
+	Referer    string     `gorm:"size:500" json:"referer"`
+	IsBot      bool       `gorm:"not null;default:false" json:"is_bot"` // Classified as crawler/automation
+	ClickedAt  time.Time  `gorm:"autoCreateTime" json:"clicked_at"`
 
 	// Relationships - SET NULL when link is deleted to preserve analytics
 	Link *Link `gorm:"foreignKey:LinkID;constraint:OnDelete:SET NULL" json:"-"`
@@ -35,13 +42,20 @@ func (lc *LinkClick) BeforeCreate(tx *gorm.DB) error {
 
 // PageView tracks views on public profile pages
 type PageView struct {
-	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"` // Owner of the profile
-	VisitorID string    `gorm:"size:36;index" json:"visitor_id"`         // For tracking
-	Source    string    `gorm:"size:50" json:"source"`                   // utm_source
-	VisitorIP string    `gorm:"size:45" json:"visitor_ip"`
-	UserAgent string    `gorm:"type:text" json:"user_agent"`
-	ViewedAt  time.Time `gorm:"autoCreateTime" json:"viewed_at"`
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"` // Owner of the profile
+	VisitorID  string    `gorm:"size:36;index" json:"visitor_id"`         // For tracking
+	Source     string    `gorm:"size:50" json:"source"`                   // utm_source
+	IPHash     string    `gorm:"size:64" json:"-"`                        // Salted hash of the IP, rotated daily
+	Country    string    `gorm:"size:2" json:"country"`                   // ISO code from the edge proxy or GeoIP database
+	Region     string    `gorm:"size:64" json:"region"`
+	City       string    `gorm:"size:100" json:"city"`
+	UserAgent  string    `gorm:"type:text" json:"user_agent"`
+	DeviceType string    `gorm:"size:10" json:"device_type"`
+	OS         string    `gorm:"size:20" json:"os"`
+	Browser    string    `gorm:"size:30" json:"browser"`
+	IsBot      bool      `gorm:"not null;default:false" json:"is_bot"`
+	ViewedAt   time.Time `gorm:"autoCreateTime" json:"viewed_at"`
 
 	// Relationships
 	User User `gorm:"foreignKey:UserID" json:"-"`
@@ -61,6 +75,7 @@ type SocialClick struct {
 	VisitorID  string    `gorm:"size:36;index" json:"visitor_id"`
 	Source     string    `gorm:"size:50" json:"source"`      // utm_source
 	SocialType string    `gorm:"size:50" json:"social_type"` // instagram, tiktok, youtube, etc
+	IsBot      bool      `gorm:"not null;default:false" json:"is_bot"`
 	ClickedAt  time.Time `gorm:"autoCreateTime" json:"clicked_at"`
 
 	// Relationships
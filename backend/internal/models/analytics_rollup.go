@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ClickRollup counts the clicks of one UTC day with the same link, source,
+// platform, category, location, client and bot flag
+type ClickRollup struct {
+	Day        string     `gorm:"size:10;not null"` // YYYY-MM-DD
+	UserID     uuid.UUID  `gorm:"type:uuid;not null"`
+	LinkID     *uuid.UUID `gorm:"type:uuid"` // Nil once the link is deleted
+	Source     string     `gorm:"size:50;not null"`
+	Platform   string     `gorm:"size:50;not null"`
+	Category   string     `gorm:"size:50;not null"`
+	Country    string     `gorm:"size:2;not null"`
+	Region     string     `gorm:"size:64;not null"`
+	City       string     `gorm:"size:100;not null"`
+	DeviceType string     `gorm:"size:10;not null"`
+	OS         string     `gorm:"size:20;not null"`
+	Browser    string     `gorm:"size:30;not null"`
+	IsBot      bool       `gorm:"not null"`
+	Clicks     int64      `gorm:"not null"`
+}
+
+func (ClickRollup) TableName() string {
+	return "click_rollups_daily"
+}
+
+// ViewRollup counts the profile views of one UTC day with the same source and bot flag
+type ViewRollup struct {
+	Day    string    `gorm:"size:10;not null"`
+	UserID uuid.UUID `gorm:"type:uuid;not null"`
+	Source string    `gorm:"size:50;not null"`
+	IsBot  bool      `gorm:"not null"`
+	Views  int64     `gorm:"not null"`
+}
+
+func (ViewRollup) TableName() string {
+	return "view_rollups_daily"
+}
+
+// RollupWatermark records the last day a set of rollups includes
+type RollupWatermark struct {
+	Name          string    `gorm:"size:50;primaryKey"`
+	RolledThrough string    `gorm:"size:10;not null"` // YYYY-MM-DD
+	UpdatedAt     time.Time `gorm:"autoUpdateTime"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditLog records every change made through the admin API
+type AuditLog struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	ActorID    uuid.UUID `gorm:"type:uuid;not null;index" json:"actor_id"` // Admin who made the change
+	Action     string    `gorm:"size:50;not null;index" json:"action"`     // e.g. user.suspend, commission_rate.update
+	TargetType string    `gorm:"size:50;not null" json:"target_type"`
+	TargetID   string    `gorm:"size:64;index" json:"target_id"`
+	Details    string    `gorm:"type:text" json:"details"` // JSON with before/after values
+	IPAddress  string    `gorm:"size:45" json:"ip_address"`
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+}
+
+func (a *AuditLog) BeforeCreate(tx *gorm.DB) error {
+	if a.ID == uuid.Nil {
+		a.ID = uuid.New()
+	}
+	return nil
+}
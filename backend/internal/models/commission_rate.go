@@ -7,15 +7,21 @@ import (
 	"gorm.io/gorm"
 )
 
+// CommissionRate is one version of the rate of a platform and category, in force
+// from EffectiveFrom until EffectiveTo (exclusive). Rates with a UserID override
+// the platform rates for that creator.
 type CommissionRate struct {
-	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Platform      string    `gorm:"size:50;not null;uniqueIndex:idx_platform_category" json:"platform"`
-	Category      string    `gorm:"size:100;not null;uniqueIndex:idx_platform_category" json:"category"`
-	RatePercent   float64   `gorm:"type:decimal(5,2);not null" json:"rate_percent"`
-	MaxCommission *int      `gorm:"default:null" json:"max_commission"` // nullable, in Rupiah
-	Notes         string    `gorm:"type:text" json:"notes"`
-	CreatedAt     time.Time `gorm:"autoCreateTime" json:"created_at"`
-	UpdatedAt     time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	ID            uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID        *uuid.UUID `gorm:"type:uuid;index:idx_commission_rates_user" json:"user_id"` // nil for the platform rate
+	Platform      string     `gorm:"size:50;not null;index:idx_commission_rates_lookup" json:"platform"`
+	Category      string     `gorm:"size:100;not null;index:idx_commission_rates_lookup" json:"category"`
+	RatePercent   float64    `gorm:"type:decimal(5,2);not null" json:"rate_percent"`
+	MaxCommission *int       `gorm:"default:null" json:"max_commission"` // nullable, in Rupiah
+	Notes         string     `gorm:"type:text" json:"notes"`
+	EffectiveFrom time.Time  `gorm:"not null;index:idx_commission_rates_lookup" json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"` // nil while the rate is current
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
 }
 
 func (c *CommissionRate) BeforeCreate(tx *gorm.DB) error {
@@ -24,3 +30,9 @@ func (c *CommissionRate) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+// Overlaps reports whether the rate is in force at some time in [from, to);
+// a nil to is open-ended
+func (c *CommissionRate) Overlaps(from time.Time, to *time.Time) bool {
+	return (to == nil || c.EffectiveFrom.Before(*to)) && (c.EffectiveTo == nil || from.Before(*c.EffectiveTo))
+}
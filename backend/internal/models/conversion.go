@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Conversion statuses, normalized from the marketplace reports (see orderreport)
+const (
+	ConversionPending   = "pending"
+	ConversionCompleted = "completed"
+	ConversionCancelled = "cancelled"
+)
+
+// Conversion is one ordered item from a marketplace affiliate report. Importing
+// a newer report updates the row of the same order and item.
+type Conversion struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_conversions_order_item" json:"user_id"`
+	LinkID      *uuid.UUID `gorm:"type:uuid;index" json:"link_id"` // Nil when no link matched or the link was deleted
+	ImportID    *uuid.UUID `gorm:"type:uuid" json:"import_id"`     // The import that last updated the row
+	Platform    string     `gorm:"size:50;not null;uniqueIndex:idx_conversions_order_item" json:"platform"`
+	OrderID     string     `gorm:"size:100;not null;uniqueIndex:idx_conversions_order_item" json:"order_id"`
+	ItemID      string     `gorm:"size:100;not null;uniqueIndex:idx_conversions_order_item" json:"item_id"` // Marketplace product ID
+	ItemName    string     `gorm:"size:255;not null" json:"item_name"`
+	SubID       string     `gorm:"size:100;not null" json:"sub_id"`
+	Source      string     `gorm:"size:50;not null" json:"source"` // Traffic source of tagged orders (see subid)
+	Status      string     `gorm:"size:20;not null" json:"status"` // pending, completed, cancelled
+	OrderAmount float64    `gorm:"type:decimal(14,2);not null" json:"order_amount"`
+	Commission  float64    `gorm:"type:decimal(14,2);not null" json:"commission"` // In Rupiah, as reported by the marketplace
+	OrderedAt   time.Time  `gorm:"not null" json:"ordered_at"`
+	CreatedAt   time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (c *Conversion) BeforeCreate(tx *gorm.DB) error {
+	if c.ID == uuid.Nil {
+		c.ID = uuid.New()
+	}
+	return nil
+}
+
+// ConversionImport records one uploaded affiliate report
+type ConversionImport struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Platform  string    `gorm:"size:50;not null" json:"platform"`
+	Filename  string    `gorm:"size:255;not null" json:"filename"`
+	RowCount  int       `gorm:"not null" json:"rows"`
+	Matched   int       `gorm:"not null" json:"matched"` // Rows matched to a link
+	Skipped   int       `gorm:"not null" json:"skipped"` // Rows without an order ID or order time
+	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+}
+
+func (i *ConversionImport) BeforeCreate(tx *gorm.DB) error {
+	if i.ID == uuid.Nil {
+		i.ID = uuid.New()
+	}
+	return nil
+}
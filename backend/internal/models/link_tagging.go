@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LinkTagging is a creator's choice to tag outbound URLs of one marketplace
+// with affiliate sub-IDs; marketplaces without a row are not tagged
+type LinkTagging struct {
+	UserID      uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	Platform    string    `gorm:"size:50;primaryKey" json:"platform"`
+	Enabled     bool      `gorm:"not null" json:"enabled"`
+	AffiliateID string    `gorm:"size:100;not null" json:"affiliate_id"` // The creator's ID in the marketplace affiliate program
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+func (LinkTagging) TableName() string {
+	return "link_tagging"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LoginAttempt is an audit record of a login attempt, used for lockout and shown to the account owner
+type LoginAttempt struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     *uuid.UUID `gorm:"type:uuid;index" json:"user_id"` // Null when the identifier matched no account
+	Identifier string     `gorm:"size:255" json:"identifier"`     // Username or email as typed
+	IPAddress  string     `gorm:"size:45;index" json:"ip_address"`
+	UserAgent  string     `gorm:"type:text" json:"user_agent"`
+	Success    bool       `gorm:"default:false" json:"success"`
+	Reason     string     `gorm:"size:50" json:"reason"` // invalid_password, unknown_user, ...
+	CreatedAt  time.Time  `gorm:"autoCreateTime;index" json:"created_at"`
+
+	// Relationships
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (la *LoginAttempt) BeforeCreate(tx *gorm.DB) error {
+	if la.ID == uuid.Nil {
+		la.ID = uuid.New()
+	}
+	return nil
+}
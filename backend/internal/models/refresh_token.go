@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshToken is a long-lived, server-stored credential used to mint new access tokens.
+// Only the SHA-256 hash of the token is stored. Every refresh rotates the token; all tokens
+// descending from the same login share a FamilyID so a reused token can revoke the whole chain.
+type RefreshToken struct {
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID       uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	FamilyID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	RotatedAt    *time.Time `json:"rotated_at"`              // Set when exchanged for a new token
+	ReplacedByID *uuid.UUID `gorm:"type:uuid" json:"-"`      // Token issued in exchange
+	RevokedAt    *time.Time `gorm:"index" json:"revoked_at"` // Set when the family is revoked
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+func (rt *RefreshToken) BeforeCreate(tx *gorm.DB) error {
+	if rt.ID == uuid.Nil {
+		rt.ID = uuid.New()
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Dedup keys decide which earlier events count as "the same visitor"
+const (
+	DedupKeyVisitor   = "visitor"    // same visitor ID (browser)
+	DedupKeyVisitorIP = "visitor_ip" // same visitor ID and IP address (hashes rotate daily, so until midnight UTC)
+	DedupKeyNone      = "none"       // every event counts
+)
+
+// Default dedup windows, used for creators without tracking settings
+const (
+	DefaultClickDedupWindow  = 3 * time.Hour
+	DefaultViewDedupWindow   = time.Hour
+	DefaultSocialDedupWindow = 3 * time.Hour
+)
+
+// TrackingSettings holds a creator's tracking preferences
+type TrackingSettings struct {
+	UserID                   uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	DedupKey                 string    `gorm:"size:20;not null;default:'visitor'" json:"dedup_key"`
+	ClickDedupWindowSeconds  int       `gorm:"not null" json:"click_dedup_window_seconds"`
+	ViewDedupWindowSeconds   int       `gorm:"not null" json:"view_dedup_window_seconds"`
+	SocialDedupWindowSeconds int       `gorm:"not null" json:"social_dedup_window_seconds"`
+	CaptureIP                bool      `gorm:"not null" json:"capture_ip"` // store hashed visitor IPs
+	UpdatedAt                time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// DefaultTrackingSettings returns the settings of a creator who never changed them
+func DefaultTrackingSettings(userID uuid.UUID) *TrackingSettings {
+	return &TrackingSettings{
+		UserID:                   userID,
+		DedupKey:                 DedupKeyVisitor,
+		ClickDedupWindowSeconds:  int(DefaultClickDedupWindow / time.Second),
+		ViewDedupWindowSeconds:   int(DefaultViewDedupWindow / time.Second),
+		SocialDedupWindowSeconds: int(DefaultSocialDedupWindow / time.Second),
+		CaptureIP:                true,
+	}
+}
@@ -7,6 +7,12 @@ import (
 	"gorm.io/gorm"
 )
 
+// User roles
+const (
+	RoleCreator = "creator"
+	RoleAdmin   = "admin"
+)
+
 type User struct {
 	ID           uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	Email        string    `gorm:"not null;size:255;unique" json:"email"`   // UNIQUE constraint exists in DB
@@ -20,7 +26,19 @@ type User struct {
 	BannerColor  string    `gorm:"size:7;default:'#FF6B35'" json:"banner_color"`
 	Theme        string    `gorm:"size:50;default:'sunset'" json:"theme"`
 
-	IsVerified bool `gorm:"default:false" json:"is_verified"`
+	IsVerified bool `gorm:"default:false" json:"is_verified"` // Creator badge, granted by admins
+
+	Role        string     `gorm:"size:20;not null;default:'creator'" json:"role"`
+	SuspendedAt *time.Time `json:"suspended_at"` // Suspended users cannot log in and their profile is hidden
+
+	// Set once the creator confirms ownership of Email; cleared when Email changes
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+
+	// Two-factor authentication (TOTP). The secret is set during setup and only
+	// enforced once TwoFactorEnabled is true.
+	TwoFactorEnabled bool   `gorm:"default:false" json:"two_factor_enabled"`
+	TOTPSecret       string `gorm:"size:64" json:"-"`
+	TOTPLastUsedStep int64  `gorm:"default:0" json:"-"` // Rejects replay of an already used code
 
 	// Counter columns for fast analytics
 	TotalViews  int64 `gorm:"default:0" json:"total_views"`
@@ -34,6 +52,19 @@ type User struct {
 	Links    []Link    `gorm:"foreignKey:UserID" json:"links,omitempty"`
 }
 
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+func (u *User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// HasVerifiedEmail reports whether the current email address has been confirmed
+func (u *User) HasVerifiedEmail() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.ID == uuid.Nil {
 		u.ID = uuid.New()
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// VisitorSalt is the secret mixed into visitor IP hashes on one UTC day
+type VisitorSalt struct {
+	Day       string    `gorm:"size:10;primaryKey"` // YYYY-MM-DD
+	Salt      string    `gorm:"size:64;not null"`
+	CreatedAt time.Time `gorm:"autoCreateTime"`
+}
@@ -0,0 +1,353 @@
+// Package orderreport reads the order reports exported from the affiliate
+// centers of Shopee, Tokopedia, Lazada and TikTok Shop (CSV or XLSX) into
+// normalized orders. Columns are found by header name, so the English and
+// Indonesian exports and reordered columns all work.
+package orderreport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// MaxSize is the largest report Parse reads
+const MaxSize = 10 << 20
+
+// Order statuses
+const (
+	StatusPending   = "pending"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+var (
+	ErrUnsupportedFormat = errors.New("report must be a .csv or .xlsx file")
+	ErrTooLarge          = errors.New("report is larger than 10 MB")
+	ErrNoHeader          = errors.New("report has no order ID column")
+	ErrUnreadable        = errors.New("report is not a valid CSV or XLSX file")
+)
+
+// Order is one ordered item of a report
+type Order struct {
+	OrderID    string
+	ItemID     string // Marketplace product ID
+	ItemName   string
+	SubID      string // All sub-ID columns, joined with "-"
+	Status     string // StatusPending, StatusCompleted or StatusCancelled
+	Amount     float64
+	Commission float64
+	OrderedAt  time.Time
+}
+
+// Report is a parsed report. Rows without an order ID or a readable order time
+// are counted in Skipped.
+type Report struct {
+	Orders  []Order
+	Rows    int
+	Skipped int
+}
+
+// reportZone is the time zone of the order times in the reports (WIB)
+var reportZone = time.FixedZone("WIB", 7*60*60)
+
+// columns lists the headers each field is read from, normalized by headerKey,
+// in order of preference
+var columns = struct {
+	order, item, name, subID, subIDs, status, amount, commission, orderedAt []string
+}{
+	order:      []string{"orderid", "idpesanan", "nomorpesanan", "noorder", "ordernumber", "orderno"},
+	item:       []string{"itemid", "productid", "idproduk", "idbarang"},
+	name:       []string{"itemname", "productname", "namaproduk", "namabarang"},
+	subID:      []string{"subid", "subaffid", "subaffiliateid", "tag"},
+	subIDs:     []string{"subid1", "subid2", "subid3", "subid4", "subid5", "subid6"}, // Read together, in order
+	status:     []string{"orderstatus", "statuspesanan", "conversionstatus", "status"},
+	amount:     []string{"purchasevaluerp", "purchasevalue", "ordervaluerp", "ordervalue", "orderamount", "gmvrp", "gmv", "nilaipembelian", "totalpembelian"},
+	commission: []string{"netcommissionrp", "netcommission", "totalcommissionrp", "totalcommission", "estimatedcommissionrp", "estimatedcommission", "commissionrp", "commission", "payout", "komisibersih", "estimasikomisi", "komisi"},
+	orderedAt:  []string{"ordertime", "purchasetime", "ordercreatedtime", "ordercreatedat", "orderdate", "waktupesanan", "tanggalpesanan", "createdtime"},
+}
+
+// headerRows is how many leading rows are searched for the header, since some
+// exports start with a title or the report period
+const headerRows = 10
+
+// Parse reads a report; the format is taken from the file name
+func Parse(r io.Reader, filename string) (*Report, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > MaxSize {
+		return nil, ErrTooLarge
+	}
+
+	var rows [][]string
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".csv":
+		rows, err = readCSV(data)
+	case ".xlsx":
+		rows, err = readXLSX(data)
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnreadable, err)
+	}
+	return parseRows(rows)
+}
+
+// readCSV reads comma or semicolon separated rows
+func readCSV(data []byte) ([][]string, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	firstLine, _, _ := bytes.Cut(data, []byte("\n"))
+	if bytes.Count(firstLine, []byte(";")) > bytes.Count(firstLine, []byte(",")) {
+		reader.Comma = ';'
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	return reader.ReadAll()
+}
+
+func parseRows(rows [][]string) (*Report, error) {
+	start := -1
+	var header map[string]int
+	for i := 0; i < len(rows) && i < headerRows; i++ {
+		header = make(map[string]int)
+		for col, name := range rows[i] {
+			if _, ok := header[headerKey(name)]; !ok {
+				header[headerKey(name)] = col
+			}
+		}
+		if column(header, columns.order) >= 0 {
+			start = i + 1
+			break
+		}
+	}
+	if start < 0 {
+		return nil, ErrNoHeader
+	}
+
+	idx := struct {
+		order, item, name, subID, status, amount, commission, orderedAt int
+		subIDs                                                          []int
+	}{
+		order:      column(header, columns.order),
+		item:       column(header, columns.item),
+		name:       column(header, columns.name),
+		subID:      column(header, columns.subID),
+		status:     column(header, columns.status),
+		amount:     column(header, columns.amount),
+		commission: column(header, columns.commission),
+		orderedAt:  column(header, columns.orderedAt),
+	}
+	for _, name := range columns.subIDs {
+		if col, ok := header[name]; ok {
+			idx.subIDs = append(idx.subIDs, col)
+		}
+	}
+
+	report := &Report{}
+	merged := make(map[[2]string]int)
+	for _, row := range rows[start:] {
+		if blank(row) {
+			continue
+		}
+		report.Rows++
+
+		order := Order{
+			OrderID:    cell(row, idx.order),
+			ItemID:     cell(row, idx.item),
+			ItemName:   cell(row, idx.name),
+			SubID:      subIDs(row, idx.subID, idx.subIDs),
+			Status:     normalizeStatus(cell(row, idx.status)),
+			Amount:     parseAmount(cell(row, idx.amount)),
+			Commission: parseAmount(cell(row, idx.commission)),
+		}
+		orderedAt, ok := parseTime(cell(row, idx.orderedAt))
+		if order.OrderID == "" || !ok {
+			report.Skipped++
+			continue
+		}
+		order.OrderedAt = orderedAt
+
+		// Reports list an item once per variant; keep one order per item
+		key := [2]string{order.OrderID, order.ItemID}
+		if i, ok := merged[key]; ok {
+			report.Orders[i].Amount += order.Amount
+			report.Orders[i].Commission += order.Commission
+			continue
+		}
+		merged[key] = len(report.Orders)
+		report.Orders = append(report.Orders, order)
+	}
+	return report, nil
+}
+
+// headerKey normalizes a header: "Purchase Value(Rp)" and "purchase_value_rp"
+// both become "purchasevaluerp"
+func headerKey(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// column returns the index of the first of names present in header, or -1
+func column(header map[string]int, names []string) int {
+	for _, name := range names {
+		if col, ok := header[name]; ok {
+			return col
+		}
+	}
+	return -1
+}
+
+func cell(row []string, col int) string {
+	if col < 0 || col >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[col])
+}
+
+// subIDs joins numbered sub-ID columns (Sub_id1, Sub_id2, ...) with "-",
+// dropping trailing empty ones, or returns the single sub-ID column
+func subIDs(row []string, single int, numbered []int) string {
+	if len(numbered) == 0 {
+		return cell(row, single)
+	}
+	values := make([]string, len(numbered))
+	for i, col := range numbered {
+		values[i] = cell(row, col)
+	}
+	return strings.TrimRight(strings.Join(values, "-"), "-")
+}
+
+func blank(row []string) bool {
+	for _, value := range row {
+		if strings.TrimSpace(value) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeStatus maps the marketplace order statuses (English or Indonesian)
+// to pending, completed or cancelled
+func normalizeStatus(status string) string {
+	s := strings.ToLower(status)
+	// Checked first: "invalid" contains "valid"
+	for _, word := range []string{"cancel", "batal", "invalid", "reject", "ditolak", "refund", "return", "gagal", "fail"} {
+		if strings.Contains(s, word) {
+			return StatusCancelled
+		}
+	}
+	// Checked next: "unpaid" contains "paid" and "belum dibayar" contains "dibayar"
+	for _, word := range []string{"pending", "unpaid", "belum", "menunggu", "awaiting", "process"} {
+		if strings.Contains(s, word) {
+			return StatusPending
+		}
+	}
+	for _, word := range []string{"complete", "selesai", "settle", "approved", "valid", "paid", "dibayar", "berhasil", "delivered"} {
+		if strings.Contains(s, word) {
+			return StatusCompleted
+		}
+	}
+	return StatusPending
+}
+
+// parseAmount reads amounts like "Rp 12.345", "12,345.00" and "12.345,67";
+// unreadable amounts are 0
+func parseAmount(value string) float64 {
+	s := strings.Map(func(r rune) rune {
+		if unicode.IsDigit(r) || r == '.' || r == ',' || r == '-' {
+			return r
+		}
+		return -1
+	}, value)
+
+	lastDot, lastComma := strings.LastIndex(s, "."), strings.LastIndex(s, ",")
+	switch {
+	case lastDot >= 0 && lastComma >= 0:
+		// The later separator is the decimal one
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		s = decimalOrThousands(s, ",")
+	case lastDot >= 0:
+		s = decimalOrThousands(s, ".")
+	}
+
+	amount, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return amount
+}
+
+// decimalOrThousands treats sep as a thousands separator when it appears more
+// than once or is followed by exactly three digits, and as the decimal point otherwise
+func decimalOrThousands(s, sep string) string {
+	last := strings.LastIndex(s, sep)
+	if strings.Count(s, sep) > 1 || len(s)-last-1 == 3 {
+		return strings.ReplaceAll(s, sep, "")
+	}
+	return strings.Replace(s, sep, ".", 1)
+}
+
+// timeLayouts are the order time formats seen in the exports
+var timeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"02/01/2006 15:04:05",
+	"02/01/2006 15:04",
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04",
+	"2006-01-02",
+	"02/01/2006",
+	"02-01-2006",
+}
+
+// parseTime reads an order time in WIB, an RFC 3339 time or an Excel serial date
+func parseTime(value string) (time.Time, bool) {
+	if value == "" {
+		return time.Time{}, false
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, true
+	}
+	for _, layout := range timeLayouts {
+		if t, err := time.ParseInLocation(layout, value, reportZone); err == nil {
+			return t, true
+		}
+	}
+
+	// XLSX cells hold dates as days since 1899-12-30
+	serial, err := strconv.ParseFloat(value, 64)
+	if err != nil || serial < 1 || serial > 100000 {
+		return time.Time{}, false
+	}
+	days, frac := math.Modf(serial)
+	t := time.Date(1899, 12, 30, 0, 0, 0, 0, reportZone).
+		AddDate(0, 0, int(days)).
+		Add(time.Duration(math.Round(frac*24*60*60)) * time.Second)
+	return t, true
+}
@@ -0,0 +1,188 @@
+package orderreport
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseShopeeCSV(t *testing.T) {
+	report := "\xef\xbb\xbf" + `Order id,Order Status,Order Time,Item id,Item Name,Purchase Value(Rp),Net Commission(Rp),Sub_id1,Sub_id2,Sub_id3
+2401ABC,Completed,2024-03-01 20:15:00,22334455,Serum,"150,000","9,000.50",4f1c,ig_bio,
+2401ABC,Completed,2024-03-01 20:15:00,22334455,Serum,"50,000","3,000",4f1c,ig_bio,
+2402DEF,Cancelled,2024-03-02 08:00:00,99887766,Toner,80000,0,,,
+,Pending,2024-03-02 09:00:00,1,Broken,1,1,,,
+2403GHI,Pending,not a time,1,Broken,1,1,,,
+`
+	parsed, err := Parse(strings.NewReader(report), "SellerConversionReport.CSV")
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, parsed.Rows)
+	assert.Equal(t, 2, parsed.Skipped)
+	require.Len(t, parsed.Orders, 2)
+
+	// Variant rows of the same item are merged
+	assert.Equal(t, Order{
+		OrderID:    "2401ABC",
+		ItemID:     "22334455",
+		ItemName:   "Serum",
+		SubID:      "4f1c-ig_bio",
+		Status:     StatusCompleted,
+		Amount:     200000,
+		Commission: 12000.5,
+		OrderedAt:  time.Date(2024, 3, 1, 13, 15, 0, 0, time.UTC),
+	}, normalized(parsed.Orders[0]))
+	assert.Equal(t, StatusCancelled, parsed.Orders[1].Status)
+}
+
+func TestParseIndonesianSemicolonCSV(t *testing.T) {
+	report := `Laporan Afiliasi Maret 2024;;;;
+ID Pesanan;Status Pesanan;Waktu Pesanan;ID Produk;Komisi
+TK-1;Selesai;05/03/2024 10:30;7001;Rp 12.500
+TK-2;Menunggu;06/03/2024 11:00;7002;1.234,5
+`
+	parsed, err := Parse(strings.NewReader(report), "komisi.csv")
+	require.NoError(t, err)
+
+	require.Len(t, parsed.Orders, 2)
+	assert.Equal(t, StatusCompleted, parsed.Orders[0].Status)
+	assert.Equal(t, 12500.0, parsed.Orders[0].Commission)
+	assert.Equal(t, time.Date(2024, 3, 5, 3, 30, 0, 0, time.UTC), parsed.Orders[0].OrderedAt.UTC())
+	assert.Equal(t, StatusPending, parsed.Orders[1].Status)
+	assert.Equal(t, 1234.5, parsed.Orders[1].Commission)
+}
+
+func TestParseXLSX(t *testing.T) {
+	parsed, err := Parse(bytes.NewReader(testWorkbook(t)), "lazada_orders.xlsx")
+	require.NoError(t, err)
+
+	require.Len(t, parsed.Orders, 1)
+	order := parsed.Orders[0]
+	assert.Equal(t, "LZ-9", order.OrderID)
+	assert.Equal(t, "5551234", order.ItemID)
+	assert.Equal(t, "Rice Cooker", order.ItemName)
+	assert.Equal(t, StatusCompleted, order.Status)
+	assert.Equal(t, 4500.0, order.Commission)
+	// 45352.5 is 2024-03-01 12:00 WIB
+	assert.Equal(t, time.Date(2024, 3, 1, 5, 0, 0, 0, time.UTC), order.OrderedAt.UTC())
+}
+
+func TestParseXLSXRejectsInvalidCellReferences(t *testing.T) {
+	for _, ref := range []string{"a1", "1A", "XFE1", "ZZZZZZZZZZZZZZZ1"} {
+		workbook := workbookWithRows(t, `<row r="1"><c r="`+ref+`" t="inlineStr"><is><t>Order ID</t></is></c></row>`)
+		_, err := Parse(bytes.NewReader(workbook), "report.xlsx")
+		assert.ErrorIs(t, err, ErrUnreadable, ref)
+	}
+}
+
+func TestParseRejectsUnknownReports(t *testing.T) {
+	_, err := Parse(strings.NewReader("a,b\n1,2\n"), "report.csv")
+	assert.ErrorIs(t, err, ErrNoHeader)
+
+	_, err = Parse(strings.NewReader("Order ID\n1\n"), "report.pdf")
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestNormalizeStatus(t *testing.T) {
+	for status, want := range map[string]string{
+		"Completed":     StatusCompleted,
+		"Selesai":       StatusCompleted,
+		"Paid":          StatusCompleted,
+		"Unpaid":        StatusPending,
+		"Belum Dibayar": StatusPending,
+		"Pending":       StatusPending,
+		"":              StatusPending,
+		"Invalid":       StatusCancelled,
+		"Dibatalkan":    StatusCancelled,
+		"Refunded":      StatusCancelled,
+	} {
+		assert.Equal(t, want, normalizeStatus(status), status)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	for value, want := range map[string]float64{
+		"12500":        12500,
+		"Rp 12.500":    12500,
+		"Rp1.234.567":  1234567,
+		"12,345.67":    12345.67,
+		"12.345,67":    12345.67,
+		"9,5":          9.5,
+		"1234.5":       1234.5,
+		"-2.000":       -2000,
+		"":             0,
+		"not a number": 0,
+	} {
+		assert.Equal(t, want, parseAmount(value), value)
+	}
+}
+
+func TestProductID(t *testing.T) {
+	tests := []struct {
+		platform, url, want string
+	}{
+		{"shopee", "https://shopee.co.id/Serum-Wajah-i.123456.22334455?sp_atk=x", "22334455"},
+		{"shopee", "https://shopee.co.id/product/123456/22334455", "22334455"},
+		{"shopee", "https://id.shp.ee/CF2zXom", ""},
+		{"lazada", "https://www.lazada.co.id/products/rice-cooker-i5551234-s8881234.html", "5551234"},
+		{"tiktok_shop", "https://shop-id.tokopedia.com/view/product/1729384756?region=ID", "1729384756"},
+		{"tokopedia", "https://www.tokopedia.com/toko/serum-wajah", ""},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, ProductID(tt.platform, tt.url), tt.url)
+	}
+}
+
+// normalized returns o with its order time in UTC for comparison
+func normalized(o Order) Order {
+	o.OrderedAt = o.OrderedAt.UTC()
+	return o
+}
+
+// testWorkbook builds a one-sheet workbook with shared, inline and numeric cells
+func testWorkbook(t *testing.T) []byte {
+	t.Helper()
+	return workbookWithRows(t, `<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="C1" t="s"><v>2</v></c><c r="D1" t="s"><v>3</v></c><c r="E1" t="s"><v>4</v></c><c r="G1" t="s"><v>5</v></c></row>
+<row r="2"><c r="A2" t="inlineStr"><is><t>LZ-9</t></is></c><c r="B2" t="s"><v>6</v></c><c r="C2"><v>45352.5</v></c><c r="D2"><v>5551234</v></c><c r="E2" t="inlineStr"><is><t>Rice Cooker</t></is></c><c r="G2"><v>4500</v></c></row>
+<row r="3"></row>`)
+}
+
+// workbookWithRows builds a workbook whose sheet holds the row elements rows
+func workbookWithRows(t *testing.T, rows string) []byte {
+	t.Helper()
+
+	parts := map[string]string{
+		"xl/workbook.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Orders" sheetId="1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/orders.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<sst xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+<si><t>Order ID</t></si><si><r><t>Order </t></r><r><t>Status</t></r></si><si><t>Order Time</t></si>
+<si><t>Product ID</t></si><si><t>Product Name</t></si><si><t>Estimated Commission</t></si><si><t>Delivered</t></si>
+</sst>`,
+		"xl/worksheets/orders.xml": `<?xml version="1.0" encoding="UTF-8"?>
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>
+` + rows + `
+</sheetData></worksheet>`,
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for name, content := range parts {
+		w, err := archive.Create(name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	return buf.Bytes()
+}
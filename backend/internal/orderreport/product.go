@@ -0,0 +1,30 @@
+package orderreport
+
+import "regexp"
+
+// productIDPatterns find the product ID the reports use in product URLs.
+// Tokopedia URLs only carry a slug and short links carry no ID at all; those
+// links are matched by sub-ID only.
+var productIDPatterns = map[string][]*regexp.Regexp{
+	"shopee": {
+		regexp.MustCompile(`-i\.\d+\.(\d+)`),
+		regexp.MustCompile(`/product/\d+/(\d+)`),
+	},
+	"lazada": {
+		regexp.MustCompile(`-i(\d+)(?:-s\d+)?\.html`),
+	},
+	"tiktok_shop": {
+		regexp.MustCompile(`/product/(\d+)`),
+	},
+}
+
+// ProductID returns the marketplace product ID in a product URL, or "" if the
+// URL has none
+func ProductID(platform, productURL string) string {
+	for _, re := range productIDPatterns[platform] {
+		if m := re.FindStringSubmatch(productURL); m != nil {
+			return m[1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiterCountsPerKeyAndWindow(t *testing.T) {
+	now := time.Date(2024, 6, 10, 12, 0, 0, 0, time.UTC)
+	l := New(2, time.Minute)
+	l.now = func() time.Time { return now }
+
+	assert.True(t, l.Allow("203.0.113.7"))
+	assert.True(t, l.Allow("203.0.113.7"))
+	assert.False(t, l.Allow("203.0.113.7"))
+	assert.True(t, l.Allow("198.51.100.1"), "other keys have their own budget")
+
+	now = now.Add(time.Minute)
+	assert.True(t, l.Allow("203.0.113.7"), "a new window starts over")
+	assert.Len(t, l.windows, 1, "expired windows are forgotten")
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"github.com/onedash/backend/internal/models"
+)
+
+// ClientFields are the event columns derived from the user agent
+type ClientFields struct {
+	DeviceType string
+	OS         string
+	Browser    string
+}
+
+// userAgentPage is how many distinct user agents are classified per query
+const userAgentPage = 500
+
+// ClassifyUserAgents fills the device type, OS and browser of clicks and views
+// stored before they were parsed at ingest, and returns how many events it
+// updated. Events are grouped by user agent so each one is parsed once. Events
+// whose user agent was erased by retention, or that classify finds nothing in,
+// stay unclassified.
+func (r *AnalyticsRepository) ClassifyUserAgents(classify func(userAgent string) ClientFields) (int64, error) {
+	var total int64
+	for _, model := range []interface{}{&models.LinkClick{}, &models.PageView{}} {
+		after := ""
+		for {
+			var userAgents []string
+			err := r.db.Model(model).
+				Distinct("user_agent").
+				Where("device_type = '' AND os = '' AND browser = ''").
+				Where("user_agent > ?", after).
+				Order("user_agent").
+				Limit(userAgentPage).
+				Pluck("user_agent", &userAgents).Error
+			if err != nil {
+				return total, err
+			}
+
+			for _, ua := range userAgents {
+				fields := classify(ua)
+				if fields == (ClientFields{}) {
+					continue
+				}
+				result := r.db.Model(model).
+					Where("user_agent = ? AND device_type = '' AND os = '' AND browser = ''", ua).
+					Updates(map[string]interface{}{
+						"device_type": fields.DeviceType,
+						"os":          fields.OS,
+						"browser":     fields.Browser,
+					})
+				if result.Error != nil {
+					return total, result.Error
+				}
+				total += result.RowsAffected
+			}
+
+			if len(userAgents) < userAgentPage {
+				break
+			}
+			after = userAgents[len(userAgents)-1]
+		}
+	}
+	return total, nil
+}
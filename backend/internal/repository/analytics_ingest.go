@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+// insertChunkSize bounds the rows per INSERT statement (SQLite allows 32766 parameters)
+const insertChunkSize = 100
+
+// EventBatch is a set of tracking events written together
+type EventBatch struct {
+	Clicks       []*models.LinkClick
+	PageViews    []*models.PageView
+	SocialClicks []*models.SocialClick
+}
+
+// Len returns the number of events in the batch
+func (b *EventBatch) Len() int {
+	return len(b.Clicks) + len(b.PageViews) + len(b.SocialClicks)
+}
+
+type counterDelta struct {
+	clicks int
+	views  int
+}
+
+// InsertEvents writes a batch in one transaction. The users' total_clicks and
+// total_views counters get one UPDATE per user with the summed delta, instead
+// of one per event, so a busy profile does not serialize on its user row.
+// Bot events are stored but not counted.
+func (r *AnalyticsRepository) InsertEvents(batch EventBatch) error {
+	deltas := make(map[uuid.UUID]*counterDelta)
+	delta := func(userID uuid.UUID) *counterDelta {
+		d, ok := deltas[userID]
+		if !ok {
+			d = &counterDelta{}
+			deltas[userID] = d
+		}
+		return d
+	}
+	for _, click := range batch.Clicks {
+		if !click.IsBot {
+			delta(click.UserID).clicks++
+		}
+	}
+	for _, view := range batch.PageViews {
+		if !view.IsBot {
+			delta(view.UserID).views++
+		}
+	}
+
+	// Lock user rows in a fixed order so concurrent batches cannot deadlock
+	userIDs := make([]uuid.UUID, 0, len(deltas))
+	for id := range deltas {
+		userIDs = append(userIDs, id)
+	}
+	sort.Slice(userIDs, func(i, j int) bool {
+		return userIDs[i].String() < userIDs[j].String()
+	})
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if len(batch.Clicks) > 0 {
+			if err := tx.CreateInBatches(batch.Clicks, insertChunkSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(batch.PageViews) > 0 {
+			if err := tx.CreateInBatches(batch.PageViews, insertChunkSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(batch.SocialClicks) > 0 {
+			if err := tx.CreateInBatches(batch.SocialClicks, insertChunkSize).Error; err != nil {
+				return err
+			}
+		}
+
+		for _, id := range userIDs {
+			d := deltas[id]
+			if err := tx.Model(&models.User{}).
+				Where("id = ?", id).
+				Updates(map[string]interface{}{
+					"total_clicks": gorm.Expr("total_clicks + ?", d.clicks),
+					"total_views":  gorm.Expr("total_views + ?", d.views),
+				}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
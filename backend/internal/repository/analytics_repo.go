@@ -9,12 +9,51 @@ import (
 
 // AnalyticsRepository handles all analytics-related database operations
 type AnalyticsRepository struct {
-	db *gorm.DB
+	db          *gorm.DB
+	dialect     Dialect
+	includeBots bool
 }
 
 // NewAnalyticsRepository creates a new analytics repository
 func NewAnalyticsRepository(db *gorm.DB) *AnalyticsRepository {
-	return &AnalyticsRepository{db: db}
+	return &AnalyticsRepository{db: db, dialect: dialectFor(db)}
+}
+
+// WithBots returns a repository whose reads include (or exclude) events flagged as bots.
+// By default reports count human traffic only.
+func (r *AnalyticsRepository) WithBots(include bool) *AnalyticsRepository {
+	scoped := *r
+	scoped.includeBots = include
+	return &scoped
+}
+
+// excludeBots hides bot events from a query unless the repository includes them
+func (r *AnalyticsRepository) excludeBots(query *gorm.DB, tablePrefix string) *gorm.DB {
+	if r.includeBots {
+		return query
+	}
+	return query.Where(tablePrefix+"is_bot = ?", false)
+}
+
+// DedupFilter selects earlier events from the same visitor; empty fields are not compared
+type DedupFilter struct {
+	VisitorID string
+	IPHash    string
+	Since     time.Time
+}
+
+// seenSince reports whether query matches an event from the filter's visitor
+// since filter.Since. It reads at most one row instead of counting them all.
+func seenSince(query *gorm.DB, filter DedupFilter, timeColumn string) (bool, error) {
+	if filter.VisitorID != "" {
+		query = query.Where("visitor_id = ?", filter.VisitorID)
+	}
+	if filter.IPHash != "" {
+		query = query.Where("ip_hash = ?", filter.IPHash)
+	}
+	var ids []string
+	err := query.Where(timeColumn+" > ?", filter.Since).Limit(1).Pluck("id", &ids).Error
+	return len(ids) > 0, err
 }
 
 // FilterParams constructs the common parameters for analytics filtering
@@ -27,35 +66,6 @@ type FilterParams struct {
 	To       time.Time
 }
 
-// applyAnalyticsFilters applies all common filters to the query
-func applyAnalyticsFilters(query *gorm.DB, params FilterParams, tablePrefix string) *gorm.DB {
-	// Handle table prefix if provided (e.g. "link_clicks.")
-	sourceCol := "source"
-	platformCol := "platform"
-	categoryCol := "category"
-	dateCol := "clicked_at"
-
-	if tablePrefix != "" {
-		sourceCol = tablePrefix + sourceCol
-		platformCol = tablePrefix + platformCol
-		categoryCol = tablePrefix + categoryCol
-		dateCol = tablePrefix + dateCol
-	}
-
-	query = query.Where(tablePrefix+"user_id = ?", params.UserID)
-	query = applySourceFilter(query, sourceCol, params.Source)
-	query = applyPlatformFilter(query, platformCol, params.Platform)
-	query = applyCategoryFilter(query, categoryCol, params.Category)
-
-	if !params.From.IsZero() {
-		query = query.Where(dateCol+" >= ?", params.From)
-	}
-	if !params.To.IsZero() {
-		query = query.Where(dateCol+" <= ?", params.To)
-	}
-	return query
-}
-
 // Known values for "others" filter
 var (
 	knownSources    = []string{"instagram", "tiktok", "whatsapp", "facebook", "twitter", "youtube"}
@@ -0,0 +1,462 @@
+package repository
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+func TestTimelineBucketsOnSQLite(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	link := createTestLink(t, db, user.ID, "shopee", "Fashion")
+
+	// Dates around year boundaries where ISO weeks differ from calendar weeks
+	clickDates := []time.Time{
+		time.Date(2020, 12, 31, 10, 0, 0, 0, time.UTC), // Thursday, 2020-W53
+		time.Date(2021, 1, 3, 10, 0, 0, 0, time.UTC),   // Sunday, still 2020-W53
+		time.Date(2021, 1, 4, 10, 0, 0, 0, time.UTC),   // Monday, 2021-W01
+		time.Date(2024, 12, 30, 10, 0, 0, 0, time.UTC), // Monday, 2025-W01
+	}
+	for _, clickedAt := range clickDates {
+		require.NoError(t, repo.CreateClick(&models.LinkClick{
+			LinkID:    &link.ID,
+			UserID:    user.ID,
+			Source:    "instagram",
+			Platform:  "shopee",
+			Category:  "Fashion",
+			ClickedAt: clickedAt,
+		}))
+	}
+
+	weekly, err := repo.GetTimelineClicksByGroup(user.ID, "weekly", "source", "", "", "", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []TimelineDataPoint{
+		{Date: "2020-53", Group: "instagram", Count: 2},
+		{Date: "2021-01", Group: "instagram", Count: 1},
+		{Date: "2025-01", Group: "instagram", Count: 1},
+	}, weekly)
+
+	monthly, err := repo.GetTimelineClicksByGroup(user.ID, "monthly", "platform", "", "", "", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []TimelineDataPoint{
+		{Date: "2020-12", Group: "shopee", Count: 1},
+		{Date: "2021-01", Group: "shopee", Count: 2},
+		{Date: "2024-12", Group: "shopee", Count: 1},
+	}, monthly)
+
+	daily, err := repo.GetDailyClicks(user.ID, "", "",
+		time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 1, 31, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, []DailyStat{
+		{Date: "2021-01-03", Count: 1},
+		{Date: "2021-01-04", Count: 1},
+	}, daily)
+}
+
+func TestClickDeduplicationWindow(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	recent := createTestLink(t, db, user.ID, "shopee", "Fashion")
+	old := createTestLink(t, db, user.ID, "shopee", "Fashion")
+
+	require.NoError(t, repo.CreateClick(&models.LinkClick{
+		LinkID: &recent.ID, UserID: user.ID, VisitorID: "visitor", IPHash: "hash-a", ClickedAt: time.Now().Add(-time.Hour),
+	}))
+	require.NoError(t, repo.CreateClick(&models.LinkClick{
+		LinkID: &old.ID, UserID: user.ID, VisitorID: "visitor", ClickedAt: time.Now().Add(-4 * time.Hour),
+	}))
+
+	since := time.Now().Add(-3 * time.Hour)
+	exists, err := repo.CheckClickExists(recent.ID, DedupFilter{VisitorID: "visitor", Since: since})
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.CheckClickExists(recent.ID, DedupFilter{VisitorID: "visitor", IPHash: "hash-b", Since: since})
+	require.NoError(t, err)
+	assert.False(t, exists, "a visitor_ip key treats another IP as a new visitor")
+
+	exists, err = repo.CheckClickExists(recent.ID, DedupFilter{VisitorID: "visitor", Since: time.Now().Add(-30 * time.Minute)})
+	require.NoError(t, err)
+	assert.False(t, exists, "a shorter window no longer sees the click")
+
+	exists, err = repo.CheckClickExists(old.ID, DedupFilter{VisitorID: "visitor", Since: since})
+	require.NoError(t, err)
+	assert.False(t, exists, "clicks older than the window are counted again")
+
+	require.NoError(t, repo.CreateSocialClick(&models.SocialClick{
+		UserID: user.ID, VisitorID: "visitor", SocialType: "instagram", ClickedAt: time.Now().Add(-time.Hour),
+	}))
+	exists, err = repo.CheckSocialClickExists(user.ID, "instagram", DedupFilter{VisitorID: "visitor", Since: since})
+	require.NoError(t, err)
+	assert.True(t, exists)
+
+	exists, err = repo.CheckSocialClickExists(user.ID, "tiktok", DedupFilter{VisitorID: "visitor", Since: since})
+	require.NoError(t, err)
+	assert.False(t, exists)
+}
+
+func TestBotTrafficIsExcludedByDefault(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	link := createTestLink(t, db, user.ID, "shopee", "Fashion")
+
+	require.NoError(t, repo.InsertEvents(EventBatch{
+		Clicks: []*models.LinkClick{
+			{LinkID: &link.ID, UserID: user.ID, Source: "instagram"},
+			{LinkID: &link.ID, UserID: user.ID, Source: "instagram", IsBot: true},
+			{LinkID: &link.ID, UserID: user.ID, Source: "tiktok", IsBot: true},
+		},
+		PageViews: []*models.PageView{
+			{UserID: user.ID, Source: "instagram"},
+			{UserID: user.ID, Source: "instagram"},
+			{UserID: user.ID, Source: "instagram", IsBot: true},
+		},
+	}))
+
+	overview, err := repo.GetOverviewStats(user.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), overview.TotalClicks)
+	assert.Equal(t, int64(2), overview.TotalViews)
+	assert.Equal(t, int64(2), overview.BotClicks)
+	assert.Equal(t, int64(1), overview.BotViews)
+
+	bySource, err := repo.GetClicksBySource(user.ID, "", "", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []SourceStat{{Source: "instagram", Count: 1}}, bySource)
+
+	all, err := repo.WithBots(true).GetOverviewStats(user.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), all.TotalClicks)
+	assert.Equal(t, int64(3), all.TotalViews)
+
+	// Only human events move the profile counters
+	var stored models.User
+	require.NoError(t, db.First(&stored, "id = ?", user.ID).Error)
+	assert.Equal(t, int64(1), stored.TotalClicks)
+	assert.Equal(t, int64(2), stored.TotalViews)
+}
+
+func TestAnonymizeEventsBeforeKeepsCounts(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	link := createTestLink(t, db, user.ID, "shopee", "Fashion")
+
+	old := time.Now().Add(-100 * 24 * time.Hour)
+	require.NoError(t, repo.CreateClick(&models.LinkClick{
+		LinkID: &link.ID, UserID: user.ID, VisitorID: "old", IPHash: "hash", UserAgent: "ua",
+		Referer: "https://instagram.com/", Source: "instagram", Country: "ID", ClickedAt: old,
+	}))
+	require.NoError(t, repo.CreateClick(&models.LinkClick{
+		LinkID: &link.ID, UserID: user.ID, VisitorID: "recent", IPHash: "hash", UserAgent: "ua", ClickedAt: time.Now(),
+	}))
+	require.NoError(t, repo.CreatePageView(&models.PageView{UserID: user.ID, VisitorID: "old", UserAgent: "ua", ViewedAt: old}))
+	require.NoError(t, repo.CreateSocialClick(&models.SocialClick{UserID: user.ID, VisitorID: "old", SocialType: "instagram", ClickedAt: old}))
+
+	cutoff := time.Now().Add(-90 * 24 * time.Hour)
+	anonymized, err := repo.AnonymizeEventsBefore(cutoff)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, anonymized)
+
+	var clicks []models.LinkClick
+	require.NoError(t, db.Order("clicked_at").Find(&clicks).Error)
+	require.Len(t, clicks, 2)
+	assert.Empty(t, clicks[0].VisitorID)
+	assert.Empty(t, clicks[0].IPHash)
+	assert.Empty(t, clicks[0].UserAgent)
+	assert.Empty(t, clicks[0].Referer)
+	assert.Equal(t, "instagram", clicks[0].Source)
+	assert.Equal(t, "ID", clicks[0].Country)
+	assert.Equal(t, "recent", clicks[1].VisitorID, "recent events keep their identifiers")
+
+	anonymized, err = repo.AnonymizeEventsBefore(cutoff)
+	require.NoError(t, err)
+	assert.Zero(t, anonymized, "anonymized rows are not touched again")
+}
+
+func TestVisitorSaltsAreSharedAndExpire(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewVisitorSaltRepository(db)
+
+	salt, err := repo.GetOrCreate("2024-05-01", "first")
+	require.NoError(t, err)
+	assert.Equal(t, "first", salt)
+	salt, err = repo.GetOrCreate("2024-05-01", "second")
+	require.NoError(t, err)
+	assert.Equal(t, "first", salt, "the salt stored first wins")
+
+	_, err = repo.GetOrCreate("2024-05-02", "next")
+	require.NoError(t, err)
+	deleted, err := repo.DeleteBefore("2024-05-02")
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, deleted)
+}
+
+func TestRollupsMatchRawEvents(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	shoes := createTestLink(t, db, user.ID, "shopee", "Fashion")
+	phone := createTestLink(t, db, user.ID, "tokopedia", "Electronics")
+
+	day := func(d, hour int) time.Time { return time.Date(2024, 5, d, hour, 0, 0, 0, time.UTC) }
+	batch := EventBatch{}
+	for _, c := range []struct {
+		link   *models.Link
+		source string
+		at     time.Time
+		bot    bool
+	}{
+		{shoes, "instagram", day(1, 9), false},
+		{shoes, "instagram", day(1, 23), false},
+		{phone, "tiktok", day(1, 12), true},
+		{phone, "tiktok", day(2, 0), false},
+		{shoes, "", day(2, 15), false},
+		{phone, "whatsapp", day(3, 8), false},
+		{shoes, "instagram", day(3, 20), false},
+	} {
+		batch.Clicks = append(batch.Clicks, &models.LinkClick{
+			LinkID: &c.link.ID, UserID: user.ID, Source: c.source,
+			Platform: c.link.Platform, Category: c.link.Category, IsBot: c.bot, ClickedAt: c.at,
+		})
+	}
+	for i, loc := range [][3]string{{"ID", "JK", "Jakarta"}, {"ID", "JB", "Bandung"}, {"ID", "JK", "Jakarta"}, {"SG", "", ""}} {
+		batch.Clicks[i].Country, batch.Clicks[i].Region, batch.Clicks[i].City = loc[0], loc[1], loc[2]
+	}
+	for _, at := range []time.Time{day(1, 8), day(1, 10), day(2, 11), day(3, 7)} {
+		batch.PageViews = append(batch.PageViews, &models.PageView{UserID: user.ID, Source: "instagram", ViewedAt: at})
+	}
+	require.NoError(t, repo.InsertEvents(batch))
+
+	report := func() []interface{} {
+		from, to := day(2, 0), day(3, 0).Add(24*time.Hour)
+		var results []interface{}
+		collect := func(result interface{}, err error) {
+			require.NoError(t, err)
+			results = append(results, result)
+		}
+		collect(repo.GetOverviewStats(user.ID, time.Time{}, time.Time{}))
+		collect(repo.GetOverviewStats(user.ID, from, to))
+		collect(repo.GetOverviewStats(user.ID, day(1, 12), to)) // starts mid-day
+		collect(repo.GetTopLinks(user.ID, 5))
+		collect(repo.GetFilteredTopLinks(user.ID, "instagram", "all", "all", time.Time{}, time.Time{}, 5))
+		collect(repo.GetClicksBySource(user.ID, "", "", from, to))
+		collect(repo.GetClicksByPlatform(user.ID, "others", "", time.Time{}, time.Time{}))
+		collect(repo.GetClicksByCategory(user.ID, "", "shopee", time.Time{}, time.Time{}))
+		collect(repo.GetViewsBySource(user.ID))
+		collect(repo.GetDailyClicks(user.ID, "", "", time.Time{}, time.Time{}))
+		collect(repo.GetTimelineClicksByGroup(user.ID, "weekly", "platform", "", "", "", time.Time{}, time.Time{}))
+		collect(repo.WithBots(true).GetClicksBySource(user.ID, "", "", time.Time{}, time.Time{}))
+		collect(repo.GetClicksByLocation(FilterParams{UserID: user.ID}, GeoCountry, ""))
+		collect(repo.WithBots(true).GetClicksByLocation(FilterParams{UserID: user.ID}, GeoCity, "ID"))
+		return results
+	}
+	raw := report()
+	assert.Equal(t, []LocationStat{{Country: "ID", Count: 2}, {Country: "SG", Count: 1}}, raw[12])
+	assert.Equal(t, []LocationStat{
+		{Country: "ID", Region: "JK", City: "Jakarta", Count: 2},
+		{Country: "ID", Region: "JB", City: "Bandung", Count: 1},
+	}, raw[13])
+
+	through, err := repo.RolledThrough()
+	require.NoError(t, err)
+	assert.True(t, through.IsZero())
+
+	require.NoError(t, repo.RollupDay(day(1, 0)))
+	require.NoError(t, repo.RollupDay(day(2, 0)))
+	require.NoError(t, repo.RollupDay(day(2, 0)), "rebuilding a day replaces its rows")
+	var rebuilds sync.WaitGroup
+	errs := make(chan error, 4)
+	for i := 0; i < 4; i++ {
+		rebuilds.Add(1)
+		go func() {
+			defer rebuilds.Done()
+			errs <- repo.RollupDay(day(2, 0))
+		}()
+	}
+	rebuilds.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err, "concurrent rebuilds run one after the other")
+	}
+	through, err = repo.RolledThrough()
+	require.NoError(t, err)
+	assert.Equal(t, day(2, 0), through)
+	assert.Equal(t, raw, report(), "rollups and raw events give the same reports")
+
+	// Rolled-up days are no longer read from the raw events
+	require.NoError(t, db.Where("clicked_at < ?", day(2, 0)).Delete(&models.LinkClick{}).Error)
+	overview, err := repo.GetOverviewStats(user.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 6, overview.TotalClicks)
+	assert.EqualValues(t, 1, overview.BotClicks)
+
+	require.NoError(t, repo.ResetRollups())
+	overview, err = repo.GetOverviewStats(user.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, overview.TotalClicks, "without rollups reports read raw events again")
+
+	first, err := repo.FirstEventDay()
+	require.NoError(t, err)
+	assert.Equal(t, day(1, 0), first, "page views are events too")
+}
+
+func TestClassifyUserAgentsBackfillsOlderEvents(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	link := createTestLink(t, db, user.ID, "shopee", "Fashion")
+
+	batch := EventBatch{}
+	for _, ua := range []string{"instagram-ua", "instagram-ua", "chrome-ua", "unknown-ua", ""} {
+		batch.Clicks = append(batch.Clicks, &models.LinkClick{LinkID: &link.ID, UserID: user.ID, UserAgent: ua})
+	}
+	batch.Clicks = append(batch.Clicks, &models.LinkClick{
+		LinkID: &link.ID, UserID: user.ID, UserAgent: "chrome-ua", DeviceType: "desktop", OS: "Windows", Browser: "Edge",
+	})
+	batch.PageViews = []*models.PageView{{UserID: user.ID, UserAgent: "chrome-ua"}}
+	require.NoError(t, repo.InsertEvents(batch))
+
+	parsed := map[string]int{}
+	updated, err := repo.ClassifyUserAgents(func(ua string) ClientFields {
+		parsed[ua]++
+		switch ua {
+		case "instagram-ua":
+			return ClientFields{DeviceType: "mobile", OS: "iOS", Browser: "Instagram"}
+		case "chrome-ua":
+			return ClientFields{DeviceType: "mobile", OS: "Android", Browser: "Chrome"}
+		}
+		return ClientFields{}
+	})
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, updated, "two instagram clicks, one chrome click and one chrome view")
+	assert.Equal(t, map[string]int{"instagram-ua": 1, "chrome-ua": 2, "unknown-ua": 1}, parsed,
+		"each user agent is parsed once per table")
+
+	browsers, err := repo.GetClicksByBrowser(user.ID, "", "", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []SourceStat{{Source: "Instagram", Count: 2}, {Source: "Chrome", Count: 1}, {Source: "Edge", Count: 1}}, browsers)
+
+	devices, err := repo.GetClicksByDevice(user.ID, "", "", time.Time{}, time.Time{})
+	require.NoError(t, err)
+	assert.Equal(t, []SourceStat{{Source: "mobile", Count: 3}, {Source: "desktop", Count: 1}}, devices)
+
+	updated, err = repo.ClassifyUserAgents(func(string) ClientFields { return ClientFields{OS: "Linux"} })
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, updated, "only the unknown user agent is left")
+}
+
+func TestEstimatedCommissionUsesRateInForceOnClickDay(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	other := createTestUser(t, db, "other")
+	link := createTestLink(t, db, user.ID, "shopee", "Fashion")
+	require.NoError(t, db.Model(link).Update("price", 100000).Error)
+
+	march := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC)
+	rates := []models.CommissionRate{
+		{Platform: "shopee", Category: "Fashion", RatePercent: 5, EffectiveFrom: time.Time{}, EffectiveTo: &march},
+		{Platform: "shopee", Category: "Fashion", RatePercent: 3, EffectiveFrom: march},
+		// Another creator's special program does not apply
+		{UserID: &other.ID, Platform: "shopee", Category: "Other", RatePercent: 10, EffectiveFrom: march},
+	}
+	require.NoError(t, db.Create(&rates).Error)
+
+	for _, clickedAt := range []time.Time{
+		march.Add(-time.Hour),    // Old rate
+		march.Add(time.Hour),     // New rate
+		april.Add(2 * time.Hour), // Override below
+	} {
+		require.NoError(t, repo.CreateClick(&models.LinkClick{
+			LinkID: &link.ID, UserID: user.ID, Platform: "shopee", Category: "Fashion", ClickedAt: clickedAt,
+		}))
+	}
+
+	estimates, err := repo.GetEstimatedCommission(user.ID, time.Time{}, time.Time{})
+	require.NoError(t, err)
+	require.Len(t, estimates, 3)
+	assert.Equal(t, 5000.0, estimates[0].Commission)
+	assert.Equal(t, 3000.0, estimates[1].Commission)
+	assert.Equal(t, 3000.0, estimates[2].Commission)
+
+	// The creator's own rate, even for the "Other" category, wins over the platform rate
+	override := models.CommissionRate{UserID: &user.ID, Platform: "shopee", Category: "Other", RatePercent: 8, EffectiveFrom: april}
+	require.NoError(t, db.Create(&override).Error)
+
+	revenue, err := repo.GetEstimatedRevenue(FilterParams{UserID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, 5000.0+3000.0+8000.0, revenue)
+}
+
+func TestRevenueBreakdownHonoursFilters(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewAnalyticsRepository(db)
+	user := createTestUser(t, db, "creator")
+	dress := createTestLink(t, db, user.ID, "shopee", "Fashion")
+	require.NoError(t, db.Model(dress).Update("price", 200000).Error)
+	phone := createTestLink(t, db, user.ID, "tokopedia", "Electronics")
+	require.NoError(t, db.Model(phone).Update("price", 3000000).Error)
+
+	maxCommission := 20000
+	require.NoError(t, db.Create(&models.CommissionRate{Platform: "shopee", Category: "Other", RatePercent: 4}).Error)
+	require.NoError(t, db.Create(&models.CommissionRate{
+		Platform: "tokopedia", Category: "Electronics", RatePercent: 1, MaxCommission: &maxCommission,
+	}).Error)
+
+	day := time.Date(2024, 5, 2, 9, 0, 0, 0, time.UTC)
+	for _, click := range []struct {
+		link   *models.Link
+		source string
+		at     time.Time
+		isBot  bool
+	}{
+		{dress, "instagram", day, false},
+		{dress, "tiktok", day.AddDate(0, 0, 1), false},
+		{phone, "instagram", day, false},
+		{phone, "instagram", day, true},
+	} {
+		require.NoError(t, repo.CreateClick(&models.LinkClick{
+			LinkID: &click.link.ID, UserID: user.ID, Source: click.source, Platform: click.link.Platform,
+			Category: click.link.Category, ClickedAt: click.at, IsBot: click.isBot,
+		}))
+	}
+
+	// Fashion has no rate of its own and falls back to Shopee's "Other"; the phone is capped
+	all, err := repo.GetRevenueBreakdown(FilterParams{UserID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), all.Clicks)
+	assert.Equal(t, 8000.0+8000.0+20000.0, all.Revenue)
+	assert.Equal(t, []RevenueStat{
+		{Key: phone.ID.String(), Label: "Product", Clicks: 1, Revenue: 20000},
+		{Key: dress.ID.String(), Label: "Product", Clicks: 2, Revenue: 16000},
+	}, all.ByLink)
+	assert.Equal(t, []RevenueStat{{Key: "Electronics", Clicks: 1, Revenue: 20000}, {Key: "Fashion", Clicks: 2, Revenue: 16000}}, all.ByCategory)
+	assert.Equal(t, []RevenueStat{{Key: "instagram", Clicks: 2, Revenue: 28000}, {Key: "tiktok", Clicks: 1, Revenue: 8000}}, all.BySource)
+	assert.Equal(t, []RevenueStat{{Key: "2024-05-02", Clicks: 2, Revenue: 28000}, {Key: "2024-05-03", Clicks: 1, Revenue: 8000}}, all.ByDay)
+
+	instagram, err := repo.GetRevenueBreakdown(FilterParams{UserID: user.ID, Source: "instagram", Platform: "shopee"})
+	require.NoError(t, err)
+	assert.Equal(t, 8000.0, instagram.Revenue)
+	assert.Equal(t, []RevenueStat{{Key: "shopee", Clicks: 1, Revenue: 8000}}, instagram.ByPlatform)
+
+	revenue, err := repo.GetEstimatedRevenue(FilterParams{UserID: user.ID, Category: "Electronics", From: day.AddDate(0, 0, 1)})
+	require.NoError(t, err)
+	assert.Zero(t, revenue)
+
+	// Rolled-up days give the same estimate
+	require.NoError(t, repo.RollupDay(day))
+	rolled, err := repo.GetRevenueBreakdown(FilterParams{UserID: user.ID})
+	require.NoError(t, err)
+	assert.Equal(t, all, rolled)
+}
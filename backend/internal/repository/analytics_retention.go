@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+// AnonymizeEventsBefore strips the visitor identifiers (visitor ID, IP hash,
+// user agent, referer) from events older than cutoff. The rows themselves stay,
+// so counts by day, source, platform, category and country are unchanged.
+func (r *AnalyticsRepository) AnonymizeEventsBefore(cutoff time.Time) (int64, error) {
+	var total int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		clicks := tx.Model(&models.LinkClick{}).
+			Where("clicked_at < ?", cutoff).
+			Where("visitor_id <> '' OR ip_hash <> '' OR user_agent <> '' OR referer <> ''").
+			Updates(map[string]interface{}{"visitor_id": "", "ip_hash": "", "user_agent": "", "referer": ""})
+		if clicks.Error != nil {
+			return clicks.Error
+		}
+
+		views := tx.Model(&models.PageView{}).
+			Where("viewed_at < ?", cutoff).
+			Where("visitor_id <> '' OR ip_hash <> '' OR user_agent <> ''").
+			Updates(map[string]interface{}{"visitor_id": "", "ip_hash": "", "user_agent": ""})
+		if views.Error != nil {
+			return views.Error
+		}
+
+		social := tx.Model(&models.SocialClick{}).
+			Where("clicked_at < ? AND visitor_id <> ''", cutoff).
+			Update("visitor_id", "")
+		if social.Error != nil {
+			return social.Error
+		}
+
+		total = clicks.RowsAffected + views.RowsAffected + social.RowsAffected
+		return nil
+	})
+	return total, err
+}
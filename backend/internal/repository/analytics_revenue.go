@@ -4,90 +4,155 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-
-	"github.com/onedash/backend/internal/models"
+	"gorm.io/gorm"
 )
 
-// GetEstimatedRevenue calculates potential affiliate revenue based on clicks and commission rates
-func (r *AnalyticsRepository) GetEstimatedRevenue(userID uuid.UUID, from, to time.Time) (float64, error) {
-	// Query that joins link_clicks with links and commission_rates
-	// For each click: commission = min(price * rate_percent / 100, max_commission)
-
-	type ClickWithLink struct {
-		Price    float64
-		Platform string
-		Category string
+// Revenue is estimated as if every click bought the product at its listed price:
+// min(price * rate_percent / 100, max_commission) per click, with the commission
+// rate in force on the day of the click. The rate is the creator's override for
+// the click's platform and category, then the creator's "Other" rate of the
+// platform, then the platform rates the same way, and 2% with no cap otherwise.
+
+// commissionPerClick is the estimated commission of one click of a revenueFacts row
+const commissionPerClick = "CASE WHEN rates.max_commission IS NOT NULL " +
+	"AND links.price * rates.rate_percent / 100 > rates.max_commission THEN rates.max_commission " +
+	"ELSE links.price * COALESCE(rates.rate_percent, 2) / 100 END"
+
+// revenueSum aggregates the estimated commission of revenueFacts rows
+const revenueSum = "SUM(facts.clicks * (" + commissionPerClick + "))"
+
+// revenueFacts joins the click facts of params with their links and the commission
+// rate applying to each (aliased "rates", NULL for the 2% default), with the
+// filters of params applied
+func (r *AnalyticsRepository) revenueFacts(params FilterParams) (*gorm.DB, error) {
+	facts, err := r.clickFacts(params.UserID, params.From, params.To)
+	if err != nil {
+		return nil, err
 	}
 
-	var clicksData []ClickWithLink
-
-	query := r.db.Table("link_clicks lc").
-		Select("l.price, l.platform, l.category").
-		Joins("JOIN links l ON lc.link_id = l.id").
-		Where("lc.user_id = ?", userID)
-
-	if !from.IsZero() {
-		query = query.Where("lc.clicked_at >= ?", from)
-	}
-	if !to.IsZero() {
-		query = query.Where("lc.clicked_at <= ?", to)
-	}
+	// Rates are in force on whole UTC days, compared as YYYY-MM-DD with the facts
+	effectiveFrom := r.dialect.DateBucket("cr.effective_from", BucketDay)
+	effectiveTo := r.dialect.DateBucket("cr.effective_to", BucketDay)
+	query := facts.
+		Joins("JOIN links ON links.id = facts.link_id").
+		Joins(`LEFT JOIN commission_rates rates ON rates.id = (
+			SELECT cr.id FROM commission_rates cr
+			WHERE cr.platform = facts.platform
+				AND cr.category IN (CASE WHEN facts.category = '' THEN 'Other' ELSE facts.category END, 'Other')
+				AND (cr.user_id IS NULL OR cr.user_id = ?)
+				AND `+effectiveFrom+` <= facts.day
+				AND (cr.effective_to IS NULL OR `+effectiveTo+` > facts.day)
+			ORDER BY cr.user_id IS NULL, cr.category = 'Other'
+			LIMIT 1
+		)`, params.UserID)
+	return r.applyFactFilters(query, params), nil
+}
 
-	if err := query.Find(&clicksData).Error; err != nil {
+// GetEstimatedRevenue estimates the commission of the clicks matching params
+func (r *AnalyticsRepository) GetEstimatedRevenue(params FilterParams) (float64, error) {
+	query, err := r.revenueFacts(params)
+	if err != nil {
 		return 0, err
 	}
 
-	// Get all commission rates (cache for efficiency)
-	var rates []models.CommissionRate
-	if err := r.db.Find(&rates).Error; err != nil {
-		return 0, err
-	}
+	var revenue float64
+	err = query.Select("COALESCE(" + revenueSum + ", 0)").Scan(&revenue).Error
+	return revenue, err
+}
 
-	// Build rate lookup map: platform_category -> rate
-	rateMap := make(map[string]models.CommissionRate)
-	for _, rate := range rates {
-		key := rate.Platform + "_" + rate.Category
-		rateMap[key] = rate
-	}
+// RevenueStat is the estimated commission of the clicks of one value of a breakdown
+type RevenueStat struct {
+	Key     string  `json:"key"`
+	Label   string  `json:"label,omitempty"` // Link title in by_link
+	Clicks  int64   `json:"clicks"`
+	Revenue float64 `json:"revenue"`
+}
 
-	var totalRevenue float64
+// RevenueBreakdown is the estimated commission of the clicks matching a filter,
+// in total and by link, platform, category, source and UTC day
+type RevenueBreakdown struct {
+	Clicks     int64         `json:"clicks"`
+	Revenue    float64       `json:"revenue"`
+	ByLink     []RevenueStat `json:"by_link"`
+	ByPlatform []RevenueStat `json:"by_platform"`
+	ByCategory []RevenueStat `json:"by_category"`
+	BySource   []RevenueStat `json:"by_source"`
+	ByDay      []RevenueStat `json:"by_day"`
+}
 
-	for _, click := range clicksData {
-		// Find matching rate
-		platform := click.Platform
-		category := click.Category
-		if category == "" {
-			category = "Other"
-		}
+// GetRevenueBreakdown estimates the commission of the clicks matching params.
+// Breakdowns are ordered by revenue, except by_day which is chronological.
+func (r *AnalyticsRepository) GetRevenueBreakdown(params FilterParams) (*RevenueBreakdown, error) {
+	query, err := r.revenueFacts(params)
+	if err != nil {
+		return nil, err
+	}
+	facts := query.Session(&gorm.Session{})
 
-		// Try exact match first
-		key := platform + "_" + category
-		rate, found := rateMap[key]
+	var total struct {
+		Clicks  int64
+		Revenue float64
+	}
+	err = facts.Select("COALESCE(SUM(facts.clicks), 0) AS clicks, COALESCE(" + revenueSum + ", 0) AS revenue").
+		Scan(&total).Error
+	if err != nil {
+		return nil, err
+	}
 
-		// Fallback to "Other" category for the platform
-		if !found {
-			key = platform + "_Other"
-			rate, found = rateMap[key]
+	breakdown := &RevenueBreakdown{Clicks: total.Clicks, Revenue: total.Revenue}
+	for _, dimension := range []struct {
+		stats      *[]RevenueStat
+		key, label string
+		order      string
+	}{
+		{&breakdown.ByLink, "facts.link_id", "links.title", "revenue DESC, label ASC, key ASC"},
+		{&breakdown.ByPlatform, "facts.platform", "", "revenue DESC, key ASC"},
+		{&breakdown.ByCategory, "facts.category", "", "revenue DESC, key ASC"},
+		{&breakdown.BySource, "facts.source", "", "revenue DESC, key ASC"},
+		{&breakdown.ByDay, "facts.day", "", "key ASC"},
+	} {
+		group, label := dimension.key, "''"
+		if dimension.label != "" {
+			group, label = dimension.key+", "+dimension.label, dimension.label
 		}
 
-		// If still not found, use default 2% with no cap
-		if !found {
-			rate = models.CommissionRate{
-				RatePercent:   2.0,
-				MaxCommission: nil,
-			}
+		stats := []RevenueStat{}
+		err := facts.Select(dimension.key + " AS key, " + label + " AS label, " +
+			"SUM(facts.clicks) AS clicks, " + revenueSum + " AS revenue").
+			Group(group).
+			Order(dimension.order).
+			Scan(&stats).Error
+		if err != nil {
+			return nil, err
 		}
+		*dimension.stats = stats
+	}
+	return breakdown, nil
+}
 
-		// Calculate commission
-		commission := click.Price * rate.RatePercent / 100
-
-		// Apply max cap if exists
-		if rate.MaxCommission != nil && commission > float64(*rate.MaxCommission) {
-			commission = float64(*rate.MaxCommission)
-		}
+// EstimatedCommission is the commission estimated for the clicks on one link from one source in one UTC day
+type EstimatedCommission struct {
+	LinkID     uuid.UUID
+	Day        string // YYYY-MM-DD
+	Platform   string
+	Source     string
+	Clicks     int64
+	Commission float64
+}
 
-		totalRevenue += commission
+// GetEstimatedCommission estimates the commission of the clicks of userID in
+// [from, to) per link, source and day
+func (r *AnalyticsRepository) GetEstimatedCommission(userID uuid.UUID, from, to time.Time) ([]EstimatedCommission, error) {
+	query, err := r.revenueFacts(FilterParams{UserID: userID, From: from, To: to})
+	if err != nil {
+		return nil, err
 	}
 
-	return totalRevenue, nil
+	var estimates []EstimatedCommission
+	err = query.Select("facts.link_id, facts.day, facts.platform, facts.source, " +
+		"SUM(facts.clicks) AS clicks, " + revenueSum + " AS commission").
+		Group("facts.link_id, facts.day, facts.platform, facts.source").
+		Order("facts.day, facts.link_id, facts.source").
+		Find(&estimates).Error
+	return estimates, err
 }
@@ -0,0 +1,262 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+// Daily rollups count clicks and views per UTC day. Reports read the days up to
+// the watermark from click_rollups_daily and view_rollups_daily, and everything
+// after it (normally just today) from the raw events.
+
+// dailyRollups names the watermark of the daily rollup tables
+const dailyRollups = "daily"
+
+// rollupDayLayout formats the day column of the rollups
+const rollupDayLayout = "2006-01-02"
+
+// rollupLockKey serializes RollupDay across processes (server instances and
+// the rollup and useragent commands)
+const rollupLockKey int64 = 0x726f6c6c7570 // "rollup"
+
+// utcDay returns the start of the UTC day containing t
+func utcDay(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// RolledThrough returns the last day included in the daily rollups, or the zero
+// time if nothing was rolled up yet
+func (r *AnalyticsRepository) RolledThrough() (time.Time, error) {
+	var mark models.RollupWatermark
+	err := r.db.First(&mark, "name = ?", dailyRollups).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(rollupDayLayout, mark.RolledThrough)
+}
+
+// FirstEventDay returns the UTC day of the oldest click or view, or the zero
+// time if there are no events
+func (r *AnalyticsRepository) FirstEventDay() (time.Time, error) {
+	var first time.Time
+	for _, table := range []struct {
+		model  interface{}
+		column string
+	}{
+		{&models.LinkClick{}, "clicked_at"},
+		{&models.PageView{}, "viewed_at"},
+	} {
+		var times []time.Time
+		err := r.db.Model(table.model).Order(table.column).Limit(1).Pluck(table.column, &times).Error
+		if err != nil {
+			return time.Time{}, err
+		}
+		if len(times) > 0 && (first.IsZero() || times[0].Before(first)) {
+			first = times[0]
+		}
+	}
+	if first.IsZero() {
+		return first, nil
+	}
+	return utcDay(first), nil
+}
+
+// RollupDay rebuilds the rollups of the UTC day containing day from the raw
+// events and moves the watermark to it if it is later. Rebuilding a day twice
+// gives the same rows, and concurrent rebuilds run one after the other.
+func (r *AnalyticsRepository) RollupDay(day time.Time) error {
+	start := utcDay(day)
+	end := start.AddDate(0, 0, 1)
+	label := start.Format(rollupDayLayout)
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		// Without it two rebuilds could both delete and then both insert the day
+		if lock := r.dialect.TransactionLock(rollupLockKey); lock != "" {
+			if err := tx.Exec(lock).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("day = ?", label).Delete(&models.ClickRollup{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("day = ?", label).Delete(&models.ViewRollup{}).Error; err != nil {
+			return err
+		}
+
+		err := tx.Exec(`INSERT INTO click_rollups_daily
+				(day, user_id, link_id, source, platform, category, country, region, city,
+				device_type, os, browser, is_bot, clicks)
+			SELECT CAST(? AS VARCHAR(10)), user_id, link_id,
+				COALESCE(source, ''), COALESCE(platform, ''), COALESCE(category, ''),
+				country, region, city, device_type, os, browser, is_bot, COUNT(*)
+			FROM link_clicks
+			WHERE clicked_at >= ? AND clicked_at < ?
+			GROUP BY user_id, link_id, COALESCE(source, ''), COALESCE(platform, ''), COALESCE(category, ''),
+				country, region, city, device_type, os, browser, is_bot`,
+			label, start, end).Error
+		if err != nil {
+			return err
+		}
+		err = tx.Exec(`INSERT INTO view_rollups_daily (day, user_id, source, is_bot, views)
+			SELECT CAST(? AS VARCHAR(10)), user_id, COALESCE(source, ''), is_bot, COUNT(*)
+			FROM page_views
+			WHERE viewed_at >= ? AND viewed_at < ?
+			GROUP BY user_id, COALESCE(source, ''), is_bot`,
+			label, start, end).Error
+		if err != nil {
+			return err
+		}
+
+		var marks []models.RollupWatermark
+		if err := tx.Where("name = ?", dailyRollups).Limit(1).Find(&marks).Error; err != nil {
+			return err
+		}
+		if len(marks) > 0 && marks[0].RolledThrough >= label {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{UpdateAll: true}).
+			Create(&models.RollupWatermark{Name: dailyRollups, RolledThrough: label}).Error
+	})
+}
+
+// ResetRollups empties the daily rollups; reports read raw events until they are rebuilt
+func (r *AnalyticsRepository) ResetRollups() error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("name = ?", dailyRollups).Delete(&models.RollupWatermark{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("1 = 1").Delete(&models.ClickRollup{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("1 = 1").Delete(&models.ViewRollup{}).Error
+	})
+}
+
+// rollupSpan is the part of a report period answered by the rollups: the whole
+// UTC days in [start, end). A zero start has no lower bound.
+type rollupSpan struct {
+	start, end time.Time
+}
+
+// rollupSpanFor returns the rolled-up days inside [from, to) (zero bounds are
+// open); ok is false when the period has to be read from raw events only
+func (r *AnalyticsRepository) rollupSpanFor(from, to time.Time) (span rollupSpan, ok bool, err error) {
+	through, err := r.RolledThrough()
+	if err != nil || through.IsZero() {
+		return span, false, err
+	}
+
+	span.end = through.AddDate(0, 0, 1)
+	if !to.IsZero() && utcDay(to).Before(span.end) {
+		span.end = utcDay(to)
+	}
+	if !from.IsZero() {
+		span.start = utcDay(from)
+		if span.start.Before(from) {
+			span.start = span.start.AddDate(0, 0, 1)
+		}
+		if !span.end.After(span.start) {
+			return span, false, nil
+		}
+	}
+	return span, true, nil
+}
+
+// days restricts a rollup query to the span
+func (s rollupSpan) days(query *gorm.DB) *gorm.DB {
+	if !s.start.IsZero() {
+		query = query.Where("day >= ?", s.start.Format(rollupDayLayout))
+	}
+	return query.Where("day < ?", s.end.Format(rollupDayLayout))
+}
+
+// outside restricts a raw event query to the times the span does not cover
+func (s rollupSpan) outside(query *gorm.DB, timeColumn string) *gorm.DB {
+	if s.start.IsZero() {
+		return query.Where(timeColumn+" >= ?", s.end)
+	}
+	return query.Where(timeColumn+" < ? OR "+timeColumn+" >= ?", s.start, s.end)
+}
+
+// withinPeriod restricts a raw event query to [from, to); zero bounds are open
+func withinPeriod(query *gorm.DB, timeColumn string, from, to time.Time) *gorm.DB {
+	if !from.IsZero() {
+		query = query.Where(timeColumn+" >= ?", from)
+	}
+	if !to.IsZero() {
+		query = query.Where(timeColumn+" < ?", to)
+	}
+	return query
+}
+
+// clickFacts selects the clicks of userID in [from, to) as rows of (day,
+// link_id, source, platform, category, country, region, city, device_type, os,
+// browser, is_bot, clicks), aliased "facts".
+// Rolled-up days come from click_rollups_daily and the rest from link_clicks,
+// so callers always aggregate with SUM(facts.clicks).
+func (r *AnalyticsRepository) clickFacts(userID uuid.UUID, from, to time.Time) (*gorm.DB, error) {
+	raw := r.db.Model(&models.LinkClick{}).
+		Select(r.dialect.DateBucket("clicked_at", BucketDay)+" AS day, link_id, "+
+			"COALESCE(source, '') AS source, COALESCE(platform, '') AS platform, COALESCE(category, '') AS category, "+
+			"country, region, city, device_type, os, browser, is_bot, 1 AS clicks").
+		Where("user_id = ?", userID)
+	raw = withinPeriod(raw, "clicked_at", from, to)
+
+	span, ok, err := r.rollupSpanFor(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return r.db.Table("(?) AS facts", raw), nil
+	}
+
+	rolled := r.db.Model(&models.ClickRollup{}).
+		Select("day, link_id, source, platform, category, country, region, city, device_type, os, browser, is_bot, clicks").
+		Where("user_id = ?", userID)
+	return r.db.Table("(?) AS facts", r.db.Raw(
+		"SELECT * FROM (?) AS rolled UNION ALL SELECT * FROM (?) AS recent",
+		span.days(rolled), span.outside(raw, "clicked_at"),
+	)), nil
+}
+
+// viewFacts is clickFacts for profile views: rows of (day, source, is_bot, views)
+func (r *AnalyticsRepository) viewFacts(userID uuid.UUID, from, to time.Time) (*gorm.DB, error) {
+	raw := r.db.Model(&models.PageView{}).
+		Select(r.dialect.DateBucket("viewed_at", BucketDay)+" AS day, COALESCE(source, '') AS source, is_bot, 1 AS views").
+		Where("user_id = ?", userID)
+	raw = withinPeriod(raw, "viewed_at", from, to)
+
+	span, ok, err := r.rollupSpanFor(from, to)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return r.db.Table("(?) AS facts", raw), nil
+	}
+
+	rolled := r.db.Model(&models.ViewRollup{}).
+		Select("day, source, is_bot, views").
+		Where("user_id = ?", userID)
+	return r.db.Table("(?) AS facts", r.db.Raw(
+		"SELECT * FROM (?) AS rolled UNION ALL SELECT * FROM (?) AS recent",
+		span.days(rolled), span.outside(raw, "viewed_at"),
+	)), nil
+}
+
+// applyFactFilters applies the bot, source, platform and category filters of params to a facts query
+func (r *AnalyticsRepository) applyFactFilters(query *gorm.DB, params FilterParams) *gorm.DB {
+	query = r.excludeBots(query, "facts.")
+	query = applySourceFilter(query, "facts.source", params.Source)
+	query = applyPlatformFilter(query, "facts.platform", params.Platform)
+	return applyCategoryFilter(query, "facts.category", params.Category)
+}
@@ -9,15 +9,14 @@ import (
 // Social click tracking functions
 
 func (r *AnalyticsRepository) CreateSocialClick(click *models.SocialClick) error {
-	return r.db.Create(click).Error
+	return r.InsertEvents(EventBatch{SocialClicks: []*models.SocialClick{click}})
 }
 
-func (r *AnalyticsRepository) CheckSocialClickExists(visitorID string, userID uuid.UUID, socialType string) (bool, error) {
-	var count int64
-	err := r.db.Model(&models.SocialClick{}).
-		Where("visitor_id = ? AND user_id = ? AND social_type = ? AND clicked_at > NOW() - INTERVAL '3 hours'", visitorID, userID, socialType).
-		Count(&count).Error
-	return count > 0, err
+// CheckSocialClickExists reports whether the visitor clicked the social icon since filter.Since.
+// Social clicks do not store an IP hash, so filter.IPHash must be empty.
+func (r *AnalyticsRepository) CheckSocialClickExists(userID uuid.UUID, socialType string, filter DedupFilter) (bool, error) {
+	query := r.db.Model(&models.SocialClick{}).Where("user_id = ? AND social_type = ?", userID, socialType)
+	return seenSince(query, filter, "clicked_at")
 }
 
 // SocialClickStat for pie chart
@@ -28,9 +27,10 @@ type SocialClickStat struct {
 
 func (r *AnalyticsRepository) GetSocialClickStats(userID uuid.UUID) ([]SocialClickStat, error) {
 	var stats []SocialClickStat
-	err := r.db.Model(&models.SocialClick{}).
+	query := r.db.Model(&models.SocialClick{}).
 		Select("social_type, COUNT(*) as clicks").
-		Where("user_id = ?", userID).
+		Where("user_id = ?", userID)
+	err := r.excludeBots(query, "").
 		Group("social_type").
 		Order("clicks DESC").
 		Find(&stats).Error
@@ -1,11 +1,11 @@
 package repository
 
 import (
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
-
-	"github.com/onedash/backend/internal/models"
+	"gorm.io/gorm"
 )
 
 // Overview Stats
@@ -13,34 +13,37 @@ type OverviewStats struct {
 	TotalViews  int64   `json:"total_views"`
 	TotalClicks int64   `json:"total_clicks"`
 	CVR         float64 `json:"cvr"` // Conversion rate
+
+	// Bot traffic in the same period, reported separately so dashboards can show both
+	BotViews  int64 `json:"bot_views"`
+	BotClicks int64 `json:"bot_clicks"`
 }
 
 func (r *AnalyticsRepository) GetOverviewStats(userID uuid.UUID, from, to time.Time) (*OverviewStats, error) {
 	stats := &OverviewStats{}
 
-	// Get total views
-	viewQuery := r.db.Model(&models.PageView{}).Where("user_id = ?", userID)
-	if !from.IsZero() {
-		viewQuery = viewQuery.Where("viewed_at >= ?", from)
-	}
-	if !to.IsZero() {
-		viewQuery = viewQuery.Where("viewed_at <= ?", to)
+	views, err := r.viewFacts(userID, from, to)
+	if err != nil {
+		return nil, err
 	}
-	if err := viewQuery.Count(&stats.TotalViews).Error; err != nil {
+	clicks, err := r.clickFacts(userID, from, to)
+	if err != nil {
 		return nil, err
 	}
 
-	// Get total clicks
-	clickQuery := r.db.Model(&models.LinkClick{}).Where("user_id = ?", userID)
-	if !from.IsZero() {
-		clickQuery = clickQuery.Where("clicked_at >= ?", from)
-	}
-	if !to.IsZero() {
-		clickQuery = clickQuery.Where("clicked_at <= ?", to)
+	// Human and bot totals come from one query per table
+	var humanViews, humanClicks int64
+	if humanViews, stats.BotViews, err = sumByBotFlag(views, "views"); err != nil {
+		return nil, err
 	}
-	if err := clickQuery.Count(&stats.TotalClicks).Error; err != nil {
+	if humanClicks, stats.BotClicks, err = sumByBotFlag(clicks, "clicks"); err != nil {
 		return nil, err
 	}
+	stats.TotalViews, stats.TotalClicks = humanViews, humanClicks
+	if r.includeBots {
+		stats.TotalViews += stats.BotViews
+		stats.TotalClicks += stats.BotClicks
+	}
 
 	// Calculate CVR
 	if stats.TotalViews > 0 {
@@ -50,6 +53,25 @@ func (r *AnalyticsRepository) GetOverviewStats(userID uuid.UUID, from, to time.T
 	return stats, nil
 }
 
+// sumByBotFlag totals the count column of a facts query for human and bot events
+func sumByBotFlag(facts *gorm.DB, countColumn string) (humans, bots int64, err error) {
+	var rows []struct {
+		IsBot bool
+		Count int64
+	}
+	err = facts.Select("facts.is_bot AS is_bot, CAST(SUM(facts." + countColumn + ") AS BIGINT) AS count").
+		Group("facts.is_bot").
+		Find(&rows).Error
+	for _, row := range rows {
+		if row.IsBot {
+			bots += row.Count
+		} else {
+			humans += row.Count
+		}
+	}
+	return humans, bots, err
+}
+
 // Top Links
 type TopLink struct {
 	LinkID uuid.UUID `json:"link_id"`
@@ -57,15 +79,21 @@ type TopLink struct {
 	Clicks int64     `json:"clicks"`
 }
 
+// clicksSum is the total of a facts query over clicks
+const clicksSum = "CAST(SUM(facts.clicks) AS BIGINT)"
+
 func (r *AnalyticsRepository) GetTopLinks(userID uuid.UUID, limit int) ([]TopLink, error) {
 	var topLinks []TopLink
 
-	err := r.db.Table("link_clicks").
-		Select("link_clicks.link_id, links.title, COUNT(*) as clicks").
-		Joins("JOIN links ON links.id = link_clicks.link_id").
-		Where("link_clicks.user_id = ?", userID).
-		Group("link_clicks.link_id, links.title").
-		Order("clicks DESC").
+	facts, err := r.clickFacts(userID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	err = r.excludeBots(facts, "facts.").
+		Select("facts.link_id, links.title, " + clicksSum + " as clicks").
+		Joins("JOIN links ON links.id = facts.link_id").
+		Group("facts.link_id, links.title").
+		Order("clicks DESC, links.title ASC, facts.link_id ASC").
 		Limit(limit).
 		Find(&topLinks).Error
 
@@ -76,22 +104,23 @@ func (r *AnalyticsRepository) GetTopLinks(userID uuid.UUID, limit int) ([]TopLin
 func (r *AnalyticsRepository) GetFilteredTopLinks(userID uuid.UUID, source, platform, category string, from, to time.Time, limit int) ([]TopLink, error) {
 	var topLinks []TopLink
 
-	query := r.db.Table("link_clicks").
-		Select("link_clicks.link_id, links.title, COUNT(*) as clicks").
-		Joins("JOIN links ON links.id = link_clicks.link_id")
+	facts, err := r.clickFacts(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	query := facts.
+		Select("facts.link_id, links.title, " + clicksSum + " as clicks").
+		Joins("JOIN links ON links.id = facts.link_id")
 
 	params := FilterParams{
-		UserID:   userID,
 		Source:   source,
 		Platform: platform,
 		Category: category,
-		From:     from,
-		To:       to,
 	}
-	query = applyAnalyticsFilters(query, params, "link_clicks.")
+	query = r.applyFactFilters(query, params)
 
-	err := query.Group("link_clicks.link_id, links.title").
-		Order("clicks DESC").
+	err = query.Group("facts.link_id, links.title").
+		Order("clicks DESC, links.title ASC, facts.link_id ASC").
 		Limit(limit).
 		Find(&topLinks).Error
 
@@ -104,76 +133,144 @@ type SourceStat struct {
 	Count  int64  `json:"count"`
 }
 
-func (r *AnalyticsRepository) GetClicksBySource(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
+// getClicksByColumn breaks the filtered clicks down by one dimension of the facts
+func (r *AnalyticsRepository) getClicksByColumn(column string, params FilterParams) ([]SourceStat, error) {
 	var stats []SourceStat
-	query := r.db.Model(&models.LinkClick{}).
-		Select("source, COUNT(*) as count").
-		Where("source != ''")
+	facts, err := r.clickFacts(params.UserID, params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+	query := facts.
+		Select("facts." + column + " as source, " + clicksSum + " as count").
+		Where("facts." + column + " != ''")
+	query = r.applyFactFilters(query, params)
 
-	params := FilterParams{
+	err = query.Group("facts." + column).
+		Order("count DESC, source ASC").
+		Find(&stats).Error
+	return stats, err
+}
+
+func (r *AnalyticsRepository) GetClicksBySource(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
+	return r.getClicksByColumn("source", FilterParams{
 		UserID:   userID,
 		Source:   source,
 		Platform: platform,
 		From:     from,
 		To:       to,
-	}
-	query = applyAnalyticsFilters(query, params, "")
-
-	err := query.Group("source").
-		Order("count DESC").
-		Find(&stats).Error
-	return stats, err
+	})
 }
 
 func (r *AnalyticsRepository) GetClicksByPlatform(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
-	var stats []SourceStat
-	query := r.db.Model(&models.LinkClick{}).
-		Select("platform as source, COUNT(*) as count").
-		Where("platform != ''")
+	return r.getClicksByColumn("platform", FilterParams{
+		UserID:   userID,
+		Source:   source,
+		Platform: platform,
+		From:     from,
+		To:       to,
+	})
+}
 
-	params := FilterParams{
+func (r *AnalyticsRepository) GetClicksByCategory(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
+	return r.getClicksByColumn("category", FilterParams{
 		UserID:   userID,
 		Source:   source,
 		Platform: platform,
 		From:     from,
 		To:       to,
-	}
-	query = applyAnalyticsFilters(query, params, "")
+	})
+}
 
-	err := query.Group("platform").
-		Order("count DESC").
-		Find(&stats).Error
-	return stats, err
+func (r *AnalyticsRepository) GetClicksByDevice(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
+	return r.getClicksByColumn("device_type", FilterParams{
+		UserID:   userID,
+		Source:   source,
+		Platform: platform,
+		From:     from,
+		To:       to,
+	})
 }
 
-func (r *AnalyticsRepository) GetClicksByCategory(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
-	var stats []SourceStat
-	query := r.db.Model(&models.LinkClick{}).
-		Select("category as source, COUNT(*) as count").
-		Where("category != ''")
+func (r *AnalyticsRepository) GetClicksByOS(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
+	return r.getClicksByColumn("os", FilterParams{
+		UserID:   userID,
+		Source:   source,
+		Platform: platform,
+		From:     from,
+		To:       to,
+	})
+}
 
-	params := FilterParams{
+// GetClicksByBrowser counts in-app browsers under their app (Instagram, TikTok, ...)
+func (r *AnalyticsRepository) GetClicksByBrowser(userID uuid.UUID, source, platform string, from, to time.Time) ([]SourceStat, error) {
+	return r.getClicksByColumn("browser", FilterParams{
 		UserID:   userID,
 		Source:   source,
 		Platform: platform,
 		From:     from,
 		To:       to,
+	})
+}
+
+// Location levels of the geo breakdown
+const (
+	GeoCountry = "country"
+	GeoRegion  = "region"
+	GeoCity    = "city"
+)
+
+// LocationStat for geo breakdown; finer fields are empty at coarser levels
+type LocationStat struct {
+	Country string `json:"country"`
+	Region  string `json:"region,omitempty"`
+	City    string `json:"city,omitempty"`
+	Count   int64  `json:"count"`
+}
+
+// GetClicksByLocation breaks the filtered clicks down by country, region (within
+// country) or city (within region). Clicks without a location at that level are
+// left out. A non-empty country keeps only clicks from it.
+func (r *AnalyticsRepository) GetClicksByLocation(params FilterParams, level, country string) ([]LocationStat, error) {
+	columns := []string{"facts.country"}
+	switch level {
+	case GeoRegion:
+		columns = append(columns, "facts.region")
+	case GeoCity:
+		columns = append(columns, "facts.region", "facts.city")
+	}
+	grouping := strings.Join(columns, ", ")
+
+	var stats []LocationStat
+	facts, err := r.clickFacts(params.UserID, params.From, params.To)
+	if err != nil {
+		return nil, err
+	}
+	query := facts.
+		Select(grouping + ", " + clicksSum + " as count").
+		Where(columns[len(columns)-1] + " != ''")
+	if country != "" {
+		query = query.Where("facts.country = ?", country)
 	}
-	query = applyAnalyticsFilters(query, params, "")
+	query = r.applyFactFilters(query, params)
 
-	err := query.Group("category").
-		Order("count DESC").
+	err = query.Group(grouping).
+		Order("count DESC, " + grouping).
 		Find(&stats).Error
 	return stats, err
 }
 
 func (r *AnalyticsRepository) GetViewsBySource(userID uuid.UUID) ([]SourceStat, error) {
 	var stats []SourceStat
-	err := r.db.Model(&models.PageView{}).
-		Select("source, COUNT(*) as count").
-		Where("user_id = ? AND source != ''", userID).
-		Group("source").
-		Order("count DESC").
+	facts, err := r.viewFacts(userID, time.Time{}, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	query := facts.
+		Select("facts.source as source, CAST(SUM(facts.views) AS BIGINT) as count").
+		Where("facts.source != ''")
+	err = r.excludeBots(query, "facts.").
+		Group("facts.source").
+		Order("count DESC, source ASC").
 		Find(&stats).Error
 	return stats, err
 }
@@ -187,19 +284,19 @@ type DailyStat struct {
 // GetDailyClicks returns daily click counts with filters
 func (r *AnalyticsRepository) GetDailyClicks(userID uuid.UUID, source, platform string, from, to time.Time) ([]DailyStat, error) {
 	var stats []DailyStat
-	query := r.db.Model(&models.LinkClick{}).
-		Select("TO_CHAR(clicked_at, 'YYYY-MM-DD') as date, COUNT(*) as count")
+	facts, err := r.clickFacts(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	query := facts.Select("facts.day as date, " + clicksSum + " as count")
 
 	params := FilterParams{
-		UserID:   userID,
 		Source:   source,
 		Platform: platform,
-		From:     from,
-		To:       to,
 	}
-	query = applyAnalyticsFilters(query, params, "")
+	query = r.applyFactFilters(query, params)
 
-	err := query.Group("TO_CHAR(clicked_at, 'YYYY-MM-DD')").
+	err = query.Group("facts.day").
 		Order("date ASC").
 		Find(&stats).Error
 	return stats, err
@@ -218,38 +315,38 @@ type TimelineDataPoint struct {
 func (r *AnalyticsRepository) GetTimelineClicksByGroup(userID uuid.UUID, timeGroup, groupBy, source, platform, category string, from, to time.Time) ([]TimelineDataPoint, error) {
 	var stats []TimelineDataPoint
 
-	// Determine date format based on time grouping
-	var dateFormat string
+	// Determine date bucket based on time grouping
+	bucket := BucketDay
 	switch timeGroup {
 	case "weekly":
-		dateFormat = "IYYY-IW" // ISO week
+		bucket = BucketWeek // ISO week
 	case "monthly":
-		dateFormat = "YYYY-MM"
-	default: // daily
-		dateFormat = "YYYY-MM-DD"
+		bucket = BucketMonth
 	}
+	dateExpr := r.dialect.DayBucket("facts.day", bucket)
 
 	// Determine grouping column
-	groupColumn := "source"
+	groupColumn := "facts.source"
 	if groupBy == "platform" {
-		groupColumn = "platform"
+		groupColumn = "facts.platform"
 	}
 
-	query := r.db.Model(&models.LinkClick{}).
-		Select("TO_CHAR(clicked_at, '" + dateFormat + "') as date, " + groupColumn + " as \"group\", COUNT(*) as count").
+	facts, err := r.clickFacts(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	query := facts.
+		Select(dateExpr + " as date, " + groupColumn + " as \"group\", " + clicksSum + " as count").
 		Where(groupColumn + " != ''")
 
 	params := FilterParams{
-		UserID:   userID,
 		Source:   source,
 		Platform: platform,
 		Category: category,
-		From:     from,
-		To:       to,
 	}
-	query = applyAnalyticsFilters(query, params, "")
+	query = r.applyFactFilters(query, params)
 
-	err := query.Group("TO_CHAR(clicked_at, '" + dateFormat + "'), " + groupColumn).
+	err = query.Group(dateExpr + ", " + groupColumn).
 		Order("date ASC, \"group\" ASC").
 		Find(&stats).Error
 	return stats, err
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type AuditLogRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditLogRepository(db *gorm.DB) *AuditLogRepository {
+	return &AuditLogRepository{db: db}
+}
+
+func (r *AuditLogRepository) Create(entry *models.AuditLog) error {
+	return r.db.Create(entry).Error
+}
+
+// Record runs change in a transaction and stores the audit entry it returns in
+// the same transaction, so a change is never stored without its entry
+func (r *AuditLogRepository) Record(change func(tx *gorm.DB) (*models.AuditLog, error)) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		entry, err := change(tx)
+		if err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// FindPage returns audit entries newest first, optionally filtered by target
+func (r *AuditLogRepository) FindPage(targetType, targetID string, limit, offset int) ([]models.AuditLog, int64, error) {
+	query := r.db.Model(&models.AuditLog{})
+	if targetType != "" {
+		query = query.Where("target_type = ?", targetType)
+	}
+	if targetID != "" {
+		query = query.Where("target_id = ?", targetID)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var entries []models.AuditLog
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&entries).Error
+	return entries, total, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+func TestRefreshTokenRotateOnlyOnce(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewRefreshTokenRepository(db)
+	user := createTestUser(t, db, "creator")
+	familyID := uuid.New()
+
+	first := &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: "first", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Create(first))
+
+	second := &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: "second", ExpiresAt: time.Now().Add(time.Hour)}
+	require.NoError(t, repo.Rotate(first, second))
+
+	replay := &models.RefreshToken{UserID: user.ID, FamilyID: familyID, TokenHash: "replay", ExpiresAt: time.Now().Add(time.Hour)}
+	assert.ErrorIs(t, repo.Rotate(first, replay), ErrTokenAlreadyRotated)
+
+	_, err := repo.FindByHash("replay")
+	assert.Error(t, err, "the losing rotation must not leave a usable token behind")
+
+	require.NoError(t, repo.RevokeFamily(familyID))
+	stored, err := repo.FindByHash("second")
+	require.NoError(t, err)
+	assert.NotNil(t, stored.RevokedAt)
+}
+
+func TestLoginAttemptFailureStats(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewLoginAttemptRepository(db)
+	user := createTestUser(t, db, "creator")
+	since := time.Now().Add(-time.Hour)
+
+	for i, createdAt := range []time.Time{time.Now().Add(-2 * time.Hour), time.Now().Add(-30 * time.Minute), time.Now().Add(-10 * time.Minute)} {
+		require.NoError(t, repo.Create(&models.LoginAttempt{
+			UserID:     &user.ID,
+			Identifier: "creator",
+			IPAddress:  "10.0.0.1",
+			Reason:     "invalid_password",
+			CreatedAt:  createdAt,
+		}), "attempt %d", i)
+	}
+
+	stats, err := repo.GetUserFailureStats(user.ID, since)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, stats.Count)
+	assert.WithinDuration(t, time.Now().Add(-10*time.Minute), stats.LastAt, time.Second)
+
+	ipStats, err := repo.GetIPFailureStats("10.0.0.2", since)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, ipStats.Count)
+}
+
+func TestUserSearch(t *testing.T) {
+	db := newTestDB(t)
+	repo := NewUserRepository(db)
+	createTestUser(t, db, "alice")
+	bob := createTestUser(t, db, "bob")
+	createTestUser(t, db, "alicia")
+
+	now := time.Now()
+	bob.SuspendedAt = &now
+	require.NoError(t, repo.Update(bob))
+
+	users, total, err := repo.Search("ALI", "", 10, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	assert.Len(t, users, 2)
+
+	users, total, err = repo.Search("", "suspended", 10, 0)
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, total)
+	assert.Equal(t, "bob", users[0].Username)
+}
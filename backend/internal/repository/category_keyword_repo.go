@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type CategoryKeywordRepository struct {
+	db *gorm.DB
+}
+
+func NewCategoryKeywordRepository(db *gorm.DB) *CategoryKeywordRepository {
+	return &CategoryKeywordRepository{db: db}
+}
+
+// WithTx returns the repository working in the transaction tx
+func (r *CategoryKeywordRepository) WithTx(tx *gorm.DB) *CategoryKeywordRepository {
+	return &CategoryKeywordRepository{db: tx}
+}
+
+func (r *CategoryKeywordRepository) Create(keyword *models.CategoryKeyword) error {
+	return r.db.Create(keyword).Error
+}
+
+func (r *CategoryKeywordRepository) FindByID(id uuid.UUID) (*models.CategoryKeyword, error) {
+	var keyword models.CategoryKeyword
+	err := r.db.First(&keyword, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &keyword, nil
+}
+
+func (r *CategoryKeywordRepository) FindAll(category string) ([]models.CategoryKeyword, error) {
+	var keywords []models.CategoryKeyword
+	query := r.db.Order("category ASC, keyword ASC")
+	if category != "" {
+		query = query.Where("category = ?", category)
+	}
+	err := query.Find(&keywords).Error
+	return keywords, err
+}
+
+func (r *CategoryKeywordRepository) Update(keyword *models.CategoryKeyword) error {
+	return r.db.Save(keyword).Error
+}
+
+func (r *CategoryKeywordRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.CategoryKeyword{}, "id = ?", id).Error
+}
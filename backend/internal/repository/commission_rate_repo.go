@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type CommissionRateRepository struct {
+	db *gorm.DB
+}
+
+func NewCommissionRateRepository(db *gorm.DB) *CommissionRateRepository {
+	return &CommissionRateRepository{db: db}
+}
+
+// WithTx returns the repository working in the transaction tx
+func (r *CommissionRateRepository) WithTx(tx *gorm.DB) *CommissionRateRepository {
+	return &CommissionRateRepository{db: tx}
+}
+
+func (r *CommissionRateRepository) FindByID(id uuid.UUID) (*models.CommissionRate, error) {
+	var rate models.CommissionRate
+	err := r.db.First(&rate, "id = ?", id).Error
+	if err != nil {
+		return nil, err
+	}
+	return &rate, nil
+}
+
+// FindAll returns every version of the rates, optionally only of one platform
+// and of one creator's overrides
+func (r *CommissionRateRepository) FindAll(platform string, userID *uuid.UUID) ([]models.CommissionRate, error) {
+	var rates []models.CommissionRate
+	query := r.db.Order("platform ASC, category ASC, user_id ASC, effective_from ASC")
+	if platform != "" {
+		query = query.Where("platform = ?", platform)
+	}
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Find(&rates).Error
+	return rates, err
+}
+
+// FindVersions returns the versions of the rate of a platform and category,
+// either the platform rate (nil userID) or a creator's override, oldest first
+func (r *CommissionRateRepository) FindVersions(platform, category string, userID *uuid.UUID) ([]models.CommissionRate, error) {
+	var rates []models.CommissionRate
+	query := r.db.Where("platform = ? AND category = ?", platform, category)
+	if userID == nil {
+		query = query.Where("user_id IS NULL")
+	} else {
+		query = query.Where("user_id = ?", *userID)
+	}
+	err := query.Order("effective_from ASC").Find(&rates).Error
+	return rates, err
+}
+
+// CreateVersion creates rate and, if superseded is not nil, ends that version
+// where rate starts, in one transaction
+func (r *CommissionRateRepository) CreateVersion(rate, superseded *models.CommissionRate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if superseded != nil {
+			end := rate.EffectiveFrom
+			superseded.EffectiveTo = &end
+			if err := tx.Save(superseded).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Create(rate).Error
+	})
+}
+
+func (r *CommissionRateRepository) Update(rate *models.CommissionRate) error {
+	return r.db.Save(rate).Error
+}
+
+func (r *CommissionRateRepository) Delete(id uuid.UUID) error {
+	return r.db.Delete(&models.CommissionRate{}, "id = ?", id).Error
+}
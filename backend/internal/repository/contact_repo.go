@@ -19,11 +19,12 @@ func (r *ContactRepository) Create(contact *models.Contact) error {
 	return r.db.Create(contact).Error
 }
 
-func (r *ContactRepository) FindByID(id uuid.UUID) (*models.Contact, error) {
+// FindByIDForUser returns the contact only if it belongs to userID
+func (r *ContactRepository) FindByIDForUser(id, userID uuid.UUID) (*models.Contact, error) {
 	var contact models.Contact
-	err := r.db.First(&contact, "id = ?", id).Error
+	err := r.db.First(&contact, "id = ? AND user_id = ?", id, userID).Error
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
 	}
 	return &contact, nil
 }
@@ -41,8 +42,16 @@ func (r *ContactRepository) Update(contact *models.Contact) error {
 	return r.db.Save(contact).Error
 }
 
-func (r *ContactRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Contact{}, "id = ?", id).Error
+// DeleteForUser deletes the contact only if it belongs to userID
+func (r *ContactRepository) DeleteForUser(id, userID uuid.UUID) error {
+	result := r.db.Delete(&models.Contact{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
 func (r *ContactRepository) DeleteByUserID(userID uuid.UUID) error {
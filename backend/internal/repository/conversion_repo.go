@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type ConversionRepository struct {
+	db      *gorm.DB
+	dialect Dialect
+}
+
+func NewConversionRepository(db *gorm.DB) *ConversionRepository {
+	return &ConversionRepository{db: db, dialect: dialectFor(db)}
+}
+
+// conversionBatch is how many conversions are written per statement
+const conversionBatch = 200
+
+// SaveImport records an import and upserts its conversions in one transaction.
+// Conversions already imported for the same order and item take the values of
+// the newer report, so re-importing a report updates statuses and commissions.
+func (r *ConversionRepository) SaveImport(imp *models.ConversionImport, conversions []models.Conversion) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(imp).Error; err != nil {
+			return err
+		}
+		if len(conversions) == 0 {
+			return nil
+		}
+		for i := range conversions {
+			conversions[i].ImportID = &imp.ID
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "platform"}, {Name: "order_id"}, {Name: "item_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"link_id", "import_id", "item_name", "sub_id", "source", "status",
+				"order_amount", "commission", "ordered_at", "updated_at",
+			}),
+		}).CreateInBatches(conversions, conversionBatch).Error
+	})
+}
+
+// FindImportsByUserID returns the imports of userID, newest first
+func (r *ConversionRepository) FindImportsByUserID(userID uuid.UUID, limit int) ([]models.ConversionImport, error) {
+	var imports []models.ConversionImport
+	err := r.db.Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&imports).Error
+	return imports, err
+}
+
+// ConversionTotal sums the conversions of one link, platform, source, UTC day and status
+type ConversionTotal struct {
+	LinkID     *uuid.UUID
+	Platform   string
+	Source     string // Empty for orders without tagged sub-IDs
+	Day        string // YYYY-MM-DD
+	Status     string
+	Orders     int64
+	Commission float64
+}
+
+// GetConversionTotals sums the conversions of userID ordered in [from, to)
+// (zero bounds are open) by link, platform, source, day and status
+func (r *ConversionRepository) GetConversionTotals(userID uuid.UUID, from, to time.Time) ([]ConversionTotal, error) {
+	day := r.dialect.DateBucket("ordered_at", BucketDay)
+
+	query := r.db.Model(&models.Conversion{}).
+		Select("link_id, platform, source, "+day+" AS day, status, COUNT(*) AS orders, SUM(commission) AS commission").
+		Where("user_id = ?", userID)
+	query = withinPeriod(query, "ordered_at", from, to)
+
+	var totals []ConversionTotal
+	err := query.Group("link_id, platform, source, " + day + ", status").
+		Order("day").
+		Find(&totals).Error
+	return totals, err
+}
@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// DateBucket is the period analytics are grouped by
+type DateBucket string
+
+const (
+	BucketDay   DateBucket = "day"   // 2024-01-31
+	BucketWeek  DateBucket = "week"  // ISO week, 2024-05
+	BucketMonth DateBucket = "month" // 2024-01
+)
+
+// Dialect hides SQL differences between the supported databases.
+// Relative time windows ("last 3 hours") are computed in Go and passed as
+// parameters, so only expressions that must run inside the query live here.
+type Dialect interface {
+	Name() string
+	// DateBucket returns an expression rendering column as the text label of its bucket
+	DateBucket(column string, bucket DateBucket) string
+	// DayBucket is DateBucket for a column holding a YYYY-MM-DD day as text
+	DayBucket(column string, bucket DateBucket) string
+	// TransactionLock returns a statement that makes transactions running it
+	// with the same key wait for each other, or "" if the database already
+	// serializes writing transactions
+	TransactionLock(key int64) string
+}
+
+// dialectFor picks the dialect matching the gorm driver
+func dialectFor(db *gorm.DB) Dialect {
+	if db.Dialector != nil && db.Dialector.Name() == "sqlite" {
+		return sqliteDialect{}
+	}
+	return postgresDialect{}
+}
+
+type postgresDialect struct{}
+
+func (postgresDialect) Name() string { return "postgres" }
+
+func (postgresDialect) DateBucket(column string, bucket DateBucket) string {
+	switch bucket {
+	case BucketWeek:
+		return fmt.Sprintf("TO_CHAR(%s, 'IYYY-IW')", column)
+	case BucketMonth:
+		return fmt.Sprintf("TO_CHAR(%s, 'YYYY-MM')", column)
+	default:
+		return fmt.Sprintf("TO_CHAR(%s, 'YYYY-MM-DD')", column)
+	}
+}
+
+func (d postgresDialect) DayBucket(column string, bucket DateBucket) string {
+	if bucket == BucketDay {
+		return column
+	}
+	return d.DateBucket("CAST("+column+" AS DATE)", bucket)
+}
+
+// Advisory locks are held until the transaction ends
+func (postgresDialect) TransactionLock(key int64) string {
+	return fmt.Sprintf("SELECT pg_advisory_xact_lock(%d)", key)
+}
+
+type sqliteDialect struct{}
+
+func (sqliteDialect) Name() string { return "sqlite" }
+
+func (sqliteDialect) DateBucket(column string, bucket DateBucket) string {
+	switch bucket {
+	case BucketWeek:
+		// SQLite has no ISO week format: the ISO week is the one containing the
+		// Thursday of the same Monday-Sunday week, numbered from that Thursday's year.
+		thursday := fmt.Sprintf("date(%s, '-3 days', 'weekday 4')", column)
+		return fmt.Sprintf(
+			"printf('%%s-%%02d', strftime('%%Y', %s), (CAST(strftime('%%j', %s) AS INTEGER) - 1) / 7 + 1)",
+			thursday, thursday,
+		)
+	case BucketMonth:
+		return fmt.Sprintf("strftime('%%Y-%%m', %s)", column)
+	default:
+		return fmt.Sprintf("strftime('%%Y-%%m-%%d', %s)", column)
+	}
+}
+
+// SQLite date functions read YYYY-MM-DD text directly
+func (d sqliteDialect) DayBucket(column string, bucket DateBucket) string {
+	if bucket == BucketDay {
+		return column
+	}
+	return d.DateBucket(column, bucket)
+}
+
+// SQLite allows one writing transaction at a time
+func (sqliteDialect) TransactionLock(int64) string {
+	return ""
+}
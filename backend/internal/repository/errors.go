@@ -0,0 +1,19 @@
+package repository
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// ErrNotFound is returned by owner-scoped lookups when the record does not exist
+// or belongs to another user. The two cases are deliberately indistinguishable.
+var ErrNotFound = errors.New("not found")
+
+// notFound maps gorm's missing-record error to ErrNotFound
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -23,7 +23,17 @@ func (r *LinkRepository) FindByID(id uuid.UUID) (*models.Link, error) {
 	var link models.Link
 	err := r.db.First(&link, "id = ?", id).Error
 	if err != nil {
-		return nil, err
+		return nil, notFound(err)
+	}
+	return &link, nil
+}
+
+// FindByIDForUser returns the link only if it belongs to userID
+func (r *LinkRepository) FindByIDForUser(id, userID uuid.UUID) (*models.Link, error) {
+	var link models.Link
+	err := r.db.First(&link, "id = ? AND user_id = ?", id, userID).Error
+	if err != nil {
+		return nil, notFound(err)
 	}
 	return &link, nil
 }
@@ -50,15 +60,31 @@ func (r *LinkRepository) Update(link *models.Link) error {
 	return r.db.Save(link).Error
 }
 
-func (r *LinkRepository) Delete(id uuid.UUID) error {
-	return r.db.Delete(&models.Link{}, "id = ?", id).Error
+// DeleteForUser deletes the link only if it belongs to userID
+func (r *LinkRepository) DeleteForUser(id, userID uuid.UUID) error {
+	result := r.db.Delete(&models.Link{}, "id = ? AND user_id = ?", id, userID)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrNotFound
+	}
+	return nil
 }
 
-func (r *LinkRepository) UpdatePositions(links []models.Link) error {
+// UpdatePositions reorders links of userID. If any link belongs to someone else
+// nothing is changed and ErrNotFound is returned.
+func (r *LinkRepository) UpdatePositions(userID uuid.UUID, links []models.Link) error {
 	return r.db.Transaction(func(tx *gorm.DB) error {
 		for _, link := range links {
-			if err := tx.Model(&models.Link{}).Where("id = ?", link.ID).Update("position", link.Position).Error; err != nil {
-				return err
+			result := tx.Model(&models.Link{}).
+				Where("id = ? AND user_id = ?", link.ID, userID).
+				Update("position", link.Position)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrNotFound
 			}
 		}
 		return nil
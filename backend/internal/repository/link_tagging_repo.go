@@ -0,0 +1,32 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type LinkTaggingRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkTaggingRepository(db *gorm.DB) *LinkTaggingRepository {
+	return &LinkTaggingRepository{db: db}
+}
+
+// FindByUserID returns the marketplaces the creator configured
+func (r *LinkTaggingRepository) FindByUserID(userID uuid.UUID) ([]models.LinkTagging, error) {
+	var settings []models.LinkTagging
+	err := r.db.Where("user_id = ?", userID).Order("platform").Find(&settings).Error
+	return settings, err
+}
+
+// Save creates or replaces the settings of each marketplace in one transaction
+func (r *LinkTaggingRepository) Save(settings []models.LinkTagging) error {
+	if len(settings) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(&settings).Error
+}
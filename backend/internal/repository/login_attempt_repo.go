@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type LoginAttemptRepository struct {
+	db *gorm.DB
+}
+
+func NewLoginAttemptRepository(db *gorm.DB) *LoginAttemptRepository {
+	return &LoginAttemptRepository{db: db}
+}
+
+// FailureStats summarizes failed attempts in a window
+type FailureStats struct {
+	Count  int64
+	LastAt time.Time
+}
+
+func (r *LoginAttemptRepository) Create(attempt *models.LoginAttempt) error {
+	return r.db.Create(attempt).Error
+}
+
+// GetUserFailureStats counts failed attempts on an account since the given time
+func (r *LoginAttemptRepository) GetUserFailureStats(userID uuid.UUID, since time.Time) (*FailureStats, error) {
+	return r.failureStats(r.db.Where("user_id = ?", userID), since)
+}
+
+// GetIdentifierFailureStats counts failed attempts with a username or email since
+// the given time, whether or not it belongs to an account
+func (r *LoginAttemptRepository) GetIdentifierFailureStats(identifier string, since time.Time) (*FailureStats, error) {
+	return r.failureStats(r.db.Where("identifier = ?", identifier), since)
+}
+
+// GetIPFailureStats counts failed attempts from an IP address since the given time
+func (r *LoginAttemptRepository) GetIPFailureStats(ip string, since time.Time) (*FailureStats, error) {
+	return r.failureStats(r.db.Where("ip_address = ?", ip), since)
+}
+
+func (r *LoginAttemptRepository) failureStats(query *gorm.DB, since time.Time) (*FailureStats, error) {
+	query = query.Model(&models.LoginAttempt{}).
+		Where("success = ? AND created_at > ?", false, since)
+
+	stats := &FailureStats{}
+	if err := query.Session(&gorm.Session{}).Count(&stats.Count).Error; err != nil {
+		return nil, err
+	}
+	if stats.Count == 0 {
+		return stats, nil
+	}
+
+	var last models.LoginAttempt
+	if err := query.Order("created_at DESC").Limit(1).Find(&last).Error; err != nil {
+		return nil, err
+	}
+	stats.LastAt = last.CreatedAt
+	return stats, nil
+}
+
+// GetLastSuccessAt returns the time of the last successful login, or zero time if none
+func (r *LoginAttemptRepository) GetLastSuccessAt(userID uuid.UUID) (time.Time, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Where("user_id = ? AND success = ?", userID, true).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&attempt).Error
+	return attempt.CreatedAt, err
+}
+
+// GetLastIdentifierSuccessAt returns the time of the last successful login with
+// a username or email, or zero time if none
+func (r *LoginAttemptRepository) GetLastIdentifierSuccessAt(identifier string) (time.Time, error) {
+	var attempt models.LoginAttempt
+	err := r.db.Where("identifier = ? AND success = ?", identifier, true).
+		Order("created_at DESC").
+		Limit(1).
+		Find(&attempt).Error
+	return attempt.CreatedAt, err
+}
+
+func (r *LoginAttemptRepository) FindFailedByUserID(userID uuid.UUID, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	err := r.db.Where("user_id = ? AND success = ?", userID, false).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&attempts).Error
+	return attempts, err
+}
@@ -0,0 +1,45 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+// newTestDB returns a migrated in-memory SQLite database
+func newTestDB(t *testing.T) *gorm.DB {
+	return testutil.NewDB(t)
+}
+
+func createTestUser(t *testing.T, db *gorm.DB, username string) *models.User {
+	t.Helper()
+
+	user := &models.User{
+		Email:        username + "@example.com",
+		Username:     username,
+		PasswordHash: "hash",
+		DisplayName:  username,
+	}
+	require.NoError(t, NewUserRepository(db).Create(user))
+	return user
+}
+
+func createTestLink(t *testing.T, db *gorm.DB, userID uuid.UUID, platform, category string) *models.Link {
+	t.Helper()
+
+	link := &models.Link{
+		UserID:   userID,
+		Title:    "Product",
+		URL:      "https://example.com/product",
+		Platform: platform,
+		Category: category,
+		IsActive: true,
+	}
+	require.NoError(t, NewLinkRepository(db).Create(link))
+	return link
+}
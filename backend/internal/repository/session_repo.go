@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *gorm.DB) *SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+func (r *SessionRepository) Create(session *models.Session) error {
+	return r.db.Create(session).Error
+}
+
+// FindActive returns the session only if it belongs to the user, is not revoked and has not expired
+func (r *SessionRepository) FindActive(id, userID uuid.UUID) (*models.Session, error) {
+	var session models.Session
+	err := r.db.First(&session,
+		"id = ? AND user_id = ? AND revoked_at IS NULL AND expires_at > ?",
+		id, userID, time.Now(),
+	).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+func (r *SessionRepository) FindActiveByUserID(userID uuid.UUID) ([]models.Session, error) {
+	var sessions []models.Session
+	err := r.db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Touch records activity on a session and extends its lifetime
+func (r *SessionRepository) Touch(id uuid.UUID, ip, userAgent string, expiresAt time.Time) error {
+	return r.db.Model(&models.Session{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"ip_address":   ip,
+			"user_agent":   userAgent,
+			"last_seen_at": time.Now(),
+			"expires_at":   expiresAt,
+		}).Error
+}
+
+// Revoke revokes a single session of the user; returns false if no active session matched
+func (r *SessionRepository) Revoke(id, userID uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.Session{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", id, userID).
+		Update("revoked_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// RevokeAllByUserID revokes every session of the user except the given one (pass uuid.Nil to revoke all)
+func (r *SessionRepository) RevokeAllByUserID(userID, exceptID uuid.UUID) error {
+	return r.db.Model(&models.Session{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, exceptID).
+		Update("revoked_at", time.Now()).Error
+}
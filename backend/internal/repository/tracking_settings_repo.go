@@ -0,0 +1,39 @@
+package repository
+
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type TrackingSettingsRepository struct {
+	db *gorm.DB
+}
+
+func NewTrackingSettingsRepository(db *gorm.DB) *TrackingSettingsRepository {
+	return &TrackingSettingsRepository{db: db}
+}
+
+// FindByUserID returns ErrNotFound when the creator kept the defaults
+func (r *TrackingSettingsRepository) FindByUserID(userID uuid.UUID) (*models.TrackingSettings, error) {
+	var settings models.TrackingSettings
+	if err := r.db.First(&settings, "user_id = ?", userID).Error; err != nil {
+		return nil, notFound(err)
+	}
+	return &settings, nil
+}
+
+// OwnerExists reports whether the account exists, so events of unknown accounts
+// can be rejected before they are queued
+func (r *TrackingSettingsRepository) OwnerExists(userID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.User{}).Where("id = ?", userID).Count(&count).Error
+	return count > 0, err
+}
+
+// Save creates or replaces the creator's settings
+func (r *TrackingSettingsRepository) Save(settings *models.TrackingSettings) error {
+	return r.db.Clauses(clause.OnConflict{UpdateAll: true}).Create(settings).Error
+}
@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 	"gorm.io/gorm"
 
@@ -15,6 +17,11 @@ func NewUserRepository(db *gorm.DB) *UserRepository {
 	return &UserRepository{db: db}
 }
 
+// WithTx returns the repository working in the transaction tx
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	return &UserRepository{db: tx}
+}
+
 func (r *UserRepository) Create(user *models.User) error {
 	return r.db.Create(user).Error
 }
@@ -74,3 +81,40 @@ func (r *UserRepository) ExistsByUsername(username string) bool {
 	r.db.Model(&models.User{}).Where("username = ?", username).Count(&count)
 	return count > 0
 }
+
+// AdvanceTOTPStep records the last accepted TOTP time step; returns false if the
+// step is not newer than the stored one (the code was already used)
+func (r *UserRepository) AdvanceTOTPStep(id uuid.UUID, step int64) (bool, error) {
+	result := r.db.Model(&models.User{}).
+		Where("id = ? AND totp_last_used_step < ?", id, step).
+		Update("totp_last_used_step", step)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Search finds users whose email, username or display name contains the query.
+// status filters by "active" or "suspended"; empty means all.
+func (r *UserRepository) Search(q, status string, limit, offset int) ([]models.User, int64, error) {
+	query := r.db.Model(&models.User{})
+	if q != "" {
+		pattern := "%" + strings.ToLower(q) + "%"
+		query = query.Where(
+			"LOWER(email) LIKE ? OR LOWER(username) LIKE ? OR LOWER(display_name) LIKE ?",
+			pattern, pattern, pattern,
+		)
+	}
+	switch status {
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var users []models.User
+	err := query.Order("created_at DESC").Limit(limit).Offset(offset).Find(&users).Error
+	return users, total, err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type UserTokenRepository struct {
+	db *gorm.DB
+}
+
+func NewUserTokenRepository(db *gorm.DB) *UserTokenRepository {
+	return &UserTokenRepository{db: db}
+}
+
+func (r *UserTokenRepository) Create(token *models.UserToken) error {
+	return r.db.Create(token).Error
+}
+
+// FindValid returns an unused, unexpired token with the given hash and purpose
+func (r *UserTokenRepository) FindValid(hash, purpose string) (*models.UserToken, error) {
+	var token models.UserToken
+	err := r.db.First(&token,
+		"token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ?",
+		hash, purpose, time.Now(),
+	).Error
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+// MarkUsed consumes the token; returns false if it was already used by a concurrent request
+func (r *UserTokenRepository) MarkUsed(id uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.UserToken{}).
+		Where("id = ? AND used_at IS NULL", id).
+		Update("used_at", time.Now())
+	return result.RowsAffected > 0, result.Error
+}
+
+// InvalidateByUserID consumes every outstanding token of the user for the given purpose
+func (r *UserTokenRepository) InvalidateByUserID(userID uuid.UUID, purpose string) error {
+	return r.db.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error
+}
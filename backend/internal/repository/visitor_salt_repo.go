@@ -0,0 +1,36 @@
+package repository
+
+import (
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/onedash/backend/internal/models"
+)
+
+type VisitorSaltRepository struct {
+	db *gorm.DB
+}
+
+func NewVisitorSaltRepository(db *gorm.DB) *VisitorSaltRepository {
+	return &VisitorSaltRepository{db: db}
+}
+
+// GetOrCreate returns the salt of day, storing salt if the day has none yet.
+// Instances racing on a new day all end up with the salt stored first.
+func (r *VisitorSaltRepository) GetOrCreate(day, salt string) (string, error) {
+	if err := r.db.Clauses(clause.OnConflict{DoNothing: true}).
+		Create(&models.VisitorSalt{Day: day, Salt: salt}).Error; err != nil {
+		return "", err
+	}
+	var stored models.VisitorSalt
+	if err := r.db.First(&stored, "day = ?", day).Error; err != nil {
+		return "", err
+	}
+	return stored.Salt, nil
+}
+
+// DeleteBefore removes the salts of days before day (YYYY-MM-DD)
+func (r *VisitorSaltRepository) DeleteBefore(day string) (int64, error) {
+	result := r.db.Where("day < ?", day).Delete(&models.VisitorSalt{})
+	return result.RowsAffected, result.Error
+}
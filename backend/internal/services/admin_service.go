@@ -0,0 +1,515 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+)
+
+var (
+	ErrUserNotFound            = errors.New("user not found")
+	ErrCannotSuspendSelf       = errors.New("you cannot suspend your own account")
+	ErrCommissionRateNotFound  = errors.New("commission rate not found")
+	ErrInvalidEffectivePeriod  = errors.New("effective dates must be YYYY-MM-DD and effective_to after effective_from")
+	ErrCommissionRateOverlap   = errors.New("another version of this rate is in force during that period")
+	ErrCommissionRateStarted   = errors.New("versions in force or past only take notes and an effective_to from today on; add a new version instead")
+	ErrCategoryKeywordNotFound = errors.New("category keyword not found")
+)
+
+// Audit log actions
+const (
+	AuditUserSuspend           = "user.suspend"
+	AuditUserUnsuspend         = "user.unsuspend"
+	AuditUserVerify            = "user.verify"
+	AuditUserUnverify          = "user.unverify"
+	AuditCommissionRateCreate  = "commission_rate.create"
+	AuditCommissionRateUpdate  = "commission_rate.update"
+	AuditCommissionRateDelete  = "commission_rate.delete"
+	AuditCategoryKeywordCreate = "category_keyword.create"
+	AuditCategoryKeywordUpdate = "category_keyword.update"
+	AuditCategoryKeywordDelete = "category_keyword.delete"
+)
+
+// categoryKeywordCache is implemented by the scraper, which keeps keywords in memory
+type categoryKeywordCache interface {
+	LoadCategoryKeywords()
+}
+
+// AdminService backs the admin API. Every change is written to the audit log
+// in the transaction that makes it.
+type AdminService struct {
+	userRepo            *repository.UserRepository
+	commissionRateRepo  *repository.CommissionRateRepository
+	categoryKeywordRepo *repository.CategoryKeywordRepository
+	auditLogRepo        *repository.AuditLogRepository
+	sessionService      *SessionService
+	keywordCache        categoryKeywordCache
+}
+
+func NewAdminService(
+	userRepo *repository.UserRepository,
+	commissionRateRepo *repository.CommissionRateRepository,
+	categoryKeywordRepo *repository.CategoryKeywordRepository,
+	auditLogRepo *repository.AuditLogRepository,
+	sessionService *SessionService,
+	keywordCache categoryKeywordCache,
+) *AdminService {
+	return &AdminService{
+		userRepo:            userRepo,
+		commissionRateRepo:  commissionRateRepo,
+		categoryKeywordRepo: categoryKeywordRepo,
+		auditLogRepo:        auditLogRepo,
+		sessionService:      sessionService,
+		keywordCache:        keywordCache,
+	}
+}
+
+type SuspendUserInput struct {
+	Reason string `json:"reason"`
+}
+
+type SetVerifiedInput struct {
+	IsVerified bool `json:"is_verified"`
+}
+
+type CommissionRateInput struct {
+	UserID        *uuid.UUID `json:"user_id"` // set for a creator's override
+	Platform      string     `json:"platform" validate:"required"`
+	Category      string     `json:"category" validate:"required"`
+	RatePercent   float64    `json:"rate_percent" validate:"min=0,max=100"`
+	MaxCommission *int       `json:"max_commission"`
+	Notes         string     `json:"notes"`
+	EffectiveFrom string     `json:"effective_from"` // YYYY-MM-DD (UTC), today if empty
+	EffectiveTo   string     `json:"effective_to"`   // YYYY-MM-DD (UTC, exclusive), open-ended if empty
+}
+
+type CategoryKeywordInput struct {
+	Category string `json:"category" validate:"required"`
+	Keyword  string `json:"keyword" validate:"required"`
+	Source   string `json:"source"` // 'title' (default) or 'breadcrumb'
+}
+
+// Users
+
+func (s *AdminService) SearchUsers(query, status string, limit, offset int) ([]models.User, int64, error) {
+	return s.userRepo.Search(strings.TrimSpace(query), status, limit, offset)
+}
+
+func (s *AdminService) GetUser(id uuid.UUID) (*models.User, error) {
+	user, err := s.userRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	return user, nil
+}
+
+// SuspendUser blocks the account from logging in, hides its public profile
+// and signs it out everywhere
+func (s *AdminService) SuspendUser(actorID, userID uuid.UUID, input *SuspendUserInput, client ClientInfo) (*models.User, error) {
+	if actorID == userID {
+		return nil, ErrCannotSuspendSelf
+	}
+
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsSuspended() {
+		return user, nil
+	}
+
+	now := time.Now()
+	user.SuspendedAt = &now
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.userRepo.WithTx(tx).Update(user); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditUserSuspend, "user", user.ID.String(), map[string]interface{}{
+			"reason": input.Reason,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.sessionService.RevokeAllSessions(user.ID); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *AdminService) UnsuspendUser(actorID, userID uuid.UUID, client ClientInfo) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.IsSuspended() {
+		return user, nil
+	}
+
+	suspendedAt := user.SuspendedAt
+	user.SuspendedAt = nil
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.userRepo.WithTx(tx).Update(user); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditUserUnsuspend, "user", user.ID.String(), map[string]interface{}{
+			"suspended_at": suspendedAt,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// SetVerified grants or removes the creator verification badge
+func (s *AdminService) SetVerified(actorID, userID uuid.UUID, input *SetVerifiedInput, client ClientInfo) (*models.User, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if user.IsVerified == input.IsVerified {
+		return user, nil
+	}
+
+	user.IsVerified = input.IsVerified
+	action := AuditUserVerify
+	if !input.IsVerified {
+		action = AuditUserUnverify
+	}
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.userRepo.WithTx(tx).Update(user); err != nil {
+			return nil, err
+		}
+		return auditEntry(action, "user", user.ID.String(), nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// Commission rates
+
+func (s *AdminService) GetCommissionRates(platform string, userID *uuid.UUID) ([]models.CommissionRate, error) {
+	return s.commissionRateRepo.FindAll(platform, userID)
+}
+
+// CreateCommissionRate adds a version of a rate. A new open-ended version ends
+// the current one where it starts, so clicks before it keep their rate; any
+// other overlap with an existing version is rejected.
+func (s *AdminService) CreateCommissionRate(actorID uuid.UUID, input *CommissionRateInput, client ClientInfo) (*models.CommissionRate, error) {
+	rate := &models.CommissionRate{}
+	today := utcToday()
+	if err := s.applyCommissionRateInput(rate, input, today); err != nil {
+		return nil, err
+	}
+
+	versions, err := s.commissionRateRepo.FindVersions(rate.Platform, rate.Category, rate.UserID)
+	if err != nil {
+		return nil, err
+	}
+	var superseded *models.CommissionRate
+	for i := range versions {
+		version := &versions[i]
+		if !version.Overlaps(rate.EffectiveFrom, rate.EffectiveTo) {
+			continue
+		}
+		if rate.EffectiveTo != nil || version.EffectiveTo != nil || !version.EffectiveFrom.Before(rate.EffectiveFrom) {
+			return nil, ErrCommissionRateOverlap
+		}
+		if rate.EffectiveFrom.Before(today) {
+			// Ending the current version in the past would change earlier estimates
+			return nil, ErrCommissionRateStarted
+		}
+		before := *version
+		superseded = &before
+	}
+
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.commissionRateRepo.WithTx(tx).CreateVersion(rate, superseded); err != nil {
+			return nil, err
+		}
+		details := map[string]interface{}{"after": rate}
+		if superseded != nil {
+			details["superseded"] = superseded.ID
+		}
+		return auditEntry(AuditCommissionRateCreate, "commission_rate", rate.ID.String(), details)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// UpdateCommissionRate corrects a version that has not started yet. Versions
+// in force or past only take new notes and a new end from today on, so the
+// estimates of earlier clicks never change; rates change through a new version.
+// The period may not overlap the other versions.
+func (s *AdminService) UpdateCommissionRate(actorID, id uuid.UUID, input *CommissionRateInput, client ClientInfo) (*models.CommissionRate, error) {
+	rate, err := s.commissionRateRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrCommissionRateNotFound
+	}
+	before := *rate
+
+	if err := s.applyCommissionRateInput(rate, input, before.EffectiveFrom); err != nil {
+		return nil, err
+	}
+	if today := utcToday(); !before.EffectiveFrom.After(today) {
+		if !sameRateTerms(before, *rate) || !endsFromToday(before.EffectiveTo, rate.EffectiveTo, today) {
+			return nil, ErrCommissionRateStarted
+		}
+	}
+
+	versions, err := s.commissionRateRepo.FindVersions(rate.Platform, rate.Category, rate.UserID)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		if version.ID != rate.ID && version.Overlaps(rate.EffectiveFrom, rate.EffectiveTo) {
+			return nil, ErrCommissionRateOverlap
+		}
+	}
+
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.commissionRateRepo.WithTx(tx).Update(rate); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditCommissionRateUpdate, "commission_rate", rate.ID.String(), map[string]interface{}{
+			"before": before,
+			"after":  rate,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return rate, nil
+}
+
+// sameRateTerms reports whether two versions apply the same rate to the same clicks from the same day
+func sameRateTerms(a, b models.CommissionRate) bool {
+	sameUser := (a.UserID == nil && b.UserID == nil) || (a.UserID != nil && b.UserID != nil && *a.UserID == *b.UserID)
+	sameMax := (a.MaxCommission == nil && b.MaxCommission == nil) ||
+		(a.MaxCommission != nil && b.MaxCommission != nil && *a.MaxCommission == *b.MaxCommission)
+	return sameUser && sameMax && a.Platform == b.Platform && a.Category == b.Category &&
+		a.RatePercent == b.RatePercent && a.EffectiveFrom.Equal(b.EffectiveFrom)
+}
+
+// endsFromToday reports whether changing the end of a version from before to
+// after leaves the days up to today alone
+func endsFromToday(before, after *time.Time, today time.Time) bool {
+	if (before == nil && after == nil) || (before != nil && after != nil && before.Equal(*after)) {
+		return true
+	}
+	if before != nil && before.Before(today) {
+		return false // The version already ended
+	}
+	return after == nil || !after.Before(today)
+}
+
+// utcToday returns the start of the current UTC day, the unit of effective dates
+func utcToday() time.Time {
+	return time.Now().UTC().Truncate(24 * time.Hour)
+}
+
+// applyCommissionRateInput sets the fields of rate from input, starting it at
+// from unless input has a start. Effective dates are UTC days, matching the
+// daily click rollups the rates are applied to.
+func (s *AdminService) applyCommissionRateInput(rate *models.CommissionRate, input *CommissionRateInput, from time.Time) error {
+	if input.EffectiveFrom != "" {
+		day, err := time.Parse("2006-01-02", input.EffectiveFrom)
+		if err != nil {
+			return ErrInvalidEffectivePeriod
+		}
+		from = day
+	}
+	var to *time.Time
+	if input.EffectiveTo != "" {
+		day, err := time.Parse("2006-01-02", input.EffectiveTo)
+		if err != nil || !day.After(from) {
+			return ErrInvalidEffectivePeriod
+		}
+		to = &day
+	}
+
+	if input.UserID != nil {
+		if _, err := s.userRepo.FindByID(*input.UserID); err != nil {
+			return ErrUserNotFound
+		}
+	}
+
+	rate.UserID = input.UserID
+	rate.Platform = strings.ToLower(strings.TrimSpace(input.Platform))
+	rate.Category = strings.TrimSpace(input.Category)
+	rate.RatePercent = input.RatePercent
+	rate.MaxCommission = input.MaxCommission
+	rate.Notes = input.Notes
+	rate.EffectiveFrom = from
+	rate.EffectiveTo = to
+	return nil
+}
+
+// DeleteCommissionRate deletes a version that has not started yet
+func (s *AdminService) DeleteCommissionRate(actorID, id uuid.UUID, client ClientInfo) error {
+	rate, err := s.commissionRateRepo.FindByID(id)
+	if err != nil {
+		return ErrCommissionRateNotFound
+	}
+	if !rate.EffectiveFrom.After(utcToday()) {
+		return ErrCommissionRateStarted
+	}
+
+	return s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.commissionRateRepo.WithTx(tx).Delete(rate.ID); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditCommissionRateDelete, "commission_rate", rate.ID.String(), map[string]interface{}{
+			"before": rate,
+		})
+	})
+}
+
+// Category keywords
+
+func (s *AdminService) GetCategoryKeywords(category string) ([]models.CategoryKeyword, error) {
+	return s.categoryKeywordRepo.FindAll(category)
+}
+
+func (s *AdminService) CreateCategoryKeyword(actorID uuid.UUID, input *CategoryKeywordInput, client ClientInfo) (*models.CategoryKeyword, error) {
+	keyword := &models.CategoryKeyword{
+		Category: strings.TrimSpace(input.Category),
+		Keyword:  strings.ToLower(strings.TrimSpace(input.Keyword)),
+		Source:   keywordSource(input.Source),
+	}
+
+	err := s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.categoryKeywordRepo.WithTx(tx).Create(keyword); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditCategoryKeywordCreate, "category_keyword", keyword.ID.String(), map[string]interface{}{
+			"after": keyword,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reloadKeywords()
+
+	return keyword, nil
+}
+
+func (s *AdminService) UpdateCategoryKeyword(actorID, id uuid.UUID, input *CategoryKeywordInput, client ClientInfo) (*models.CategoryKeyword, error) {
+	keyword, err := s.categoryKeywordRepo.FindByID(id)
+	if err != nil {
+		return nil, ErrCategoryKeywordNotFound
+	}
+	before := *keyword
+
+	keyword.Category = strings.TrimSpace(input.Category)
+	keyword.Keyword = strings.ToLower(strings.TrimSpace(input.Keyword))
+	keyword.Source = keywordSource(input.Source)
+
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.categoryKeywordRepo.WithTx(tx).Update(keyword); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditCategoryKeywordUpdate, "category_keyword", keyword.ID.String(), map[string]interface{}{
+			"before": before,
+			"after":  keyword,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.reloadKeywords()
+
+	return keyword, nil
+}
+
+func (s *AdminService) DeleteCategoryKeyword(actorID, id uuid.UUID, client ClientInfo) error {
+	keyword, err := s.categoryKeywordRepo.FindByID(id)
+	if err != nil {
+		return ErrCategoryKeywordNotFound
+	}
+
+	err = s.audited(actorID, client, func(tx *gorm.DB) (*models.AuditLog, error) {
+		if err := s.categoryKeywordRepo.WithTx(tx).Delete(keyword.ID); err != nil {
+			return nil, err
+		}
+		return auditEntry(AuditCategoryKeywordDelete, "category_keyword", keyword.ID.String(), map[string]interface{}{
+			"before": keyword,
+		})
+	})
+	if err != nil {
+		return err
+	}
+	s.reloadKeywords()
+
+	return nil
+}
+
+// Audit log
+
+func (s *AdminService) GetAuditLogs(targetType, targetID string, limit, offset int) ([]models.AuditLog, int64, error) {
+	return s.auditLogRepo.FindPage(targetType, targetID, limit, offset)
+}
+
+// audited makes a change and writes its audit entry in one transaction. The
+// change returns the entry once it succeeded, so the entry can refer to the rows
+// it created; if the entry cannot be stored the change is rolled back.
+func (s *AdminService) audited(actorID uuid.UUID, client ClientInfo, change func(tx *gorm.DB) (*models.AuditLog, error)) error {
+	return s.auditLogRepo.Record(func(tx *gorm.DB) (*models.AuditLog, error) {
+		entry, err := change(tx)
+		if err != nil {
+			return nil, err
+		}
+		entry.ActorID = actorID
+		entry.IPAddress = client.IP
+		return entry, nil
+	})
+}
+
+// auditEntry describes an admin action on a target
+func auditEntry(action, targetType, targetID string, details map[string]interface{}) (*models.AuditLog, error) {
+	entry := &models.AuditLog{
+		Action:     action,
+		TargetType: targetType,
+		TargetID:   targetID,
+	}
+	if details != nil {
+		encoded, err := json.Marshal(details)
+		if err != nil {
+			return nil, err
+		}
+		entry.Details = string(encoded)
+	}
+	return entry, nil
+}
+
+// reloadKeywords refreshes the scraper's in-memory keyword cache
+func (s *AdminService) reloadKeywords() {
+	if s.keywordCache != nil {
+		s.keywordCache.LoadCategoryKeywords()
+	}
+}
+
+func keywordSource(source string) string {
+	if source == "breadcrumb" {
+		return "breadcrumb"
+	}
+	return "title"
+}
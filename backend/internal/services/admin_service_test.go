@@ -0,0 +1,87 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+type adminFixture struct {
+	db      *gorm.DB
+	service *AdminService
+	admin   *models.User
+}
+
+func newAdminFixture(t *testing.T) *adminFixture {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	admin := &models.User{Email: "gita@example.com", Username: "gita", PasswordHash: "hash"}
+	require.NoError(t, userRepo.Create(admin))
+
+	service := NewAdminService(
+		userRepo,
+		repository.NewCommissionRateRepository(db),
+		repository.NewCategoryKeywordRepository(db),
+		repository.NewAuditLogRepository(db),
+		NewSessionService(repository.NewSessionRepository(db), repository.NewRefreshTokenRepository(db)),
+		nil,
+	)
+	return &adminFixture{db: db, service: service, admin: admin}
+}
+
+func TestRateVersionsInForceKeepTheirTerms(t *testing.T) {
+	f := newAdminFixture(t)
+	today := utcToday()
+	day := func(offset int) string { return today.AddDate(0, 0, offset).Format("2006-01-02") }
+
+	current, err := f.service.CreateCommissionRate(f.admin.ID, &CommissionRateInput{
+		Platform: "shopee", Category: "Beauty", RatePercent: 5, EffectiveFrom: day(-30),
+	}, ClientInfo{})
+	require.NoError(t, err)
+
+	// The rate, cap and start of a version in force cannot change
+	changed := CommissionRateInput{Platform: "shopee", Category: "Beauty", RatePercent: 7, EffectiveFrom: day(-30)}
+	_, err = f.service.UpdateCommissionRate(f.admin.ID, current.ID, &changed, ClientInfo{})
+	assert.ErrorIs(t, err, ErrCommissionRateStarted)
+	_, err = f.service.UpdateCommissionRate(f.admin.ID, current.ID, &CommissionRateInput{
+		Platform: "shopee", Category: "Beauty", RatePercent: 5, EffectiveFrom: day(-30), EffectiveTo: day(-1),
+	}, ClientInfo{})
+	assert.ErrorIs(t, err, ErrCommissionRateStarted, "ending it in the past")
+	assert.ErrorIs(t, f.service.DeleteCommissionRate(f.admin.ID, current.ID, ClientInfo{}), ErrCommissionRateStarted)
+	_, err = f.service.CreateCommissionRate(f.admin.ID, &CommissionRateInput{
+		Platform: "shopee", Category: "Beauty", RatePercent: 7, EffectiveFrom: day(-5),
+	}, ClientInfo{})
+	assert.ErrorIs(t, err, ErrCommissionRateStarted, "a backdated version would end the current one in the past")
+
+	// Notes and an end from today on are fine
+	updated, err := f.service.UpdateCommissionRate(f.admin.ID, current.ID, &CommissionRateInput{
+		Platform: "shopee", Category: "Beauty", RatePercent: 5, Notes: "campaign", EffectiveTo: day(10),
+	}, ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, "campaign", updated.Notes)
+	assert.Equal(t, today.AddDate(0, 0, -30), updated.EffectiveFrom.UTC())
+
+	// A new version starting later can be corrected and deleted before it starts
+	next, err := f.service.CreateCommissionRate(f.admin.ID, &CommissionRateInput{
+		Platform: "shopee", Category: "Beauty", RatePercent: 6, EffectiveFrom: day(10),
+	}, ClientInfo{})
+	require.NoError(t, err)
+	next, err = f.service.UpdateCommissionRate(f.admin.ID, next.ID, &CommissionRateInput{
+		Platform: "shopee", Category: "Beauty", RatePercent: 6.5, EffectiveFrom: day(10),
+	}, ClientInfo{})
+	require.NoError(t, err)
+	assert.Equal(t, 6.5, next.RatePercent)
+	require.NoError(t, f.service.DeleteCommissionRate(f.admin.ID, next.ID, ClientInfo{}))
+
+	var stored models.CommissionRate
+	require.NoError(t, f.db.First(&stored, "id = ?", current.ID).Error)
+	assert.Equal(t, 5.0, stored.RatePercent)
+	assert.WithinDuration(t, today.AddDate(0, 0, 10), *stored.EffectiveTo, time.Second)
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/repository"
+)
+
+var (
+	ErrInvalidCompare       = errors.New("compare must be previous_period or previous_year")
+	ErrComparePeriodMissing = errors.New("compare needs both from and to")
+)
+
+// Comparison modes of the dashboard
+const (
+	ComparePreviousPeriod = "previous_period" // The period of the same length just before
+	ComparePreviousYear   = "previous_year"   // The same dates one year earlier
+)
+
+// ComparisonPeriod returns the period [from, to) is compared with in mode
+func ComparisonPeriod(mode string, from, to time.Time) (time.Time, time.Time, error) {
+	if mode != ComparePreviousPeriod && mode != ComparePreviousYear {
+		return time.Time{}, time.Time{}, ErrInvalidCompare
+	}
+	if from.IsZero() || to.IsZero() {
+		return time.Time{}, time.Time{}, ErrComparePeriodMissing
+	}
+
+	if mode == ComparePreviousYear {
+		return from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0), nil
+	}
+	return from.Add(-to.Sub(from)), from, nil
+}
+
+// DashboardSnapshot holds the dashboard figures compared between periods
+type DashboardSnapshot struct {
+	Overview         *repository.OverviewStats
+	TopLinks         []repository.TopLink
+	ClicksBySource   []repository.SourceStat
+	ClicksByPlatform []repository.SourceStat
+	ClicksByCategory []repository.SourceStat
+	ClicksByCountry  []repository.LocationStat
+	ClicksByDevice   []repository.SourceStat
+	ClicksByOS       []repository.SourceStat
+	ClicksByBrowser  []repository.SourceStat
+	EstimatedRevenue *float64 // nil when the revenue could not be estimated
+}
+
+// GetDashboardSnapshot reads the compared dashboard figures of [from, to) with
+// the same filters as the dashboard. Top links are not limited, so every link
+// of the other period can be found. Like on the dashboard the revenue estimate
+// is optional: if it fails the snapshot has none rather than failing.
+func (s *AnalyticsService) GetDashboardSnapshot(userID uuid.UUID, source, platform, category string, from, to time.Time) (*DashboardSnapshot, error) {
+	overview, err := s.GetOverview(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	topLinks, err := s.GetFilteredTopLinks(userID, source, platform, category, from, to, -1)
+	if err != nil {
+		return nil, err
+	}
+	bySource, err := s.GetClicksBySource(userID, source, platform, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byPlatform, err := s.GetClicksByPlatform(userID, source, platform, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byCategory, err := s.GetClicksByCategory(userID, source, platform, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byCountry, err := s.GetClicksByLocation(userID, repository.GeoCountry, "", source, platform, category, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byDevice, err := s.GetClicksByDevice(userID, source, platform, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byOS, err := s.GetClicksByOS(userID, source, platform, from, to)
+	if err != nil {
+		return nil, err
+	}
+	byBrowser, err := s.GetClicksByBrowser(userID, source, platform, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &DashboardSnapshot{
+		Overview:         overview,
+		TopLinks:         topLinks,
+		ClicksBySource:   bySource,
+		ClicksByPlatform: byPlatform,
+		ClicksByCategory: byCategory,
+		ClicksByCountry:  byCountry,
+		ClicksByDevice:   byDevice,
+		ClicksByOS:       byOS,
+		ClicksByBrowser:  byBrowser,
+	}
+	if revenue, err := s.GetEstimatedRevenue(userID, source, platform, category, from, to); err != nil {
+		log.Printf("⚠️  Failed to estimate revenue of user %s for comparison: %v", userID, err)
+	} else {
+		snapshot.EstimatedRevenue = &revenue
+	}
+	return snapshot, nil
+}
+
+// Delta compares a figure with its value in the comparison period
+type Delta struct {
+	Key          string   `json:"key,omitempty"`
+	Label        string   `json:"label,omitempty"` // Link title in top_links
+	Current      float64  `json:"current"`
+	Previous     float64  `json:"previous"`
+	DeltaPercent *float64 `json:"delta_percent"` // nil when the previous value is 0
+}
+
+func newDelta(key string, current, previous float64) Delta {
+	delta := Delta{Key: key, Current: current, Previous: previous}
+	if previous != 0 {
+		percent := math.Round((current-previous)/previous*1000) / 10
+		delta.DeltaPercent = &percent
+	}
+	return delta
+}
+
+// OverviewComparison compares the overview metrics
+type OverviewComparison struct {
+	TotalViews       Delta  `json:"total_views"`
+	TotalClicks      Delta  `json:"total_clicks"`
+	CVR              Delta  `json:"cvr"`
+	BotViews         Delta  `json:"bot_views"`
+	BotClicks        Delta  `json:"bot_clicks"`
+	EstimatedRevenue *Delta `json:"estimated_revenue,omitempty"` // nil unless both periods have an estimate
+}
+
+// DashboardComparison compares the dashboard with the comparison period
+type DashboardComparison struct {
+	Mode             string             `json:"mode"`
+	From             string             `json:"from"` // First day of the comparison period
+	To               string             `json:"to"`   // Last day of the comparison period
+	Overview         OverviewComparison `json:"overview"`
+	TopLinks         []Delta            `json:"top_links"`
+	ClicksBySource   []Delta            `json:"clicks_by_source"`
+	ClicksByPlatform []Delta            `json:"clicks_by_platform"`
+	ClicksByCategory []Delta            `json:"clicks_by_category"`
+	ClicksByCountry  []Delta            `json:"clicks_by_country"`
+	ClicksByDevice   []Delta            `json:"clicks_by_device"`
+	ClicksByOS       []Delta            `json:"clicks_by_os"`
+	ClicksByBrowser  []Delta            `json:"clicks_by_browser"`
+}
+
+// CompareDashboards compares the current dashboard figures with the previous
+// ones. Top links are the current ones; breakdowns list the current values and
+// then the values that only occurred in the previous period.
+func CompareDashboards(current, previous *DashboardSnapshot) DashboardComparison {
+	overview := OverviewComparison{
+		TotalViews:  newDelta("", float64(current.Overview.TotalViews), float64(previous.Overview.TotalViews)),
+		TotalClicks: newDelta("", float64(current.Overview.TotalClicks), float64(previous.Overview.TotalClicks)),
+		CVR:         newDelta("", current.Overview.CVR, previous.Overview.CVR),
+		BotViews:    newDelta("", float64(current.Overview.BotViews), float64(previous.Overview.BotViews)),
+		BotClicks:   newDelta("", float64(current.Overview.BotClicks), float64(previous.Overview.BotClicks)),
+	}
+	if current.EstimatedRevenue != nil && previous.EstimatedRevenue != nil {
+		revenue := newDelta("", *current.EstimatedRevenue, *previous.EstimatedRevenue)
+		overview.EstimatedRevenue = &revenue
+	}
+
+	previousClicks := make(map[uuid.UUID]int64, len(previous.TopLinks))
+	for _, link := range previous.TopLinks {
+		previousClicks[link.LinkID] = link.Clicks
+	}
+	topLinks := make([]Delta, 0, len(current.TopLinks))
+	for _, link := range current.TopLinks {
+		delta := newDelta(link.LinkID.String(), float64(link.Clicks), float64(previousClicks[link.LinkID]))
+		delta.Label = link.Title
+		topLinks = append(topLinks, delta)
+	}
+
+	return DashboardComparison{
+		Overview:         overview,
+		TopLinks:         topLinks,
+		ClicksBySource:   compareStats(current.ClicksBySource, previous.ClicksBySource),
+		ClicksByPlatform: compareStats(current.ClicksByPlatform, previous.ClicksByPlatform),
+		ClicksByCategory: compareStats(current.ClicksByCategory, previous.ClicksByCategory),
+		ClicksByCountry:  compareStats(countryStats(current.ClicksByCountry), countryStats(previous.ClicksByCountry)),
+		ClicksByDevice:   compareStats(current.ClicksByDevice, previous.ClicksByDevice),
+		ClicksByOS:       compareStats(current.ClicksByOS, previous.ClicksByOS),
+		ClicksByBrowser:  compareStats(current.ClicksByBrowser, previous.ClicksByBrowser),
+	}
+}
+
+// countryStats keys a country breakdown by country code
+func countryStats(locations []repository.LocationStat) []repository.SourceStat {
+	stats := make([]repository.SourceStat, 0, len(locations))
+	for _, loc := range locations {
+		stats = append(stats, repository.SourceStat{Source: loc.Country, Count: loc.Count})
+	}
+	return stats
+}
+
+// compareStats compares the counts of a breakdown by key
+func compareStats(current, previous []repository.SourceStat) []Delta {
+	previousCounts := make(map[string]int64, len(previous))
+	for _, stat := range previous {
+		previousCounts[stat.Source] = stat.Count
+	}
+
+	deltas := make([]Delta, 0, len(current))
+	seen := make(map[string]bool, len(current))
+	for _, stat := range current {
+		deltas = append(deltas, newDelta(stat.Source, float64(stat.Count), float64(previousCounts[stat.Source])))
+		seen[stat.Source] = true
+	}
+	for _, stat := range previous {
+		if !seen[stat.Source] {
+			deltas = append(deltas, newDelta(stat.Source, 0, float64(stat.Count)))
+		}
+	}
+	return deltas
+}
@@ -1,27 +1,104 @@
 package services
 
 import (
+	"errors"
+	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
 
+	"github.com/onedash/backend/internal/botdetect"
+	"github.com/onedash/backend/internal/geoip"
+	"github.com/onedash/backend/internal/ingest"
 	"github.com/onedash/backend/internal/models"
 	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/ttlcache"
+	"github.com/onedash/backend/internal/useragent"
 )
 
+var ErrInvalidGeoLevel = errors.New("level must be country, region or city")
+
+// eventQueue receives tracking events; ingest.Pipeline writes them in batches
+type eventQueue interface {
+	Enqueue(e ingest.Event) error
+}
+
+// ipHasher replaces visitor IPs by salted hashes before they are stored; see iphash.Hasher
+type ipHasher interface {
+	Hash(ip string) (string, error)
+}
+
+// geoLocator resolves visitor IPs to a location; see geoip.DB
+type geoLocator interface {
+	Lookup(ip string) (geoip.Location, error)
+}
+
+// BotFilter decides what happens to tracking events from bots. Without a
+// classifier every event is stored as human.
+type BotFilter struct {
+	Classifier *botdetect.Classifier
+	Drop       bool // drop bot events instead of storing them with is_bot set
+}
+
+// dedupSweepInterval bounds how often expired entries leave the dedup cache
+const dedupSweepInterval = time.Minute
+
+// dedupCacheSize bounds the visitors remembered in memory; visitor IDs come
+// from clients, so the cache must not grow with whatever they send
+const dedupCacheSize = 100000
+
+// beaconWait is how long a page view recorded on navigation waits for the
+// page's beacon, which must not count it again
+const beaconWait = 10 * time.Minute
+
 type AnalyticsService struct {
 	analyticsRepo *repository.AnalyticsRepository
 	linkRepo      *repository.LinkRepository
+	settings      *TrackingSettingsService
+	events        eventQueue
+	bots          BotFilter
+	ips           ipHasher
+	geo           geoLocator
+
+	// seen remembers when each visitor's last counted event happened
+	seen *ttlcache.Cache[string, time.Time]
+	now  func() time.Time
+
+	// navigations maps page view IDs handed to server-rendered pages to the viewed profile
+	navigations *ttlcache.Cache[string, uuid.UUID]
 }
 
-func NewAnalyticsService(analyticsRepo *repository.AnalyticsRepository, linkRepo *repository.LinkRepository) *AnalyticsService {
+func NewAnalyticsService(
+	analyticsRepo *repository.AnalyticsRepository,
+	linkRepo *repository.LinkRepository,
+	settings *TrackingSettingsService,
+	events eventQueue,
+	bots BotFilter,
+	ips ipHasher,
+	geo geoLocator,
+) *AnalyticsService {
 	return &AnalyticsService{
 		analyticsRepo: analyticsRepo,
 		linkRepo:      linkRepo,
+		settings:      settings,
+		events:        events,
+		bots:          bots,
+		ips:           ips,
+		geo:           geo,
+		seen:          ttlcache.NewBounded[string, time.Time](dedupSweepInterval, dedupCacheSize),
+		now:           time.Now,
+		navigations:   ttlcache.New[string, uuid.UUID](beaconWait),
 	}
 }
 
+// WithBots returns a service whose reports include (or exclude) bot traffic
+func (s *AnalyticsService) WithBots(include bool) *AnalyticsService {
+	scoped := *s
+	scoped.analyticsRepo = s.analyticsRepo.WithBots(include)
+	return &scoped
+}
+
 func (s *AnalyticsService) GetOverview(userID uuid.UUID, from, to time.Time) (*repository.OverviewStats, error) {
 	return s.analyticsRepo.GetOverviewStats(userID, from, to)
 }
@@ -55,98 +132,291 @@ func detectPlatformFromURL(url string) string {
 	return "others"
 }
 
-// TrackClick with deduplication and auto platform detection
-func (s *AnalyticsService) TrackClick(linkID, userID uuid.UUID, visitorID, source, platform, category, visitorIP, userAgent, referer string) error {
-	// Check for duplicate if visitorID provided
-	if visitorID != "" {
-		exists, err := s.analyticsRepo.CheckClickExists(visitorID, linkID)
-		if err != nil {
-			return err
-		}
-		if exists {
-			return nil
-		}
+// VisitContext describes the visitor request behind a tracking event.
+// VisitorIP is used for bot detection and hashed; it is never stored.
+type VisitContext struct {
+	VisitorID      string
+	Source         string
+	VisitorIP      string
+	Country        string // ISO 3166-1 alpha-2, from the edge proxy; the GeoIP database fills it otherwise
+	Region         string
+	UserAgent      string
+	RequestedWith  string // X-Requested-With, names the app of Android webviews
+	Referer        string
+	AcceptLanguage string
+	ClientHints    string // Sec-CH-UA
+	Purpose        string // Sec-Purpose, set on prefetches
+}
+
+// classify reports whether an event should be kept and whether it comes from a bot
+func (s *AnalyticsService) classify(ctx VisitContext) (keep, isBot bool) {
+	if s.bots.Classifier == nil {
+		return true, false
 	}
+	verdict := s.bots.Classifier.Classify(botdetect.Signals{
+		UserAgent:      ctx.UserAgent,
+		IP:             ctx.VisitorIP,
+		AcceptLanguage: ctx.AcceptLanguage,
+		ClientHints:    ctx.ClientHints,
+		Purpose:        ctx.Purpose,
+	})
+	return !(verdict.Bot && s.bots.Drop), verdict.Bot
+}
 
-	// If platform or category is empty, try to get from link
-	if platform == "" || category == "" {
-		if link, err := s.linkRepo.FindByID(linkID); err == nil {
-			if platform == "" {
-				platform = link.Platform
-				if platform == "" && link.URL != "" {
-					platform = detectPlatformFromURL(link.URL)
-				}
-			}
-			if category == "" {
-				category = link.Category
-			}
-		}
+// Event kinds in dedup cache keys
+const (
+	dedupClick  = "click"
+	dedupView   = "view"
+	dedupSocial = "social"
+)
+
+func (s *AnalyticsService) policy(ownerID uuid.UUID) (TrackingPolicy, error) {
+	if s.settings == nil {
+		return trackingPolicyFrom(models.DefaultTrackingSettings(ownerID)), nil
 	}
+	return s.settings.Policy(ownerID)
+}
 
+// hashIP returns the stored form of the visitor IP, or "" when the creator
+// turned IP capture off. A failure to hash drops the IP rather than the event.
+func (s *AnalyticsService) hashIP(policy TrackingPolicy, ctx VisitContext) string {
+	if !policy.CaptureIP || s.ips == nil {
+		return ""
+	}
+	hash, err := s.ips.Hash(ctx.VisitorIP)
+	if err != nil {
+		log.Printf("⚠️  Failed to hash visitor IP: %v", err)
+		return ""
+	}
+	return hash
+}
+
+// locate returns the stored location of the visitor. The edge proxy's country
+// and region win; the GeoIP database fills what they leave out, as long as it
+// places the IP in the same country. The IP is not looked up when the creator
+// turned IP capture off.
+func (s *AnalyticsService) locate(policy TrackingPolicy, ctx VisitContext) geoip.Location {
+	loc := geoip.Location{Country: ctx.Country, Region: ctx.Region}
+	if !policy.CaptureIP || s.geo == nil || ctx.VisitorIP == "" {
+		return loc
+	}
+	found, err := s.geo.Lookup(ctx.VisitorIP)
+	if err != nil {
+		log.Printf("⚠️  GeoIP lookup failed: %v", err)
+		return loc
+	}
+	if found.Country == "" || (loc.Country != "" && loc.Country != found.Country) {
+		return loc
+	}
+	loc.Country = found.Country
+	if loc.Region == "" {
+		loc.Region = found.Region
+	}
+	if loc.Region == found.Region {
+		loc.City = found.City
+	}
+	return loc
+}
+
+// isDuplicate reports whether the visitor already produced the event (kind on
+// target) within window, and remembers it otherwise. Events are remembered in
+// memory with their time as they are queued, so a repeat is caught even before
+// the first one is written and a changed window applies to remembered events.
+// Visitors not remembered (evicted, from before a restart or a full cache) are
+// looked up in the database. The returned forget undoes remembering the event,
+// for when it cannot be queued. Without an IP hash the visitor_ip key compares
+// visitor IDs only.
+func (s *AnalyticsService) isDuplicate(
+	kind, target, key string,
+	window time.Duration,
+	visitorID, ipHash string,
+	lookup func(repository.DedupFilter) (bool, error),
+) (duplicate bool, forget func(), err error) {
+	forget = func() {}
+	if key == models.DedupKeyNone || visitorID == "" || window <= 0 {
+		return false, forget, nil
+	}
+
+	now := s.now()
+	filter := repository.DedupFilter{VisitorID: visitorID, Since: now.Add(-window)}
+	cacheKey := kind + "|" + target + "|" + visitorID
+	if key == models.DedupKeyVisitorIP {
+		filter.IPHash = ipHash
+		cacheKey += "|" + ipHash
+	}
+
+	withinWindow := func(seenAt time.Time) bool { return now.Sub(seenAt) < window }
+	if !s.seen.AddUnless(cacheKey, now, window, withinWindow) {
+		return true, forget, nil
+	}
+	forget = func() { s.seen.Delete(cacheKey) }
+
+	exists, err := lookup(filter)
+	if err != nil || exists {
+		// The event is not counted, so it must not start a window
+		forget()
+		return exists, func() {}, err
+	}
+	return false, forget, nil
+}
+
+// enqueue queues an event that passed dedup. If the queue refuses it the event
+// is lost, so dedup forgets it and the visitor's retry counts.
+func (s *AnalyticsService) enqueue(e ingest.Event, forget func()) error {
+	if err := s.events.Enqueue(e); err != nil {
+		forget()
+		return err
+	}
+	return nil
+}
+
+// RecordClick queues a click on link, skipping repeats within the owner's dedup policy.
+// Owner, platform and category always come from the link, never from the client.
+func (s *AnalyticsService) RecordClick(link *models.Link, ctx VisitContext) error {
+	keep, isBot := s.classify(ctx)
+	if !keep {
+		return nil
+	}
+
+	policy, err := s.policy(link.UserID)
+	if err != nil {
+		return err
+	}
+	ipHash := s.hashIP(policy, ctx)
+	duplicate, forget, err := s.isDuplicate(dedupClick, link.ID.String(), policy.DedupKey, policy.ClickWindow, ctx.VisitorID, ipHash,
+		func(filter repository.DedupFilter) (bool, error) {
+			return s.analyticsRepo.CheckClickExists(link.ID, filter)
+		})
+	if err != nil || duplicate {
+		return err
+	}
+
+	platform := link.Platform
+	if platform == "" && link.URL != "" {
+		platform = detectPlatformFromURL(link.URL)
+	}
+
+	linkID := link.ID
+	loc := s.locate(policy, ctx)
+	client := useragent.Parse(ctx.UserAgent, ctx.RequestedWith)
 	click := &models.LinkClick{
-		LinkID:    &linkID,
-		UserID:    userID,
-		VisitorID: visitorID,
-		Source:    source,
-		Platform:  platform,
-		Category:  category,
-		VisitorIP: visitorIP,
-		UserAgent: userAgent,
-		Referer:   referer,
-	}
-	return s.analyticsRepo.CreateClick(click)
-}
-
-func (s *AnalyticsService) TrackPageView(userID uuid.UUID, visitorID, source, visitorIP, userAgent string) error {
-	// Skip tracking if visitor_id is empty (likely SSR or bot)
-	if visitorID == "" {
+		LinkID:     &linkID,
+		UserID:     link.UserID,
+		VisitorID:  ctx.VisitorID,
+		Source:     ctx.Source,
+		Platform:   platform,
+		Category:   link.Category,
+		IPHash:     ipHash,
+		Country:    loc.Country,
+		Region:     loc.Region,
+		City:       loc.City,
+		UserAgent:  ctx.UserAgent,
+		DeviceType: client.DeviceType,
+		OS:         client.OS,
+		Browser:    client.Browser,
+		Referer:    ctx.Referer,
+		IsBot:      isBot,
+	}
+	return s.enqueue(ingest.Event{Click: click}, forget)
+}
+
+func (s *AnalyticsService) TrackPageView(userID uuid.UUID, ctx VisitContext) error {
+	// Handlers always resolve a visitor; without one a view cannot be deduplicated
+	if ctx.VisitorID == "" {
 		return nil
 	}
-	
-	// Skip tracking if user agent is 'node' (SSR or internal requests)
-	if userAgent == "node" || userAgent == "" {
+
+	keep, isBot := s.classify(ctx)
+	if !keep {
 		return nil
 	}
-	
-	// Check for duplicate pageview within 1 hour
-	exists, err := s.analyticsRepo.CheckPageViewExists(visitorID, userID)
+
+	policy, err := s.policy(userID)
 	if err != nil {
 		return err
 	}
-	if exists {
-		// Skip tracking if same visitor viewed within last hour
-		return nil
+	ipHash := s.hashIP(policy, ctx)
+	duplicate, forget, err := s.isDuplicate(dedupView, userID.String(), policy.DedupKey, policy.ViewWindow, ctx.VisitorID, ipHash,
+		func(filter repository.DedupFilter) (bool, error) {
+			return s.analyticsRepo.CheckPageViewExists(userID, filter)
+		})
+	if err != nil || duplicate {
+		return err
 	}
 
+	loc := s.locate(policy, ctx)
+	client := useragent.Parse(ctx.UserAgent, ctx.RequestedWith)
 	view := &models.PageView{
-		UserID:    userID,
-		VisitorID: visitorID,
-		Source:    source,
-		VisitorIP: visitorIP,
-		UserAgent: userAgent,
+		UserID:     userID,
+		VisitorID:  ctx.VisitorID,
+		Source:     ctx.Source,
+		IPHash:     ipHash,
+		Country:    loc.Country,
+		Region:     loc.Region,
+		City:       loc.City,
+		UserAgent:  ctx.UserAgent,
+		DeviceType: client.DeviceType,
+		OS:         client.OS,
+		Browser:    client.Browser,
+		IsBot:      isBot,
 	}
-	return s.analyticsRepo.CreatePageView(view)
+	return s.enqueue(ingest.Event{PageView: view}, forget)
 }
 
-// TrackSocialClick with deduplication
-func (s *AnalyticsService) TrackSocialClick(userID uuid.UUID, visitorID, source, socialType string) error {
-	if visitorID != "" {
-		exists, err := s.analyticsRepo.CheckSocialClickExists(visitorID, userID, socialType)
-		if err != nil {
-			return err
-		}
-		if exists {
+// TrackNavigation records the page view of a browser navigating to a profile
+// and returns an ID for the rendered page. The page reports it back with its
+// beacon so the view is counted once.
+func (s *AnalyticsService) TrackNavigation(userID uuid.UUID, ctx VisitContext) (string, error) {
+	if err := s.TrackPageView(userID, ctx); err != nil {
+		return "", err
+	}
+	pageViewID := uuid.NewString()
+	s.navigations.Set(pageViewID, userID, beaconWait)
+	return pageViewID, nil
+}
+
+// TrackPageViewBeacon records a page view reported by the client, unless
+// pageViewID shows it was already recorded when the page was rendered.
+// Unknown IDs (expired, or issued by another instance) fall back to dedup.
+func (s *AnalyticsService) TrackPageViewBeacon(userID uuid.UUID, pageViewID string, ctx VisitContext) error {
+	if pageViewID != "" {
+		if viewed, ok := s.navigations.Get(pageViewID); ok && viewed == userID {
 			return nil
 		}
 	}
+	return s.TrackPageView(userID, ctx)
+}
+
+// TrackSocialClick with deduplication
+func (s *AnalyticsService) TrackSocialClick(userID uuid.UUID, socialType string, ctx VisitContext) error {
+	keep, isBot := s.classify(ctx)
+	if !keep {
+		return nil
+	}
+
+	policy, err := s.policy(userID)
+	if err != nil {
+		return err
+	}
+	duplicate, forget, err := s.isDuplicate(dedupSocial, userID.String()+"|"+socialType, policy.DedupKey, policy.SocialWindow,
+		ctx.VisitorID, s.hashIP(policy, ctx),
+		func(filter repository.DedupFilter) (bool, error) {
+			// Social clicks do not store an IP hash; match the visitor alone
+			filter.IPHash = ""
+			return s.analyticsRepo.CheckSocialClickExists(userID, socialType, filter)
+		})
+	if err != nil || duplicate {
+		return err
+	}
 
 	click := &models.SocialClick{
 		UserID:     userID,
-		VisitorID:  visitorID,
-		Source:     source,
+		VisitorID:  ctx.VisitorID,
+		Source:     ctx.Source,
 		SocialType: socialType,
+		IsBot:      isBot,
 	}
-	return s.analyticsRepo.CreateSocialClick(click)
+	return s.enqueue(ingest.Event{SocialClick: click}, forget)
 }
 
 // Dashboard stats
@@ -170,6 +440,36 @@ func (s *AnalyticsService) GetClicksByCategory(userID uuid.UUID, source, platfor
 	return s.analyticsRepo.GetClicksByCategory(userID, source, platform, from, to)
 }
 
+func (s *AnalyticsService) GetClicksByDevice(userID uuid.UUID, source, platform string, from, to time.Time) ([]repository.SourceStat, error) {
+	return s.analyticsRepo.GetClicksByDevice(userID, source, platform, from, to)
+}
+
+func (s *AnalyticsService) GetClicksByOS(userID uuid.UUID, source, platform string, from, to time.Time) ([]repository.SourceStat, error) {
+	return s.analyticsRepo.GetClicksByOS(userID, source, platform, from, to)
+}
+
+func (s *AnalyticsService) GetClicksByBrowser(userID uuid.UUID, source, platform string, from, to time.Time) ([]repository.SourceStat, error) {
+	return s.analyticsRepo.GetClicksByBrowser(userID, source, platform, from, to)
+}
+
+// GetClicksByLocation breaks clicks down by country, region or city (repository.Geo*)
+func (s *AnalyticsService) GetClicksByLocation(userID uuid.UUID, level, country, source, platform, category string, from, to time.Time) ([]repository.LocationStat, error) {
+	switch level {
+	case repository.GeoCountry, repository.GeoRegion, repository.GeoCity:
+	default:
+		return nil, ErrInvalidGeoLevel
+	}
+	params := repository.FilterParams{
+		UserID:   userID,
+		Source:   source,
+		Platform: platform,
+		Category: category,
+		From:     from,
+		To:       to,
+	}
+	return s.analyticsRepo.GetClicksByLocation(params, level, strings.ToUpper(country))
+}
+
 func (s *AnalyticsService) GetViewsBySource(userID uuid.UUID) ([]repository.SourceStat, error) {
 	return s.analyticsRepo.GetViewsBySource(userID)
 }
@@ -182,6 +482,25 @@ func (s *AnalyticsService) GetTimelineClicksByGroup(userID uuid.UUID, timeGroup,
 	return s.analyticsRepo.GetTimelineClicksByGroup(userID, timeGroup, groupBy, source, platform, category, from, to)
 }
 
-func (s *AnalyticsService) GetEstimatedRevenue(userID uuid.UUID, from, to time.Time) (float64, error) {
-	return s.analyticsRepo.GetEstimatedRevenue(userID, from, to)
+func (s *AnalyticsService) GetEstimatedRevenue(userID uuid.UUID, source, platform, category string, from, to time.Time) (float64, error) {
+	return s.analyticsRepo.GetEstimatedRevenue(repository.FilterParams{
+		UserID:   userID,
+		Source:   source,
+		Platform: platform,
+		Category: category,
+		From:     from,
+		To:       to,
+	})
+}
+
+// GetRevenueBreakdown estimates the commission of the filtered clicks by link, platform, category, source and day
+func (s *AnalyticsService) GetRevenueBreakdown(userID uuid.UUID, source, platform, category string, from, to time.Time) (*repository.RevenueBreakdown, error) {
+	return s.analyticsRepo.GetRevenueBreakdown(repository.FilterParams{
+		UserID:   userID,
+		Source:   source,
+		Platform: platform,
+		Category: category,
+		From:     from,
+		To:       to,
+	})
 }
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/geoip"
+	"github.com/onedash/backend/internal/ingest"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+// stubQueue collects events, or rejects them while full is set
+type stubQueue struct {
+	events []ingest.Event
+	full   bool
+}
+
+func (q *stubQueue) Enqueue(e ingest.Event) error {
+	if q.full {
+		return ingest.ErrQueueFull
+	}
+	q.events = append(q.events, e)
+	return nil
+}
+
+type dedupFixture struct {
+	service  *AnalyticsService
+	settings *TrackingSettingsService
+	queue    *stubQueue
+	link     *models.Link
+	now      time.Time
+}
+
+func newDedupFixture(t *testing.T) *dedupFixture {
+	db := testutil.NewDB(t)
+	user := &models.User{Email: "fia@example.com", Username: "fia", PasswordHash: "hash"}
+	require.NoError(t, repository.NewUserRepository(db).Create(user))
+	link := &models.Link{UserID: user.ID, Title: "Lamp", URL: "https://shopee.co.id/lamp", Platform: "shopee", IsActive: true}
+	require.NoError(t, repository.NewLinkRepository(db).Create(link))
+
+	f := &dedupFixture{
+		settings: NewTrackingSettingsService(repository.NewTrackingSettingsRepository(db)),
+		queue:    &stubQueue{},
+		link:     link,
+		now:      time.Date(2024, 7, 1, 12, 0, 0, 0, time.UTC),
+	}
+	f.service = NewAnalyticsService(repository.NewAnalyticsRepository(db), repository.NewLinkRepository(db),
+		f.settings, f.queue, BotFilter{}, nil, nil)
+	f.service.now = func() time.Time { return f.now }
+	return f
+}
+
+func (f *dedupFixture) click(t *testing.T) error {
+	t.Helper()
+	return f.service.RecordClick(f.link, VisitContext{VisitorID: "visitor-1", Source: "instagram"})
+}
+
+func TestDedupForgetsEventsTheQueueRejected(t *testing.T) {
+	f := newDedupFixture(t)
+
+	f.queue.full = true
+	assert.ErrorIs(t, f.click(t), ingest.ErrQueueFull)
+
+	// The lost click does not hide the retry
+	f.queue.full = false
+	require.NoError(t, f.click(t))
+	require.NoError(t, f.click(t))
+	assert.Len(t, f.queue.events, 1)
+}
+
+func TestDedupAppliesChangedWindowsToRememberedEvents(t *testing.T) {
+	f := newDedupFixture(t)
+	_, err := f.settings.UpdateSettings(f.link.UserID, &UpdateTrackingSettingsInput{ClickDedupWindowSeconds: 3600})
+	require.NoError(t, err)
+	require.NoError(t, f.click(t))
+
+	// Lengthened from one to three hours: a repeat two hours later is still a repeat
+	f.now = f.now.Add(2 * time.Hour)
+	_, err = f.settings.UpdateSettings(f.link.UserID, &UpdateTrackingSettingsInput{ClickDedupWindowSeconds: 3 * 3600})
+	require.NoError(t, err)
+	require.NoError(t, f.click(t))
+	assert.Len(t, f.queue.events, 1)
+
+	// Shortened to one minute: the remembered click no longer blocks
+	_, err = f.settings.UpdateSettings(f.link.UserID, &UpdateTrackingSettingsInput{ClickDedupWindowSeconds: 60})
+	require.NoError(t, err)
+	require.NoError(t, f.click(t))
+	assert.Len(t, f.queue.events, 2)
+}
+
+// stubLocator knows the location of some IPs and fails for "broken"
+type stubLocator map[string]geoip.Location
+
+func (l stubLocator) Lookup(ip string) (geoip.Location, error) {
+	if ip == "broken" {
+		return geoip.Location{}, errors.New("corrupt database")
+	}
+	return l[ip], nil
+}
+
+func TestLocatePrefersTheProxyCountry(t *testing.T) {
+	jakarta := geoip.Location{Country: "ID", Region: "JK", City: "Jakarta"}
+	service := &AnalyticsService{geo: stubLocator{"203.0.113.42": jakarta}}
+	capture := TrackingPolicy{CaptureIP: true}
+
+	for _, tc := range []struct {
+		name string
+		ctx  VisitContext
+		want geoip.Location
+	}{
+		{"database only", VisitContext{VisitorIP: "203.0.113.42"}, jakarta},
+		{"proxy agrees", VisitContext{VisitorIP: "203.0.113.42", Country: "ID", Region: "JK"}, jakarta},
+		{"proxy without region", VisitContext{VisitorIP: "203.0.113.42", Country: "ID"}, jakarta},
+		{"proxy country wins", VisitContext{VisitorIP: "203.0.113.42", Country: "SG"}, geoip.Location{Country: "SG"}},
+		// The city belongs to the database's region, not the proxy's
+		{"regions differ", VisitContext{VisitorIP: "203.0.113.42", Country: "ID", Region: "JB"}, geoip.Location{Country: "ID", Region: "JB"}},
+		{"unknown IP", VisitContext{VisitorIP: "192.0.2.1", Country: "ID"}, geoip.Location{Country: "ID"}},
+		{"no IP", VisitContext{Country: "ID", Region: "JK"}, geoip.Location{Country: "ID", Region: "JK"}},
+		{"lookup fails", VisitContext{VisitorIP: "broken", Country: "ID"}, geoip.Location{Country: "ID"}},
+	} {
+		assert.Equal(t, tc.want, service.locate(capture, tc.ctx), tc.name)
+	}
+
+	// Without a database only the proxy's location is stored
+	assert.Equal(t, geoip.Location{Country: "ID"}, (&AnalyticsService{}).locate(capture, VisitContext{VisitorIP: "203.0.113.42", Country: "ID"}))
+
+	// Nor is the IP looked up once the creator turned IP capture off
+	assert.Equal(t, geoip.Location{Country: "ID"}, service.locate(TrackingPolicy{}, VisitContext{VisitorIP: "203.0.113.42", Country: "ID"}))
+}
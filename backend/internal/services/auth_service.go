@@ -1,24 +1,61 @@
 package services
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
-	"os"
+	"log"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 
+	"github.com/onedash/backend/config"
 	"github.com/onedash/backend/internal/models"
 	"github.com/onedash/backend/internal/repository"
 )
 
+var (
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token reuse detected, please log in again")
+	ErrAccountSuspended    = errors.New("this account has been suspended")
+)
+
+// dummyPasswordHash is compared against when the account does not exist,
+// so response time does not reveal which usernames are registered
+var dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("onedash-dummy-password"), bcrypt.DefaultCost)
+
 type AuthService struct {
-	userRepo *repository.UserRepository
+	userRepo                 *repository.UserRepository
+	refreshTokenRepo         *repository.RefreshTokenRepository
+	sessionService           *SessionService
+	emailVerificationService *EmailVerificationService
+	loginAttemptService      *LoginAttemptService
+	twoFactorService         *TwoFactorService
+	config                   config.AuthConfig
 }
 
-func NewAuthService(userRepo *repository.UserRepository) *AuthService {
-	return &AuthService{userRepo: userRepo}
+func NewAuthService(
+	userRepo *repository.UserRepository,
+	refreshTokenRepo *repository.RefreshTokenRepository,
+	sessionService *SessionService,
+	emailVerificationService *EmailVerificationService,
+	loginAttemptService *LoginAttemptService,
+	twoFactorService *TwoFactorService,
+	authConfig config.AuthConfig,
+) *AuthService {
+	return &AuthService{
+		userRepo:                 userRepo,
+		refreshTokenRepo:         refreshTokenRepo,
+		sessionService:           sessionService,
+		emailVerificationService: emailVerificationService,
+		loginAttemptService:      loginAttemptService,
+		twoFactorService:         twoFactorService,
+		config:                   authConfig,
+	}
 }
 
 type RegisterInput struct {
@@ -32,18 +69,48 @@ type LoginInput struct {
 	Password string `json:"password" validate:"required"`
 }
 
+type RefreshInput struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type VerifyTwoFactorInput struct {
+	ChallengeToken string `json:"challenge_token" validate:"required"`
+	Code           string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
 type AuthResponse struct {
-	User  *models.User `json:"user"`
-	Token string       `json:"token"`
+	User         *models.User `json:"user,omitempty"`
+	Token        string       `json:"token,omitempty"`
+	ExpiresAt    *time.Time   `json:"expires_at,omitempty"` // Access token expiry
+	RefreshToken string       `json:"refresh_token,omitempty"`
+
+	// Returned by Login instead of the tokens above when the account has 2FA enabled.
+	// Exchange the challenge token and a code at /api/auth/2fa/verify.
+	TwoFactorRequired bool   `json:"two_factor_required,omitempty"`
+	ChallengeToken    string `json:"challenge_token,omitempty"`
 }
 
 type JWTClaims struct {
-	UserID   uuid.UUID `json:"user_id"`
-	Username string    `json:"username"`
+	UserID    uuid.UUID `json:"user_id"`
+	Username  string    `json:"username"`
+	SessionID uuid.UUID `json:"sid"`
+	jwt.RegisteredClaims
+}
+
+// challengeAudience marks the short-lived token issued between password and 2FA code.
+// It carries no session, so AuthMiddleware never accepts it as an access token.
+const (
+	challengeAudience = "onedash-2fa"
+	challengeTTL      = 5 * time.Minute
+)
+
+type ChallengeClaims struct {
+	UserID     uuid.UUID `json:"user_id"`
+	Identifier string    `json:"identifier"` // Username or email the login was started with
 	jwt.RegisteredClaims
 }
 
-func (s *AuthService) Register(input *RegisterInput) (*AuthResponse, error) {
+func (s *AuthService) Register(input *RegisterInput, client ClientInfo) (*AuthResponse, error) {
 	// Check if email exists
 	if s.userRepo.ExistsByEmail(input.Email) {
 		return nil, errors.New("email already registered")
@@ -72,76 +139,268 @@ func (s *AuthService) Register(input *RegisterInput) (*AuthResponse, error) {
 		return nil, errors.New("failed to create user")
 	}
 
-	// Generate token
-	token, err := s.generateToken(user)
-	if err != nil {
-		return nil, errors.New("failed to generate token")
+	// Registration succeeds even if the email cannot be sent - the user can request it again
+	if err := s.emailVerificationService.SendVerification(user); err != nil {
+		log.Printf("⚠️  Failed to send verification email to user %s: %v", user.ID, err)
 	}
 
-	return &AuthResponse{
-		User:  user,
-		Token: token,
-	}, nil
+	return s.startSession(user, client)
 }
 
-func (s *AuthService) Login(input *LoginInput) (*AuthResponse, error) {
+// Login authenticates the user. Repeated failures lock the account and the client IP
+// with exponential backoff; while locked a *LockedError is returned.
+// For accounts with 2FA enabled only a challenge token is returned.
+func (s *AuthService) Login(input *LoginInput, client ClientInfo) (*AuthResponse, error) {
+	// Stop credential stuffing from one address before touching any account
+	if err := s.loginAttemptService.CheckIP(client.IP); err != nil {
+		return nil, err
+	}
+
 	// Find user by username or email
 	user, err := s.userRepo.FindByEmailOrUsername(input.Username)
 	if err != nil {
+		// Unknown usernames lock out like existing ones
+		if err := s.loginAttemptService.CheckIdentifier(input.Username); err != nil {
+			return nil, err
+		}
+		_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(input.Password))
+		_ = s.loginAttemptService.RecordFailure(nil, input.Username, LoginFailureUnknownUser, client)
 		return nil, errors.New("invalid credentials")
 	}
 
+	// Failures count per account, so its username and email share one budget
+	if err := s.loginAttemptService.CheckAccount(user.ID); err != nil {
+		return nil, err
+	}
+
 	// Check password
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		_ = s.loginAttemptService.RecordFailure(&user.ID, input.Username, LoginFailureInvalidPassword, client)
 		return nil, errors.New("invalid credentials")
 	}
 
-	// Generate token
-	token, err := s.generateToken(user)
+	// Only reveal the suspension to someone who knows the password
+	if user.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+
+	// The login only counts as successful once the second factor is verified
+	if user.TwoFactorEnabled {
+		challenge, err := s.generateChallengeToken(user, input.Username)
+		if err != nil {
+			return nil, errors.New("failed to generate challenge token")
+		}
+		return &AuthResponse{
+			TwoFactorRequired: true,
+			ChallengeToken:    challenge,
+		}, nil
+	}
+
+	_ = s.loginAttemptService.RecordSuccess(user.ID, input.Username, client)
+
+	return s.startSession(user, client)
+}
+
+// VerifyTwoFactor completes a login started with Login using a TOTP or recovery code.
+// Wrong codes count towards the same lockout as wrong passwords.
+func (s *AuthService) VerifyTwoFactor(input *VerifyTwoFactorInput, client ClientInfo) (*AuthResponse, error) {
+	claims, err := s.parseChallengeToken(input.ChallengeToken)
+	if err != nil {
+		return nil, errors.New("invalid or expired challenge, please log in again")
+	}
+
+	if err := s.loginAttemptService.CheckIP(client.IP); err != nil {
+		return nil, err
+	}
+	if err := s.loginAttemptService.CheckAccount(claims.UserID); err != nil {
+		return nil, err
+	}
+
+	user, err := s.userRepo.FindByID(claims.UserID)
+	if err != nil || !user.TwoFactorEnabled {
+		return nil, errors.New("invalid or expired challenge, please log in again")
+	}
+	if user.IsSuspended() {
+		return nil, ErrAccountSuspended
+	}
+
+	if err := s.twoFactorService.VerifyCode(user, input.Code); err != nil {
+		_ = s.loginAttemptService.RecordFailure(&user.ID, claims.Identifier, LoginFailureInvalidTwoFactorCode, client)
+		return nil, ErrInvalidTwoFactorCode
+	}
+
+	_ = s.loginAttemptService.RecordSuccess(user.ID, claims.Identifier, client)
+
+	return s.startSession(user, client)
+}
+
+// Refresh exchanges a refresh token for a new access token and a new refresh token.
+// Presenting a token that was already rotated revokes its whole family.
+func (s *AuthService) Refresh(input *RefreshInput, client ClientInfo) (*AuthResponse, error) {
+	stored, err := s.refreshTokenRepo.FindByHash(hashToken(input.RefreshToken))
+	if err != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if stored.RevokedAt != nil {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	// A rotated token should never be presented again - assume it was stolen
+	if stored.RotatedAt != nil {
+		_ = s.sessionService.RevokeSession(stored.UserID, stored.FamilyID)
+		return nil, ErrRefreshTokenReused
+	}
+
+	if time.Now().After(stored.ExpiresAt) || !s.sessionService.IsActive(stored.FamilyID, stored.UserID) {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	user, err := s.userRepo.FindByID(stored.UserID)
+	if err != nil || user.IsSuspended() {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	rawToken, next, err := s.newRefreshToken(user.ID, stored.FamilyID)
+	if err != nil {
+		return nil, errors.New("failed to generate refresh token")
+	}
+
+	if err := s.refreshTokenRepo.Rotate(stored, next); err != nil {
+		if errors.Is(err, repository.ErrTokenAlreadyRotated) {
+			_ = s.sessionService.RevokeSession(stored.UserID, stored.FamilyID)
+			return nil, ErrRefreshTokenReused
+		}
+		return nil, errors.New("failed to rotate refresh token")
+	}
+	_ = s.sessionService.Touch(stored.FamilyID, client, next.ExpiresAt)
+
+	token, expiresAt, err := s.generateToken(user, stored.FamilyID)
 	if err != nil {
 		return nil, errors.New("failed to generate token")
 	}
 
 	return &AuthResponse{
-		User:  user,
-		Token: token,
+		User:         user,
+		Token:        token,
+		ExpiresAt:    &expiresAt,
+		RefreshToken: rawToken,
 	}, nil
 }
 
-func (s *AuthService) generateToken(user *models.User) (string, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "default-secret-key"
+// startSession creates a session with its access token and first refresh token
+func (s *AuthService) startSession(user *models.User, client ClientInfo) (*AuthResponse, error) {
+	rawToken, refreshToken, err := s.newRefreshToken(user.ID, uuid.Nil)
+	if err != nil {
+		return nil, errors.New("failed to generate refresh token")
+	}
+
+	session, err := s.sessionService.CreateSession(user.ID, client, refreshToken.ExpiresAt)
+	if err != nil {
+		return nil, errors.New("failed to create session")
+	}
+
+	// The session ID is the refresh token family
+	refreshToken.FamilyID = session.ID
+	if err := s.refreshTokenRepo.Create(refreshToken); err != nil {
+		return nil, errors.New("failed to store refresh token")
 	}
 
-	expiryStr := os.Getenv("JWT_EXPIRY")
-	expiry, err := time.ParseDuration(expiryStr)
+	token, expiresAt, err := s.generateToken(user, session.ID)
 	if err != nil {
-		expiry = 24 * time.Hour
+		return nil, errors.New("failed to generate token")
 	}
 
+	return &AuthResponse{
+		User:         user,
+		Token:        token,
+		ExpiresAt:    &expiresAt,
+		RefreshToken: rawToken,
+	}, nil
+}
+
+func (s *AuthService) generateToken(user *models.User, sessionID uuid.UUID) (string, time.Time, error) {
+	expiresAt := time.Now().Add(s.config.JWTExpiry)
 	claims := &JWTClaims{
-		UserID:   user.ID,
-		Username: user.Username,
+		UserID:    user.ID,
+		Username:  user.Username,
+		SessionID: sessionID,
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(expiry)),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Subject:   user.ID.String(),
 		},
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(secret))
+	signed, err := token.SignedString([]byte(s.config.JWTSecret))
+	return signed, expiresAt, err
 }
 
-func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		secret = "default-secret-key"
+func (s *AuthService) generateChallengeToken(user *models.User, identifier string) (string, error) {
+	claims := &ChallengeClaims{
+		UserID:     user.ID,
+		Identifier: identifier,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(challengeTTL)),
+			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			Subject:   user.ID.String(),
+			Audience:  jwt.ClaimStrings{challengeAudience},
+		},
 	}
 
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	return token.SignedString([]byte(s.config.JWTSecret))
+}
+
+func (s *AuthService) parseChallengeToken(tokenString string) (*ChallengeClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ChallengeClaims{}, func(token *jwt.Token) (interface{}, error) {
+		return []byte(s.config.JWTSecret), nil
+	}, jwt.WithAudience(challengeAudience), jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if err != nil || !token.Valid {
+		return nil, errors.New("invalid challenge token")
+	}
+
+	claims, ok := token.Claims.(*ChallengeClaims)
+	if !ok {
+		return nil, errors.New("invalid challenge token claims")
+	}
+	return claims, nil
+}
+
+// newRefreshToken generates a random refresh token; only its hash is persisted
+func (s *AuthService) newRefreshToken(userID, familyID uuid.UUID) (string, *models.RefreshToken, error) {
+	rawToken, err := generateRandomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	return rawToken, &models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(s.config.RefreshTokenExpiry),
+	}, nil
+}
+
+// generateRandomToken returns a URL-safe random token with 256 bits of entropy
+func generateRandomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// hashToken returns the hex SHA-256 of a token for storage and lookup
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return []byte(secret), nil
+		return []byte(s.config.JWTSecret), nil
 	})
 
 	if err != nil || !token.Valid {
@@ -153,9 +412,19 @@ func (s *AuthService) ValidateToken(tokenString string) (*JWTClaims, error) {
 		return nil, errors.New("invalid token claims")
 	}
 
+	// Signature and expiry are not enough - the session must not have been revoked
+	if !s.sessionService.IsActive(claims.SessionID, claims.UserID) {
+		return nil, errors.New("session has been revoked")
+	}
+
 	return claims, nil
 }
 
+// GetFailedLoginAttempts lists recent failed logins on the user's account
+func (s *AuthService) GetFailedLoginAttempts(userID uuid.UUID) ([]models.LoginAttempt, error) {
+	return s.loginAttemptService.GetFailedAttempts(userID, 50)
+}
+
 func (s *AuthService) GetUserByID(id uuid.UUID) (*models.User, error) {
 	return s.userRepo.FindByID(id)
 }
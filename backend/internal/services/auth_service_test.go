@@ -0,0 +1,134 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+
+	"github.com/onedash/backend/config"
+	"github.com/onedash/backend/internal/mailer"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/testutil"
+	"github.com/onedash/backend/internal/totp"
+)
+
+type authFixture struct {
+	db        *gorm.DB
+	service   *AuthService
+	sessions  *SessionService
+	twoFactor *TwoFactorService
+}
+
+func newAuthFixture(t *testing.T) *authFixture {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	refreshTokenRepo := repository.NewRefreshTokenRepository(db)
+
+	f := &authFixture{
+		db:        db,
+		sessions:  NewSessionService(repository.NewSessionRepository(db), refreshTokenRepo),
+		twoFactor: NewTwoFactorService(userRepo, repository.NewRecoveryCodeRepository(db)),
+	}
+	f.service = NewAuthService(
+		userRepo,
+		refreshTokenRepo,
+		f.sessions,
+		NewEmailVerificationService(userRepo, repository.NewUserTokenRepository(db), mailer.NewLogMailer(""), "https://app.example.com"),
+		NewLoginAttemptService(repository.NewLoginAttemptRepository(db)),
+		f.twoFactor,
+		config.AuthConfig{JWTSecret: "test-secret", JWTExpiry: time.Hour, RefreshTokenExpiry: 24 * time.Hour},
+	)
+	return f
+}
+
+func (f *authFixture) register(t *testing.T, username, password string) *AuthResponse {
+	t.Helper()
+	resp, err := f.service.Register(&RegisterInput{Email: username + "@example.com", Username: username, Password: password}, ClientInfo{})
+	require.NoError(t, err)
+	return resp
+}
+
+func TestLoginLocksUnknownUsernamesLikeAccounts(t *testing.T) {
+	f := newAuthFixture(t)
+	f.register(t, "lina", "correct-horse")
+	client := ClientInfo{IP: "203.0.113.7"}
+
+	for _, username := range []string{"lina", "ghost"} {
+		for i := 0; i < accountFailureThreshold; i++ {
+			_, err := f.service.Login(&LoginInput{Username: username, Password: "wrong"}, client)
+			var locked *LockedError
+			require.Error(t, err)
+			require.False(t, errors.As(err, &locked), "%s attempt %d", username, i+1)
+		}
+
+		_, err := f.service.Login(&LoginInput{Username: username, Password: "wrong"}, client)
+		var locked *LockedError
+		assert.ErrorAs(t, err, &locked, username)
+	}
+}
+
+func TestLoginLocksAccountsWhicheverIdentifierIsUsed(t *testing.T) {
+	f := newAuthFixture(t)
+	f.register(t, "lina", "correct-horse")
+	client := ClientInfo{IP: "203.0.113.7"}
+	identifiers := []string{"lina", "lina@example.com"}
+
+	// Alternating the username and the email does not double the guesses
+	for i := 0; i < accountFailureThreshold; i++ {
+		_, err := f.service.Login(&LoginInput{Username: identifiers[i%2], Password: "wrong"}, client)
+		var locked *LockedError
+		require.Error(t, err)
+		require.False(t, errors.As(err, &locked), "attempt %d", i+1)
+	}
+	for _, identifier := range identifiers {
+		_, err := f.service.Login(&LoginInput{Username: identifier, Password: "correct-horse"}, client)
+		var locked *LockedError
+		assert.ErrorAs(t, err, &locked, identifier)
+	}
+}
+
+func TestTwoFactorLoginClearsFailuresOfItsIdentifier(t *testing.T) {
+	f := newAuthFixture(t)
+	user := f.register(t, "oka", "correct-horse").User
+	setup, err := f.twoFactor.Setup(user.ID)
+	require.NoError(t, err)
+	step := totp.Step(time.Now())
+	code := func(step int64) string {
+		c, err := totp.CodeAt(setup.Secret, step)
+		require.NoError(t, err)
+		return c
+	}
+	_, err = f.twoFactor.Enable(user.ID, &EnableTwoFactorInput{Code: code(step)})
+	require.NoError(t, err)
+
+	client := ClientInfo{IP: "203.0.113.7"}
+	fail := func() {
+		t.Helper()
+		_, err := f.service.Login(&LoginInput{Username: "oka@example.com", Password: "wrong"}, client)
+		var locked *LockedError
+		require.Error(t, err)
+		require.False(t, errors.As(err, &locked))
+	}
+	for i := 0; i < accountFailureThreshold-1; i++ {
+		fail()
+	}
+
+	resp, err := f.service.Login(&LoginInput{Username: "oka@example.com", Password: "correct-horse"}, client)
+	require.NoError(t, err)
+	_, err = f.service.VerifyTwoFactor(&VerifyTwoFactorInput{ChallengeToken: resp.ChallengeToken, Code: code(step + 1)}, client)
+	require.NoError(t, err)
+
+	var success models.LoginAttempt
+	require.NoError(t, f.db.First(&success, "success = ?", true).Error)
+	assert.Equal(t, "oka@example.com", success.Identifier)
+
+	// The failures before the login no longer count
+	for i := 0; i < accountFailureThreshold-1; i++ {
+		fail()
+	}
+}
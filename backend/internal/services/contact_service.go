@@ -46,8 +46,10 @@ func (s *ContactService) CreateContact(userID uuid.UUID, input *CreateContactInp
 	return contact, nil
 }
 
-func (s *ContactService) UpdateContact(contactID uuid.UUID, input *UpdateContactInput) (*models.Contact, error) {
-	contact, err := s.contactRepo.FindByID(contactID)
+// UpdateContact updates a contact owned by userID; other users' contacts are
+// reported as repository.ErrNotFound
+func (s *ContactService) UpdateContact(userID, contactID uuid.UUID, input *UpdateContactInput) (*models.Contact, error) {
+	contact, err := s.contactRepo.FindByIDForUser(contactID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -67,6 +69,6 @@ func (s *ContactService) UpdateContact(contactID uuid.UUID, input *UpdateContact
 	return contact, nil
 }
 
-func (s *ContactService) DeleteContact(contactID uuid.UUID) error {
-	return s.contactRepo.Delete(contactID)
+func (s *ContactService) DeleteContact(userID, contactID uuid.UUID) error {
+	return s.contactRepo.DeleteForUser(contactID, userID)
 }
@@ -0,0 +1,311 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/orderreport"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/subid"
+)
+
+var ErrInvalidReportPlatform = errors.New("platform must be shopee, tokopedia, lazada or tiktok_shop")
+
+// reportPlatforms are the marketplaces whose affiliate reports can be imported
+var reportPlatforms = map[string]bool{
+	"shopee":      true,
+	"tokopedia":   true,
+	"lazada":      true,
+	"tiktok_shop": true,
+}
+
+// importHistory is how many past imports are listed
+const importHistory = 50
+
+type ConversionService struct {
+	conversionRepo *repository.ConversionRepository
+	analyticsRepo  *repository.AnalyticsRepository
+	linkRepo       *repository.LinkRepository
+}
+
+func NewConversionService(
+	conversionRepo *repository.ConversionRepository,
+	analyticsRepo *repository.AnalyticsRepository,
+	linkRepo *repository.LinkRepository,
+) *ConversionService {
+	return &ConversionService{
+		conversionRepo: conversionRepo,
+		analyticsRepo:  analyticsRepo,
+		linkRepo:       linkRepo,
+	}
+}
+
+// linkMatcher matches report orders to the links of one creator on one platform
+type linkMatcher struct {
+	byID      map[uuid.UUID]bool
+	byProduct map[string]uuid.UUID
+}
+
+func newLinkMatcher(links []models.Link, platform string) linkMatcher {
+	m := linkMatcher{byID: make(map[uuid.UUID]bool), byProduct: make(map[string]uuid.UUID)}
+	for _, link := range links {
+		m.byID[link.ID] = true
+		if link.Platform != platform {
+			continue
+		}
+		// Links are sorted by position, so the first link of a product wins
+		if id := orderreport.ProductID(platform, link.URL); id != "" {
+			if _, ok := m.byProduct[id]; !ok {
+				m.byProduct[id] = link.ID
+			}
+		}
+	}
+	return m
+}
+
+// match returns the link an order came from and the traffic source if its
+// sub-IDs were tagged (see subid), or else the link of its product
+func (m linkMatcher) match(order orderreport.Order) (*uuid.UUID, string) {
+	for _, tag := range subid.Parse(order.SubID) {
+		if m.byID[tag.LinkID] {
+			return &tag.LinkID, tag.Source
+		}
+	}
+	if id, ok := m.byProduct[order.ItemID]; ok && order.ItemID != "" {
+		return &id, ""
+	}
+	return nil, ""
+}
+
+// Import reads an affiliate report of platform and stores its orders as
+// conversions of userID, matched to the creator's links
+func (s *ConversionService) Import(userID uuid.UUID, platform, filename string, file io.Reader) (*models.ConversionImport, error) {
+	if !reportPlatforms[platform] {
+		return nil, ErrInvalidReportPlatform
+	}
+
+	report, err := orderreport.Parse(file, filename)
+	if err != nil {
+		return nil, err
+	}
+
+	links, err := s.linkRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	matcher := newLinkMatcher(links, platform)
+
+	imp := &models.ConversionImport{
+		UserID:   userID,
+		Platform: platform,
+		Filename: filename,
+		RowCount: report.Rows,
+		Skipped:  report.Skipped,
+	}
+	conversions := make([]models.Conversion, 0, len(report.Orders))
+	for _, order := range report.Orders {
+		linkID, source := matcher.match(order)
+		if linkID != nil {
+			imp.Matched++
+		}
+		conversions = append(conversions, models.Conversion{
+			UserID:      userID,
+			LinkID:      linkID,
+			Platform:    platform,
+			OrderID:     truncate(order.OrderID, 100),
+			ItemID:      truncate(order.ItemID, 100),
+			ItemName:    truncate(order.ItemName, 255),
+			SubID:       truncate(order.SubID, 100),
+			Source:      source,
+			Status:      order.Status,
+			OrderAmount: order.Amount,
+			Commission:  order.Commission,
+			OrderedAt:   order.OrderedAt.UTC(),
+		})
+	}
+
+	if err := s.conversionRepo.SaveImport(imp, conversions); err != nil {
+		return nil, err
+	}
+	return imp, nil
+}
+
+// GetImports returns the latest imports of userID
+func (s *ConversionService) GetImports(userID uuid.UUID) ([]models.ConversionImport, error) {
+	return s.conversionRepo.FindImportsByUserID(userID, importHistory)
+}
+
+// CommissionComparison compares the estimated and reported commission of a
+// link, platform or period. Actual counts completed orders; pending orders may
+// still be cancelled and are reported separately.
+type CommissionComparison struct {
+	Key       string  `json:"key"`
+	Label     string  `json:"label,omitempty"`
+	Clicks    int64   `json:"clicks"`
+	Orders    int64   `json:"orders"` // Completed and pending
+	Estimated float64 `json:"estimated_commission"`
+	Actual    float64 `json:"actual_commission"`
+	Pending   float64 `json:"pending_commission"`
+}
+
+// CommissionReport is the actual versus estimated commission in a period
+type CommissionReport struct {
+	Total      CommissionComparison   `json:"total"`
+	ByLink     []CommissionComparison `json:"by_link"`
+	ByPlatform []CommissionComparison `json:"by_platform"`
+	BySource   []CommissionComparison `json:"by_source"` // Orders without tagged sub-IDs count under ""
+	ByPeriod   []CommissionComparison `json:"by_period"`
+	Unmatched  CommissionComparison   `json:"unmatched"` // Conversions no link was matched to
+}
+
+// commissionGroups accumulates comparisons by key, remembering the order keys were first seen
+type commissionGroups struct {
+	rows map[string]*CommissionComparison
+	keys []string
+}
+
+func (g *commissionGroups) get(key string) *CommissionComparison {
+	if g.rows == nil {
+		g.rows = make(map[string]*CommissionComparison)
+	}
+	row, ok := g.rows[key]
+	if !ok {
+		row = &CommissionComparison{Key: key}
+		g.rows[key] = row
+		g.keys = append(g.keys, key)
+	}
+	return row
+}
+
+func (g *commissionGroups) list() []CommissionComparison {
+	list := make([]CommissionComparison, 0, len(g.keys))
+	for _, key := range g.keys {
+		list = append(list, *g.rows[key])
+	}
+	return list
+}
+
+// GetCommissionReport compares the commission estimated from clicks with the
+// commission of imported conversions in [from, to), per link, platform, source
+// and period (timeGroup is daily, weekly or monthly). Clicks count on the day they
+// happened and conversions on the day they were ordered.
+func (s *ConversionService) GetCommissionReport(userID uuid.UUID, timeGroup string, from, to time.Time) (*CommissionReport, error) {
+	estimates, err := s.analyticsRepo.GetEstimatedCommission(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	totals, err := s.conversionRepo.GetConversionTotals(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	links, err := s.linkRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CommissionReport{}
+	var byLink, byPlatform, bySource, byPeriod commissionGroups
+
+	for _, e := range estimates {
+		for _, row := range []*CommissionComparison{
+			&report.Total,
+			byLink.get(e.LinkID.String()),
+			byPlatform.get(e.Platform),
+			bySource.get(subid.SourceValue(e.Source)), // as tagged sub-IDs report it
+			byPeriod.get(periodLabel(e.Day, timeGroup)),
+		} {
+			row.Clicks += e.Clicks
+			row.Estimated += e.Commission
+		}
+	}
+
+	for _, t := range totals {
+		rows := []*CommissionComparison{
+			&report.Total,
+			byPlatform.get(t.Platform),
+			bySource.get(t.Source),
+			byPeriod.get(periodLabel(t.Day, timeGroup)),
+		}
+		if t.LinkID != nil {
+			rows = append(rows, byLink.get(t.LinkID.String()))
+		} else {
+			rows = append(rows, &report.Unmatched)
+		}
+
+		for _, row := range rows {
+			switch t.Status {
+			case models.ConversionCompleted:
+				row.Orders += t.Orders
+				row.Actual += t.Commission
+			case models.ConversionPending:
+				row.Orders += t.Orders
+				row.Pending += t.Commission
+			}
+		}
+	}
+
+	titles := make(map[string]string, len(links))
+	for _, link := range links {
+		titles[link.ID.String()] = link.Title
+	}
+	report.ByLink = byLink.list()
+	for i := range report.ByLink {
+		report.ByLink[i].Label = titles[report.ByLink[i].Key]
+	}
+	sort.SliceStable(report.ByLink, func(i, j int) bool {
+		return report.ByLink[i].Actual+report.ByLink[i].Pending > report.ByLink[j].Actual+report.ByLink[j].Pending
+	})
+
+	report.ByPlatform = byPlatform.list()
+	sort.Slice(report.ByPlatform, func(i, j int) bool {
+		return report.ByPlatform[i].Key < report.ByPlatform[j].Key
+	})
+
+	report.BySource = bySource.list()
+	sort.Slice(report.BySource, func(i, j int) bool {
+		return report.BySource[i].Key < report.BySource[j].Key
+	})
+
+	report.ByPeriod = byPeriod.list()
+	sort.Slice(report.ByPeriod, func(i, j int) bool {
+		return report.ByPeriod[i].Key < report.ByPeriod[j].Key
+	})
+
+	return report, nil
+}
+
+// periodLabel returns the label of the week ("2024-05", ISO) or month
+// ("2024-01") containing a YYYY-MM-DD day, matching the timeline chart
+func periodLabel(day, timeGroup string) string {
+	t, err := time.Parse("2006-01-02", day)
+	if err != nil {
+		return day
+	}
+	switch timeGroup {
+	case "weekly":
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-%02d", year, week)
+	case "monthly":
+		return t.Format("2006-01")
+	default:
+		return day
+	}
+}
+
+// truncate shortens s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
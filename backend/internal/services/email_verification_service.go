@@ -0,0 +1,188 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/mailer"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+)
+
+const emailVerificationTokenTTL = 24 * time.Hour
+
+var (
+	ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+	ErrEmailAlreadyVerified     = errors.New("email already verified")
+	ErrEmailTaken               = errors.New("email already registered")
+)
+
+type EmailVerificationService struct {
+	userRepo    *repository.UserRepository
+	tokenRepo   *repository.UserTokenRepository
+	mailer      mailer.Mailer
+	frontendURL string // Base URL for links in emails
+}
+
+func NewEmailVerificationService(
+	userRepo *repository.UserRepository,
+	tokenRepo *repository.UserTokenRepository,
+	mail mailer.Mailer,
+	frontendURL string,
+) *EmailVerificationService {
+	return &EmailVerificationService{
+		userRepo:    userRepo,
+		tokenRepo:   tokenRepo,
+		mailer:      mail,
+		frontendURL: frontendURL,
+	}
+}
+
+type VerifyEmailInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// SendVerification emails a confirmation link for the user's current address.
+// Links sent earlier stop working.
+func (s *EmailVerificationService) SendVerification(user *models.User) error {
+	if err := s.tokenRepo.InvalidateByUserID(user.ID, models.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	rawToken, err := generateRandomToken()
+	if err != nil {
+		return errors.New("failed to generate verification token")
+	}
+
+	token := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeEmailVerification,
+		TokenHash: hashToken(rawToken),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(emailVerificationTokenTTL),
+	}
+	if err := s.tokenRepo.Create(token); err != nil {
+		return errors.New("failed to store verification token")
+	}
+
+	verifyURL := s.frontendURL + "/verify-email?token=" + rawToken
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Verifikasi email OneDash",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nKonfirmasi bahwa %s adalah email kamu dengan membuka link berikut (berlaku 24 jam):\n\n%s\n\n"+
+				"Jika kamu tidak membuat akun OneDash, abaikan email ini.\n",
+			user.DisplayName, user.Email, verifyURL,
+		),
+	})
+}
+
+// ResendVerification sends a new link to a user whose email is still unverified
+func (s *EmailVerificationService) ResendVerification(userID uuid.UUID) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.HasVerifiedEmail() {
+		return ErrEmailAlreadyVerified
+	}
+	return s.SendVerification(user)
+}
+
+// RequestEmailChange emails a confirmation link to newEmail and tells the current address
+// about the request. The account keeps its current email until the link is opened;
+// change links sent earlier stop working.
+func (s *EmailVerificationService) RequestEmailChange(user *models.User, newEmail string) error {
+	if err := s.tokenRepo.InvalidateByUserID(user.ID, models.TokenPurposeEmailChange); err != nil {
+		return err
+	}
+
+	rawToken, err := generateRandomToken()
+	if err != nil {
+		return errors.New("failed to generate verification token")
+	}
+
+	token := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposeEmailChange,
+		TokenHash: hashToken(rawToken),
+		Email:     newEmail,
+		ExpiresAt: time.Now().Add(emailVerificationTokenTTL),
+	}
+	if err := s.tokenRepo.Create(token); err != nil {
+		return errors.New("failed to store verification token")
+	}
+
+	verifyURL := s.frontendURL + "/verify-email?token=" + rawToken
+	confirmErr := s.mailer.Send(mailer.Message{
+		To:      newEmail,
+		Subject: "Konfirmasi email baru OneDash",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nKonfirmasi bahwa %s adalah email baru akun OneDash kamu dengan membuka link berikut (berlaku 24 jam):\n\n%s\n\n"+
+				"Jika kamu tidak meminta perubahan ini, abaikan email ini.\n",
+			user.DisplayName, newEmail, verifyURL,
+		),
+	})
+	noticeErr := s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Permintaan ganti email OneDash",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nAda permintaan untuk mengganti email akun OneDash kamu menjadi %s. "+
+				"Email kamu tetap %s sampai alamat baru dikonfirmasi.\n\n"+
+				"Jika kamu tidak meminta perubahan ini, segera ganti password kamu.\n",
+			user.DisplayName, newEmail, user.Email,
+		),
+	})
+	return errors.Join(confirmErr, noticeErr)
+}
+
+// VerifyEmail confirms the address the token was issued for. A token of an email
+// change replaces the account's email with the confirmed address.
+func (s *EmailVerificationService) VerifyEmail(input *VerifyEmailInput) (*models.User, error) {
+	hash := hashToken(input.Token)
+	token, err := s.tokenRepo.FindValid(hash, models.TokenPurposeEmailVerification)
+	if err != nil {
+		token, err = s.tokenRepo.FindValid(hash, models.TokenPurposeEmailChange)
+	}
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	switch token.Purpose {
+	case models.TokenPurposeEmailChange:
+		// Another account may have taken the address since the link was sent
+		if token.Email != user.Email && s.userRepo.ExistsByEmail(token.Email) {
+			return nil, ErrEmailTaken
+		}
+	default:
+		// The email changed after this link was sent
+		if user.Email != token.Email {
+			return nil, ErrInvalidVerificationToken
+		}
+	}
+
+	used, err := s.tokenRepo.MarkUsed(token.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !used {
+		return nil, ErrInvalidVerificationToken
+	}
+
+	now := time.Now()
+	user.Email = token.Email
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
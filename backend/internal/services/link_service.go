@@ -121,8 +121,10 @@ func (s *LinkService) CreateLink(userID uuid.UUID, input *CreateLinkInput) (*mod
 	return link, nil
 }
 
-func (s *LinkService) UpdateLink(linkID uuid.UUID, input *UpdateLinkInput) (*models.Link, error) {
-	link, err := s.linkRepo.FindByID(linkID)
+// UpdateLink updates a link owned by userID; other users' links are reported as
+// repository.ErrNotFound
+func (s *LinkService) UpdateLink(userID, linkID uuid.UUID, input *UpdateLinkInput) (*models.Link, error) {
+	link, err := s.linkRepo.FindByIDForUser(linkID, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -171,8 +173,8 @@ func (s *LinkService) UpdateLink(linkID uuid.UUID, input *UpdateLinkInput) (*mod
 	return link, nil
 }
 
-func (s *LinkService) DeleteLink(linkID uuid.UUID) error {
-	return s.linkRepo.Delete(linkID)
+func (s *LinkService) DeleteLink(userID, linkID uuid.UUID) error {
+	return s.linkRepo.DeleteForUser(linkID, userID)
 }
 
 func (s *LinkService) ReorderLinks(userID uuid.UUID, input *ReorderLinksInput) error {
@@ -183,5 +185,5 @@ func (s *LinkService) ReorderLinks(userID uuid.UUID, input *ReorderLinksInput) e
 			Position: l.Position,
 		}
 	}
-	return s.linkRepo.UpdatePositions(links)
+	return s.linkRepo.UpdatePositions(userID, links)
 }
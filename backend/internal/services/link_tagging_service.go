@@ -0,0 +1,126 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"regexp"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/subid"
+	"github.com/onedash/backend/internal/ttlcache"
+)
+
+var (
+	ErrUnsupportedTaggingPlatform = errors.New("platform must be shopee, tokopedia, lazada or tiktok_shop")
+	ErrInvalidAffiliateID         = errors.New("affiliate_id may only contain letters, digits, '_' and '-' (at most 100)")
+)
+
+// affiliateIDPattern is what affiliate IDs of the supported marketplaces look like
+var affiliateIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{0,100}$`)
+
+// LinkTaggingService tags outbound marketplace URLs with sub-IDs naming the
+// link and traffic source, for the marketplaces each creator enabled
+type LinkTaggingService struct {
+	taggingRepo *repository.LinkTaggingRepository
+	settings    *ttlcache.Cache[uuid.UUID, map[string]models.LinkTagging]
+}
+
+func NewLinkTaggingService(taggingRepo *repository.LinkTaggingRepository) *LinkTaggingService {
+	return &LinkTaggingService{
+		taggingRepo: taggingRepo,
+		settings:    ttlcache.New[uuid.UUID, map[string]models.LinkTagging](policyCacheTTL),
+	}
+}
+
+type LinkTaggingInput struct {
+	Platform    string `json:"platform"`
+	Enabled     bool   `json:"enabled"`
+	AffiliateID string `json:"affiliate_id"`
+}
+
+type UpdateLinkTaggingInput struct {
+	Platforms []LinkTaggingInput `json:"platforms"`
+}
+
+// GetSettings returns the tagging settings of every supported marketplace;
+// marketplaces the creator never configured are disabled
+func (s *LinkTaggingService) GetSettings(userID uuid.UUID) ([]models.LinkTagging, error) {
+	saved, err := s.taggingRepo.FindByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	byPlatform := make(map[string]models.LinkTagging, len(saved))
+	for _, setting := range saved {
+		byPlatform[setting.Platform] = setting
+	}
+
+	settings := make([]models.LinkTagging, 0, len(subid.Platforms()))
+	for _, platform := range subid.Platforms() {
+		setting, ok := byPlatform[platform]
+		if !ok {
+			setting = models.LinkTagging{UserID: userID, Platform: platform}
+		}
+		settings = append(settings, setting)
+	}
+	return settings, nil
+}
+
+// UpdateSettings replaces the settings of the marketplaces listed in input;
+// the others keep theirs
+func (s *LinkTaggingService) UpdateSettings(userID uuid.UUID, input *UpdateLinkTaggingInput) ([]models.LinkTagging, error) {
+	updates := make([]models.LinkTagging, 0, len(input.Platforms))
+	for _, p := range input.Platforms {
+		if !subid.Supported(p.Platform) {
+			return nil, ErrUnsupportedTaggingPlatform
+		}
+		if !affiliateIDPattern.MatchString(p.AffiliateID) {
+			return nil, ErrInvalidAffiliateID
+		}
+		updates = append(updates, models.LinkTagging{
+			UserID:      userID,
+			Platform:    p.Platform,
+			Enabled:     p.Enabled,
+			AffiliateID: p.AffiliateID,
+		})
+	}
+
+	if err := s.taggingRepo.Save(updates); err != nil {
+		return nil, err
+	}
+	s.settings.Delete(userID)
+	return s.GetSettings(userID)
+}
+
+// TagURL returns the outbound URL of link for a click from source: the link URL
+// with the marketplace's sub-ID parameters if its owner enabled tagging, or the
+// link URL unchanged
+func (s *LinkTaggingService) TagURL(link *models.Link, source string) string {
+	setting, ok := s.enabled(link.UserID)[link.Platform]
+	if !ok || !setting.Enabled {
+		return link.URL
+	}
+	return subid.Rewrite(link.Platform, link.URL, setting.AffiliateID, subid.Tag{LinkID: link.ID, Source: source})
+}
+
+// enabled returns the creator's settings by marketplace, cached in memory so
+// redirects do not read them for every click. If they cannot be read no URL is tagged.
+func (s *LinkTaggingService) enabled(userID uuid.UUID) map[string]models.LinkTagging {
+	if settings, ok := s.settings.Get(userID); ok {
+		return settings
+	}
+
+	saved, err := s.taggingRepo.FindByUserID(userID)
+	if err != nil {
+		log.Printf("⚠️  Failed to load link tagging settings of %s: %v", userID, err)
+		return nil
+	}
+	settings := make(map[string]models.LinkTagging, len(saved))
+	for _, setting := range saved {
+		settings[setting.Platform] = setting
+	}
+	s.settings.Set(userID, settings, policyCacheTTL)
+	return settings
+}
@@ -0,0 +1,145 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+)
+
+// Lockout policy. After the threshold is reached every further failure doubles the
+// wait, starting at lockoutBase and capped at lockoutMax.
+const (
+	accountFailureThreshold = 5
+	accountFailureWindow    = 24 * time.Hour
+	ipFailureThreshold      = 20
+	ipFailureWindow         = time.Hour
+	lockoutBase             = time.Minute
+	lockoutMax              = time.Hour
+)
+
+// Reasons recorded on failed attempts
+const (
+	LoginFailureUnknownUser          = "unknown_user"
+	LoginFailureInvalidPassword      = "invalid_password"
+	LoginFailureInvalidTwoFactorCode = "invalid_2fa_code"
+)
+
+var errLockoutCheckFailed = errors.New("failed to check login attempts")
+
+// LockedError is returned while an account or IP address is locked out
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, try again in %s", e.RetryAfter.Round(time.Second))
+}
+
+type LoginAttemptService struct {
+	loginAttemptRepo *repository.LoginAttemptRepository
+}
+
+func NewLoginAttemptService(loginAttemptRepo *repository.LoginAttemptRepository) *LoginAttemptService {
+	return &LoginAttemptService{loginAttemptRepo: loginAttemptRepo}
+}
+
+// CheckIP returns a *LockedError if the IP address is temporarily blocked
+func (s *LoginAttemptService) CheckIP(ip string) error {
+	stats, err := s.loginAttemptRepo.GetIPFailureStats(ip, time.Now().Add(-ipFailureWindow))
+	if err != nil {
+		return errLockoutCheckFailed
+	}
+	return lockoutFor(stats, ipFailureThreshold)
+}
+
+// CheckIdentifier returns a *LockedError if logins with a username or email that
+// belongs to no account are temporarily locked. Failures count per identifier as
+// typed, with the same threshold as accounts, so the lockout does not tell which
+// usernames exist. A successful login with the identifier resets its failure count.
+func (s *LoginAttemptService) CheckIdentifier(identifier string) error {
+	since := time.Now().Add(-accountFailureWindow)
+	lastSuccess, err := s.loginAttemptRepo.GetLastIdentifierSuccessAt(identifier)
+	if err != nil {
+		return errLockoutCheckFailed
+	}
+	if lastSuccess.After(since) {
+		since = lastSuccess
+	}
+
+	stats, err := s.loginAttemptRepo.GetIdentifierFailureStats(identifier, since)
+	if err != nil {
+		return errLockoutCheckFailed
+	}
+	return lockoutFor(stats, accountFailureThreshold)
+}
+
+// CheckAccount returns a *LockedError if the account is temporarily locked.
+// Wrong passwords and 2FA codes count per account, whether the login named it by
+// username or email. A successful login resets the account's failure count.
+func (s *LoginAttemptService) CheckAccount(userID uuid.UUID) error {
+	since := time.Now().Add(-accountFailureWindow)
+	lastSuccess, err := s.loginAttemptRepo.GetLastSuccessAt(userID)
+	if err != nil {
+		return errLockoutCheckFailed
+	}
+	if lastSuccess.After(since) {
+		since = lastSuccess
+	}
+
+	stats, err := s.loginAttemptRepo.GetUserFailureStats(userID, since)
+	if err != nil {
+		return errLockoutCheckFailed
+	}
+	return lockoutFor(stats, accountFailureThreshold)
+}
+
+func (s *LoginAttemptService) RecordFailure(userID *uuid.UUID, identifier, reason string, client ClientInfo) error {
+	return s.loginAttemptRepo.Create(&models.LoginAttempt{
+		UserID:     userID,
+		Identifier: identifier,
+		IPAddress:  client.IP,
+		UserAgent:  client.UserAgent,
+		Success:    false,
+		Reason:     reason,
+	})
+}
+
+func (s *LoginAttemptService) RecordSuccess(userID uuid.UUID, identifier string, client ClientInfo) error {
+	return s.loginAttemptRepo.Create(&models.LoginAttempt{
+		UserID:     &userID,
+		Identifier: identifier,
+		IPAddress:  client.IP,
+		UserAgent:  client.UserAgent,
+		Success:    true,
+	})
+}
+
+// GetFailedAttempts lists recent failed logins on the user's account
+func (s *LoginAttemptService) GetFailedAttempts(userID uuid.UUID, limit int) ([]models.LoginAttempt, error) {
+	return s.loginAttemptRepo.FindFailedByUserID(userID, limit)
+}
+
+// lockoutFor applies exponential backoff once failures reach the threshold
+func lockoutFor(stats *repository.FailureStats, threshold int64) error {
+	if stats.Count < threshold {
+		return nil
+	}
+
+	lockout := lockoutMax
+	if exp := stats.Count - threshold; exp < 16 {
+		if d := lockoutBase << exp; d < lockoutMax {
+			lockout = d
+		}
+	}
+
+	retryAfter := time.Until(stats.LastAt.Add(lockout))
+	if retryAfter <= 0 {
+		return nil
+	}
+	return &LockedError{RetryAfter: retryAfter}
+}
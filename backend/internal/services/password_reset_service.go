@@ -0,0 +1,168 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/onedash/backend/internal/mailer"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/ratelimit"
+	"github.com/onedash/backend/internal/repository"
+)
+
+const passwordResetTokenTTL = time.Hour
+
+// Reset requests allowed per client IP and per email address in each window
+const (
+	resetRequestsPerIP    = 10
+	resetRequestsPerEmail = 3
+	resetRequestWindow    = time.Hour
+)
+
+var (
+	ErrInvalidResetToken    = errors.New("invalid or expired reset token")
+	ErrTooManyResetRequests = errors.New("too many password reset requests, try again later")
+)
+
+type PasswordResetService struct {
+	userRepo       *repository.UserRepository
+	tokenRepo      *repository.UserTokenRepository
+	sessionService *SessionService
+	mailer         mailer.Mailer
+	frontendURL    string // Base URL for links in emails
+	byIP           *ratelimit.Limiter
+	byEmail        *ratelimit.Limiter
+	pending        sync.WaitGroup // Reset emails being sent
+}
+
+func NewPasswordResetService(
+	userRepo *repository.UserRepository,
+	tokenRepo *repository.UserTokenRepository,
+	sessionService *SessionService,
+	mail mailer.Mailer,
+	frontendURL string,
+) *PasswordResetService {
+	return &PasswordResetService{
+		userRepo:       userRepo,
+		tokenRepo:      tokenRepo,
+		sessionService: sessionService,
+		mailer:         mail,
+		frontendURL:    frontendURL,
+		byIP:           ratelimit.New(resetRequestsPerIP, resetRequestWindow),
+		byEmail:        ratelimit.New(resetRequestsPerEmail, resetRequestWindow),
+	}
+}
+
+type RequestPasswordResetInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResetPasswordInput struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,min=6"`
+}
+
+// RequestReset emails a reset link if the address belongs to an account.
+// It never reports whether the email exists, so it cannot be used to enumerate
+// accounts: the account is looked up and mailed in the background, so the call
+// takes as long either way, and failures are logged, not returned. Requests are
+// limited per client IP and per email address, registered or not.
+func (s *PasswordResetService) RequestReset(input *RequestPasswordResetInput, client ClientInfo) error {
+	email := strings.TrimSpace(input.Email)
+	if !s.byIP.Allow(client.IP) || !s.byEmail.Allow(strings.ToLower(email)) {
+		return ErrTooManyResetRequests
+	}
+
+	s.pending.Add(1)
+	go func() {
+		defer s.pending.Done()
+		user, err := s.userRepo.FindByEmail(email)
+		if err != nil {
+			return
+		}
+		if err := s.sendResetLink(user); err != nil {
+			log.Printf("⚠️  Failed to send password reset email to user %s: %v", user.ID, err)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the reset emails requested so far are sent
+func (s *PasswordResetService) Wait() {
+	s.pending.Wait()
+}
+
+// sendResetLink issues a new reset token for user and emails its link
+func (s *PasswordResetService) sendResetLink(user *models.User) error {
+	// Only the most recent link should work
+	if err := s.tokenRepo.InvalidateByUserID(user.ID, models.TokenPurposePasswordReset); err != nil {
+		return err
+	}
+
+	rawToken, err := generateRandomToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate reset token: %w", err)
+	}
+
+	token := &models.UserToken{
+		UserID:    user.ID,
+		Purpose:   models.TokenPurposePasswordReset,
+		TokenHash: hashToken(rawToken),
+		ExpiresAt: time.Now().Add(passwordResetTokenTTL),
+	}
+	if err := s.tokenRepo.Create(token); err != nil {
+		return fmt.Errorf("failed to store reset token: %w", err)
+	}
+
+	resetURL := s.frontendURL + "/reset-password?token=" + rawToken
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset password OneDash",
+		Body: fmt.Sprintf(
+			"Halo %s,\n\nKami menerima permintaan untuk mereset password akun OneDash kamu.\n"+
+				"Klik link berikut untuk membuat password baru (berlaku 1 jam):\n\n%s\n\n"+
+				"Jika kamu tidak meminta reset password, abaikan email ini.\n",
+			user.DisplayName, resetURL,
+		),
+	})
+}
+
+// ResetPassword sets a new password using a reset token and logs out every session
+func (s *PasswordResetService) ResetPassword(input *ResetPasswordInput) error {
+	token, err := s.tokenRepo.FindValid(hashToken(input.Token), models.TokenPurposePasswordReset)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	// Consume before changing anything so the token cannot be replayed concurrently
+	used, err := s.tokenRepo.MarkUsed(token.ID)
+	if err != nil {
+		return err
+	}
+	if !used {
+		return ErrInvalidResetToken
+	}
+
+	user, err := s.userRepo.FindByID(token.UserID)
+	if err != nil {
+		return ErrInvalidResetToken
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return errors.New("failed to hash password")
+	}
+
+	user.PasswordHash = string(hashedPassword)
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	return s.sessionService.RevokeAllSessions(user.ID)
+}
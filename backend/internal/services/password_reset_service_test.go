@@ -0,0 +1,96 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/onedash/backend/internal/mailer"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+// brokenMailer fails every send
+type brokenMailer struct{}
+
+func (brokenMailer) Send(mailer.Message) error {
+	return errors.New("smtp: connection refused")
+}
+
+var resetLink = regexp.MustCompile(`https://app\.example\.com/reset-password\?token=\S+`)
+
+func newPasswordResetService(t *testing.T, mail mailer.Mailer) (*PasswordResetService, *repository.UserRepository, *models.User) {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	user := &models.User{Email: "kiki@example.com", Username: "kiki", PasswordHash: "hash"}
+	require.NoError(t, userRepo.Create(user))
+
+	sessions := NewSessionService(repository.NewSessionRepository(db), repository.NewRefreshTokenRepository(db))
+	service := NewPasswordResetService(userRepo, repository.NewUserTokenRepository(db), sessions, mail, "https://app.example.com")
+	return service, userRepo, user
+}
+
+func TestPasswordResetTokensAreSingleUse(t *testing.T) {
+	mail := mailer.NewLogMailer("")
+	service, userRepo, user := newPasswordResetService(t, mail)
+
+	require.NoError(t, service.RequestReset(&RequestPasswordResetInput{Email: " kiki@example.com "}, ClientInfo{}))
+	service.Wait()
+	sent := mail.Sent()
+	require.Len(t, sent, 1)
+	assert.Equal(t, user.Email, sent[0].To)
+	link, err := url.Parse(resetLink.FindString(sent[0].Body))
+	require.NoError(t, err)
+	token := link.Query().Get("token")
+	require.NotEmpty(t, token)
+
+	require.NoError(t, service.ResetPassword(&ResetPasswordInput{Token: token, Password: "new-secret"}))
+	stored, err := userRepo.FindByID(user.ID)
+	require.NoError(t, err)
+	assert.NoError(t, bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("new-secret")))
+
+	err = service.ResetPassword(&ResetPasswordInput{Token: token, Password: "other-secret"})
+	assert.ErrorIs(t, err, ErrInvalidResetToken)
+}
+
+func TestPasswordResetDoesNotRevealAccountsWhenMailFails(t *testing.T) {
+	service, _, _ := newPasswordResetService(t, brokenMailer{})
+
+	// Registered or not, the caller sees the same result
+	assert.NoError(t, service.RequestReset(&RequestPasswordResetInput{Email: "kiki@example.com"}, ClientInfo{}))
+	assert.NoError(t, service.RequestReset(&RequestPasswordResetInput{Email: "nobody@example.com"}, ClientInfo{}))
+	service.Wait()
+}
+
+func TestPasswordResetRequestsAreLimited(t *testing.T) {
+	mail := mailer.NewLogMailer("")
+	service, _, _ := newPasswordResetService(t, mail)
+
+	// Per email, registered or not, from any IP
+	for _, email := range []string{"kiki@example.com", "nobody@example.com"} {
+		for i := 0; i < resetRequestsPerEmail; i++ {
+			client := ClientInfo{IP: fmt.Sprintf("203.0.113.%d", i)}
+			require.NoError(t, service.RequestReset(&RequestPasswordResetInput{Email: email}, client), "%s request %d", email, i+1)
+		}
+		err := service.RequestReset(&RequestPasswordResetInput{Email: strings.ToUpper(email)}, ClientInfo{IP: "203.0.113.99"})
+		assert.ErrorIs(t, err, ErrTooManyResetRequests, email)
+	}
+	service.Wait()
+	assert.Len(t, mail.Sent(), resetRequestsPerEmail)
+
+	// Per IP, whatever the email
+	client := ClientInfo{IP: "198.51.100.1"}
+	for i := 0; i < resetRequestsPerIP; i++ {
+		require.NoError(t, service.RequestReset(&RequestPasswordResetInput{Email: fmt.Sprintf("user%d@example.com", i)}, client))
+	}
+	err := service.RequestReset(&RequestPasswordResetInput{Email: "other@example.com"}, client)
+	assert.ErrorIs(t, err, ErrTooManyResetRequests)
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
 	"os"
 
 	"github.com/google/uuid"
@@ -13,11 +14,21 @@ import (
 )
 
 type ProfileService struct {
-	userRepo *repository.UserRepository
+	userRepo                 *repository.UserRepository
+	sessionService           *SessionService
+	emailVerificationService *EmailVerificationService
 }
 
-func NewProfileService(userRepo *repository.UserRepository) *ProfileService {
-	return &ProfileService{userRepo: userRepo}
+func NewProfileService(
+	userRepo *repository.UserRepository,
+	sessionService *SessionService,
+	emailVerificationService *EmailVerificationService,
+) *ProfileService {
+	return &ProfileService{
+		userRepo:                 userRepo,
+		sessionService:           sessionService,
+		emailVerificationService: emailVerificationService,
+	}
 }
 
 type UpdateProfileInput struct {
@@ -38,22 +49,31 @@ func (s *ProfileService) GetProfile(userID uuid.UUID) (*models.User, error) {
 	return s.userRepo.FindByID(userID)
 }
 
-func (s *ProfileService) UpdateProfile(userID uuid.UUID, input *UpdateProfileInput) (*models.User, error) {
+// UpdateProfile updates the user's profile. Changing the password or the email requires the current
+// password; a new email is only used once confirmed. Changing the password logs out every session except currentSessionID.
+func (s *ProfileService) UpdateProfile(userID, currentSessionID uuid.UUID, input *UpdateProfileInput) (*models.User, error) {
 	user, err := s.userRepo.FindByID(userID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Handle password change first (if both current and new password provided)
-	if input.CurrentPassword != "" && input.NewPassword != "" {
-		// Verify current password
+	passwordChanged := input.CurrentPassword != "" && input.NewPassword != ""
+	emailChanged := input.Email != "" && input.Email != user.Email
+
+	// Changing the password or the email requires the current password
+	if passwordChanged || emailChanged {
+		if input.CurrentPassword == "" {
+			return nil, errors.New("password saat ini diperlukan untuk mengganti email")
+		}
 		if err := bcrypt.CompareHashAndPassword(
 			[]byte(user.PasswordHash),
 			[]byte(input.CurrentPassword),
 		); err != nil {
 			return nil, errors.New("password saat ini salah")
 		}
+	}
 
+	if passwordChanged {
 		// Validate new password length
 		if len(input.NewPassword) < 6 {
 			return nil, errors.New("password baru minimal 6 karakter")
@@ -71,13 +91,9 @@ func (s *ProfileService) UpdateProfile(userID uuid.UUID, input *UpdateProfileInp
 		user.PasswordHash = string(hashedPassword)
 	}
 
-	// Check if email is being changed
-	if input.Email != "" && input.Email != user.Email {
-		// Check if new email already exists
-		if s.userRepo.ExistsByEmail(input.Email) {
-			return nil, fmt.Errorf("email '%s' sudah terdaftar", input.Email)
-		}
-		user.Email = input.Email
+	// The new email only replaces the current one once it is confirmed
+	if emailChanged && s.userRepo.ExistsByEmail(input.Email) {
+		return nil, fmt.Errorf("email '%s' sudah terdaftar", input.Email)
 	}
 
 	// Check if username is being changed
@@ -124,6 +140,18 @@ func (s *ProfileService) UpdateProfile(userID uuid.UUID, input *UpdateProfileInp
 		return nil, err
 	}
 
+	if emailChanged {
+		if err := s.emailVerificationService.RequestEmailChange(user, input.Email); err != nil {
+			log.Printf("⚠️  Failed to send email change confirmation for user %s: %v", user.ID, err)
+		}
+	}
+
+	if passwordChanged {
+		if err := s.sessionService.RevokeOtherSessions(userID, currentSessionID); err != nil {
+			return nil, errors.New("password diubah, tetapi gagal mengakhiri sesi lain")
+		}
+	}
+
 	return user, nil
 }
 
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"net/url"
+	"regexp"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/onedash/backend/internal/mailer"
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/testutil"
+)
+
+var verifyLink = regexp.MustCompile(`https://app\.example\.com/verify-email\?token=\S+`)
+
+func TestEmailChangeWaitsForConfirmation(t *testing.T) {
+	db := testutil.NewDB(t)
+	userRepo := repository.NewUserRepository(db)
+	hash, err := bcrypt.GenerateFromPassword([]byte("correct-horse"), bcrypt.MinCost)
+	require.NoError(t, err)
+	user := &models.User{Email: "lina@example.com", Username: "lina", PasswordHash: string(hash)}
+	require.NoError(t, userRepo.Create(user))
+
+	mail := mailer.NewLogMailer("")
+	verification := NewEmailVerificationService(userRepo, repository.NewUserTokenRepository(db), mail, "https://app.example.com")
+	sessions := NewSessionService(repository.NewSessionRepository(db), repository.NewRefreshTokenRepository(db))
+	service := NewProfileService(userRepo, sessions, verification)
+
+	for _, password := range []string{"", "wrong"} {
+		_, err := service.UpdateProfile(user.ID, uuid.Nil, &UpdateProfileInput{Email: "lina@new.example.com", CurrentPassword: password})
+		assert.Error(t, err, password)
+	}
+	assert.Empty(t, mail.Sent())
+
+	updated, err := service.UpdateProfile(user.ID, uuid.Nil, &UpdateProfileInput{Email: "lina@new.example.com", CurrentPassword: "correct-horse"})
+	require.NoError(t, err)
+	assert.Equal(t, "lina@example.com", updated.Email, "the new address is pending until confirmed")
+
+	sent := mail.Sent()
+	require.Len(t, sent, 2)
+	assert.Equal(t, "lina@new.example.com", sent[0].To)
+	assert.Equal(t, "lina@example.com", sent[1].To, "the current address is told about the change")
+	assert.NotRegexp(t, verifyLink, sent[1].Body)
+	link, err := url.Parse(verifyLink.FindString(sent[0].Body))
+	require.NoError(t, err)
+
+	verified, err := verification.VerifyEmail(&VerifyEmailInput{Token: link.Query().Get("token")})
+	require.NoError(t, err)
+	assert.Equal(t, "lina@new.example.com", verified.Email)
+	assert.True(t, verified.HasVerifiedEmail())
+
+	stored, err := userRepo.FindByID(user.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "lina@new.example.com", stored.Email)
+}
@@ -0,0 +1,125 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"log"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/repository"
+)
+
+// linkSignatureSize is the number of HMAC bytes kept in a redirect URL
+const linkSignatureSize = 16
+
+// LinkSigner issues and verifies the signed /go/:linkID redirect URLs, so only
+// links the server handed out recently record clicks. A URL carries its
+// expiry, which the signature covers, so copied or scraped URLs go stale.
+type LinkSigner struct {
+	key     []byte
+	baseURL string
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewLinkSigner derives the signing key from secret; baseURL is the public
+// address of the API and ttl how long issued URLs are accepted
+func NewLinkSigner(secret, baseURL string, ttl time.Duration) *LinkSigner {
+	// The secret may be shared with JWT signing, so derive a key for this purpose only
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("onedash link redirect v2"))
+	return &LinkSigner{key: mac.Sum(nil), baseURL: baseURL, ttl: ttl, now: time.Now}
+}
+
+// Sign returns the URL-safe signature of a link ID and the Unix time its URL expires
+func (s *LinkSigner) Sign(linkID uuid.UUID, expires int64) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(linkID.String() + "." + strconv.FormatInt(expires, 10)))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)[:linkSignatureSize])
+}
+
+// Verify reports whether sig was issued for linkID with the expiry exp (Unix
+// seconds, as in the URL) and has not expired
+func (s *LinkSigner) Verify(linkID uuid.UUID, exp, sig string) bool {
+	expires, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || s.now().Unix() >= expires {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil {
+		return false
+	}
+	want, _ := base64.RawURLEncoding.DecodeString(s.Sign(linkID, expires))
+	return hmac.Equal(got, want)
+}
+
+// RedirectURL returns the absolute redirect URL published for a link, valid for the signer's TTL
+func (s *LinkSigner) RedirectURL(linkID uuid.UUID) string {
+	expires := s.now().Add(s.ttl).Unix()
+	return s.baseURL + "/go/" + linkID.String() + "?exp=" + strconv.FormatInt(expires, 10) + "&sig=" + s.Sign(linkID, expires)
+}
+
+// RedirectService resolves redirect links and records the click server-side
+type RedirectService struct {
+	linkRepo         *repository.LinkRepository
+	userRepo         *repository.UserRepository
+	analyticsService *AnalyticsService
+	signer           *LinkSigner
+	tagging          *LinkTaggingService
+}
+
+func NewRedirectService(
+	linkRepo *repository.LinkRepository,
+	userRepo *repository.UserRepository,
+	analyticsService *AnalyticsService,
+	signer *LinkSigner,
+	tagging *LinkTaggingService,
+) *RedirectService {
+	return &RedirectService{
+		linkRepo:         linkRepo,
+		userRepo:         userRepo,
+		analyticsService: analyticsService,
+		signer:           signer,
+		tagging:          tagging,
+	}
+}
+
+// Resolve records the click and returns the marketplace URL, tagged with the link and
+// source if the creator enabled it. A visitor holding a stale or forged URL is still
+// sent on to the link, but only correctly signed, unexpired URLs record a click.
+// Inactive links, links of suspended creators and non-HTTP targets are reported
+// as repository.ErrNotFound.
+func (s *RedirectService) Resolve(linkID uuid.UUID, exp, sig string, ctx VisitContext) (string, error) {
+	link, err := s.linkRepo.FindByID(linkID)
+	if err != nil {
+		return "", err
+	}
+	if !link.IsActive || !isHTTPURL(link.URL) {
+		return "", repository.ErrNotFound
+	}
+
+	owner, err := s.userRepo.FindByID(link.UserID)
+	if err != nil || owner.IsSuspended() {
+		return "", repository.ErrNotFound
+	}
+
+	// Neither a stale URL nor a failed write may cost the creator the sale, so the
+	// visitor is redirected either way
+	if s.signer.Verify(linkID, exp, sig) {
+		if err := s.analyticsService.RecordClick(link, ctx); err != nil {
+			log.Printf("⚠️  Failed to record click on link %s: %v", link.ID, err)
+		}
+	}
+
+	return s.tagging.TagURL(link, ctx.Source), nil
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/onedash/backend/internal/iphash"
+	"github.com/onedash/backend/internal/repository"
+)
+
+// RetentionService erases visitor identifiers once they are no longer needed:
+// events older than the retention period are anonymized, and the IP hash salts
+// of past days are deleted so their hashes cannot be reversed.
+type RetentionService struct {
+	analyticsRepo *repository.AnalyticsRepository
+	saltRepo      *repository.VisitorSaltRepository
+	retention     time.Duration // zero keeps event identifiers
+}
+
+func NewRetentionService(
+	analyticsRepo *repository.AnalyticsRepository,
+	saltRepo *repository.VisitorSaltRepository,
+	retentionDays int,
+) *RetentionService {
+	return &RetentionService{
+		analyticsRepo: analyticsRepo,
+		saltRepo:      saltRepo,
+		retention:     time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
+type RetentionResult struct {
+	AnonymizedEvents int64 `json:"anonymized_events"`
+	DeletedSalts     int64 `json:"deleted_salts"`
+}
+
+// Run applies the retention policy as of now
+func (s *RetentionService) Run(now time.Time) (RetentionResult, error) {
+	var result RetentionResult
+
+	// Yesterday's salt is kept for instances whose clock is slightly behind
+	yesterday := now.UTC().AddDate(0, 0, -1).Format(iphash.DayLayout)
+	deleted, err := s.saltRepo.DeleteBefore(yesterday)
+	if err != nil {
+		return result, err
+	}
+	result.DeletedSalts = deleted
+
+	if s.retention > 0 {
+		anonymized, err := s.analyticsRepo.AnonymizeEventsBefore(now.Add(-s.retention))
+		if err != nil {
+			return result, err
+		}
+		result.AnonymizedEvents = anonymized
+	}
+	return result, nil
+}
+
+// Start runs the retention policy now and then every interval until ctx is done
+func (s *RetentionService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			result, err := s.Run(time.Now())
+			if err != nil {
+				log.Printf("⚠️  Retention job failed: %v", err)
+			} else if result.AnonymizedEvents > 0 || result.DeletedSalts > 0 {
+				log.Printf("🧹 Retention: anonymized %d events, deleted %d IP salts", result.AnonymizedEvents, result.DeletedSalts)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
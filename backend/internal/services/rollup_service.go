@@ -0,0 +1,94 @@
+package services
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/onedash/backend/internal/repository"
+)
+
+// rollupGrace is how long after midnight (UTC) a day stays open, so events
+// still queued for writing when the day ends are included in its rollup
+const rollupGrace = 15 * time.Minute
+
+// RollupService maintains the daily analytics rollups: every closed day after
+// the watermark is rolled up once, and reports read raw events only after it.
+type RollupService struct {
+	analyticsRepo *repository.AnalyticsRepository
+}
+
+func NewRollupService(analyticsRepo *repository.AnalyticsRepository) *RollupService {
+	return &RollupService{analyticsRepo: analyticsRepo}
+}
+
+// lastClosedDay returns the latest UTC day that can no longer receive events as of now
+func lastClosedDay(now time.Time) time.Time {
+	y, m, d := now.UTC().Add(-rollupGrace).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).AddDate(0, 0, -1)
+}
+
+// Run rolls up the closed days after the watermark and returns how many it rolled up
+func (s *RollupService) Run(now time.Time) (int, error) {
+	start, err := s.analyticsRepo.RolledThrough()
+	if err != nil {
+		return 0, err
+	}
+	if start.IsZero() {
+		start, err = s.analyticsRepo.FirstEventDay()
+		if err != nil || start.IsZero() {
+			return 0, err
+		}
+	} else {
+		start = start.AddDate(0, 0, 1)
+	}
+	return s.rollup(start, lastClosedDay(now))
+}
+
+// Backfill rebuilds the rollups of every closed day from the raw events.
+// Reports read raw events while it runs.
+func (s *RollupService) Backfill(now time.Time) (int, error) {
+	if err := s.analyticsRepo.ResetRollups(); err != nil {
+		return 0, err
+	}
+	first, err := s.analyticsRepo.FirstEventDay()
+	if err != nil || first.IsZero() {
+		return 0, err
+	}
+	return s.rollup(first, lastClosedDay(now))
+}
+
+// rollup rolls up the days from first through last in order, so the watermark
+// only moves past days that are complete
+func (s *RollupService) rollup(first, last time.Time) (int, error) {
+	days := 0
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		if err := s.analyticsRepo.RollupDay(day); err != nil {
+			return days, err
+		}
+		days++
+	}
+	return days, nil
+}
+
+// Start rolls up closed days now and then every interval until ctx is done
+func (s *RollupService) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			days, err := s.Run(time.Now())
+			if err != nil {
+				log.Printf("⚠️  Rollup job failed: %v", err)
+			} else if days > 0 {
+				log.Printf("📊 Rollups: added %d day(s)", days)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/useragent"
+)
+
+var ErrSessionNotFound = errors.New("session not found")
+
+// ClientInfo describes the device a request came from
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+}
+
+type SessionService struct {
+	sessionRepo      *repository.SessionRepository
+	refreshTokenRepo *repository.RefreshTokenRepository
+}
+
+func NewSessionService(sessionRepo *repository.SessionRepository, refreshTokenRepo *repository.RefreshTokenRepository) *SessionService {
+	return &SessionService{
+		sessionRepo:      sessionRepo,
+		refreshTokenRepo: refreshTokenRepo,
+	}
+}
+
+// CreateSession starts a new login session for the user
+func (s *SessionService) CreateSession(userID uuid.UUID, client ClientInfo, expiresAt time.Time) (*models.Session, error) {
+	session := &models.Session{
+		UserID:     userID,
+		Device:     deviceLabel(client.UserAgent),
+		IPAddress:  client.IP,
+		UserAgent:  client.UserAgent,
+		LastSeenAt: time.Now(),
+		ExpiresAt:  expiresAt,
+	}
+	if err := s.sessionRepo.Create(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// IsActive reports whether the session exists, belongs to the user and has not been revoked
+func (s *SessionService) IsActive(sessionID, userID uuid.UUID) bool {
+	if sessionID == uuid.Nil {
+		return false
+	}
+	_, err := s.sessionRepo.FindActive(sessionID, userID)
+	return err == nil
+}
+
+func (s *SessionService) Touch(sessionID uuid.UUID, client ClientInfo, expiresAt time.Time) error {
+	return s.sessionRepo.Touch(sessionID, client.IP, client.UserAgent, expiresAt)
+}
+
+func (s *SessionService) GetActiveSessions(userID uuid.UUID) ([]models.Session, error) {
+	return s.sessionRepo.FindActiveByUserID(userID)
+}
+
+// RevokeSession logs out a single device
+func (s *SessionService) RevokeSession(userID, sessionID uuid.UUID) error {
+	revoked, err := s.sessionRepo.Revoke(sessionID, userID)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return ErrSessionNotFound
+	}
+	return s.refreshTokenRepo.RevokeFamily(sessionID)
+}
+
+// RevokeAllSessions logs out every device of the user
+func (s *SessionService) RevokeAllSessions(userID uuid.UUID) error {
+	return s.RevokeOtherSessions(userID, uuid.Nil)
+}
+
+// RevokeOtherSessions logs out every device except the current one
+func (s *SessionService) RevokeOtherSessions(userID, currentSessionID uuid.UUID) error {
+	if err := s.sessionRepo.RevokeAllByUserID(userID, currentSessionID); err != nil {
+		return err
+	}
+	return s.refreshTokenRepo.RevokeAllByUserID(userID, currentSessionID)
+}
+
+// deviceLabel builds a short label like "Chrome on Android" from a user agent
+func deviceLabel(userAgent string) string {
+	client := useragent.Parse(userAgent, "")
+	if client == (useragent.Client{}) {
+		return "Unknown device"
+	}
+
+	browser, os := client.Browser, client.OS
+	if browser == "" {
+		browser = "Browser"
+	}
+	if os == "" {
+		os = "Unknown OS"
+	}
+	return browser + " on " + os
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	iPhoneSafari  = "Mozilla/5.0 (iPhone; CPU iPhone OS 17_4 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.4 Mobile/15E148 Safari/604.1"
+	windowsChrome = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+)
+
+func (f *authFixture) login(t *testing.T, username, password, userAgent string) (*AuthResponse, uuid.UUID) {
+	t.Helper()
+	resp, err := f.service.Login(&LoginInput{Username: username, Password: password}, ClientInfo{IP: "198.51.100.4", UserAgent: userAgent})
+	require.NoError(t, err)
+	claims, err := f.service.ValidateToken(resp.Token)
+	require.NoError(t, err)
+	return resp, claims.SessionID
+}
+
+func TestSessionsAreListedAndRevokedPerDevice(t *testing.T) {
+	f := newAuthFixture(t)
+	owner := f.register(t, "mira", "correct-horse").User
+	other := f.register(t, "nadia", "battery-staple").User
+	phone, phoneSession := f.login(t, "mira", "correct-horse", iPhoneSafari)
+	desktop, desktopSession := f.login(t, "mira", "correct-horse", windowsChrome)
+
+	sessions, err := f.sessions.GetActiveSessions(owner.ID)
+	require.NoError(t, err)
+	devices := make(map[uuid.UUID]string, len(sessions))
+	for _, session := range sessions {
+		devices[session.ID] = session.Device
+	}
+	assert.Len(t, devices, 3) // Registering signed in too
+	assert.Equal(t, "Safari on iOS", devices[phoneSession])
+	assert.Equal(t, "Chrome on Windows", devices[desktopSession])
+
+	// Another user's session cannot be revoked
+	assert.ErrorIs(t, f.sessions.RevokeSession(other.ID, phoneSession), ErrSessionNotFound)
+
+	// A revoked device loses its access and refresh tokens
+	require.NoError(t, f.sessions.RevokeSession(owner.ID, phoneSession))
+	_, err = f.service.ValidateToken(phone.Token)
+	assert.Error(t, err)
+	_, err = f.service.Refresh(&RefreshInput{RefreshToken: phone.RefreshToken}, ClientInfo{})
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// Signing out the other devices keeps the current one
+	require.NoError(t, f.sessions.RevokeOtherSessions(owner.ID, desktopSession))
+	sessions, err = f.sessions.GetActiveSessions(owner.ID)
+	require.NoError(t, err)
+	require.Len(t, sessions, 1)
+	assert.Equal(t, desktopSession, sessions[0].ID)
+	_, err = f.service.Refresh(&RefreshInput{RefreshToken: desktop.RefreshToken}, ClientInfo{})
+	assert.NoError(t, err)
+}
+
+func TestDeviceLabel(t *testing.T) {
+	assert.Equal(t, "Safari on iOS", deviceLabel(iPhoneSafari))
+	assert.Equal(t, "Chrome on Windows", deviceLabel(windowsChrome))
+	assert.Equal(t, "Unknown device", deviceLabel(""))
+	assert.Equal(t, "Unknown device", deviceLabel("curl/8.5.0"))
+}
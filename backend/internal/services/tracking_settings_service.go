@@ -0,0 +1,151 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/ttlcache"
+)
+
+var (
+	ErrInvalidDedupKey       = errors.New("dedup_key must be visitor, visitor_ip or none")
+	ErrInvalidDedupWindow    = errors.New("dedup windows must be between 1 minute and 30 days")
+	ErrTrackingOwnerNotFound = errors.New("profile not found")
+)
+
+// Bounds for dedup windows set by creators
+const (
+	minDedupWindow = time.Minute
+	maxDedupWindow = 30 * 24 * time.Hour
+)
+
+// policyCacheTTL is how long a creator's tracking policy is served from memory.
+// Updates through this service take effect immediately on this instance.
+const policyCacheTTL = time.Minute
+
+// TrackingPolicy is how tracking events of one creator are recorded: which
+// repeats are ignored, and whether visitor IPs are kept (hashed)
+type TrackingPolicy struct {
+	DedupKey     string // models.DedupKey*
+	ClickWindow  time.Duration
+	ViewWindow   time.Duration
+	SocialWindow time.Duration
+	CaptureIP    bool
+}
+
+func trackingPolicyFrom(settings *models.TrackingSettings) TrackingPolicy {
+	return TrackingPolicy{
+		DedupKey:     settings.DedupKey,
+		ClickWindow:  time.Duration(settings.ClickDedupWindowSeconds) * time.Second,
+		ViewWindow:   time.Duration(settings.ViewDedupWindowSeconds) * time.Second,
+		SocialWindow: time.Duration(settings.SocialDedupWindowSeconds) * time.Second,
+		CaptureIP:    settings.CaptureIP,
+	}
+}
+
+type TrackingSettingsService struct {
+	settingsRepo *repository.TrackingSettingsRepository
+	policies     *ttlcache.Cache[uuid.UUID, TrackingPolicy]
+}
+
+func NewTrackingSettingsService(settingsRepo *repository.TrackingSettingsRepository) *TrackingSettingsService {
+	return &TrackingSettingsService{
+		settingsRepo: settingsRepo,
+		policies:     ttlcache.New[uuid.UUID, TrackingPolicy](policyCacheTTL),
+	}
+}
+
+type UpdateTrackingSettingsInput struct {
+	DedupKey                 string `json:"dedup_key"`
+	ClickDedupWindowSeconds  int    `json:"click_dedup_window_seconds"`
+	ViewDedupWindowSeconds   int    `json:"view_dedup_window_seconds"`
+	SocialDedupWindowSeconds int    `json:"social_dedup_window_seconds"`
+	CaptureIP                *bool  `json:"capture_ip"`
+}
+
+// GetSettings returns the creator's settings, or the defaults if they never changed them
+func (s *TrackingSettingsService) GetSettings(userID uuid.UUID) (*models.TrackingSettings, error) {
+	settings, err := s.settingsRepo.FindByUserID(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		return models.DefaultTrackingSettings(userID), nil
+	}
+	return settings, err
+}
+
+// UpdateSettings changes the fields set in input; zero values and a nil
+// CaptureIP keep the current value
+func (s *TrackingSettingsService) UpdateSettings(userID uuid.UUID, input *UpdateTrackingSettingsInput) (*models.TrackingSettings, error) {
+	settings, err := s.GetSettings(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if input.DedupKey != "" {
+		switch input.DedupKey {
+		case models.DedupKeyVisitor, models.DedupKeyVisitorIP, models.DedupKeyNone:
+			settings.DedupKey = input.DedupKey
+		default:
+			return nil, ErrInvalidDedupKey
+		}
+	}
+	windows := []struct {
+		input   int
+		setting *int
+	}{
+		{input.ClickDedupWindowSeconds, &settings.ClickDedupWindowSeconds},
+		{input.ViewDedupWindowSeconds, &settings.ViewDedupWindowSeconds},
+		{input.SocialDedupWindowSeconds, &settings.SocialDedupWindowSeconds},
+	}
+	for _, w := range windows {
+		if w.input == 0 {
+			continue
+		}
+		window := time.Duration(w.input) * time.Second
+		if window < minDedupWindow || window > maxDedupWindow {
+			return nil, ErrInvalidDedupWindow
+		}
+		*w.setting = w.input
+	}
+	if input.CaptureIP != nil {
+		settings.CaptureIP = *input.CaptureIP
+	}
+
+	if err := s.settingsRepo.Save(settings); err != nil {
+		return nil, err
+	}
+	s.policies.Delete(userID)
+	return settings, nil
+}
+
+// Policy returns the creator's tracking policy, cached in memory so tracking
+// does not read the settings table for every event. Accounts that do not exist
+// get ErrTrackingOwnerNotFound: their events would fail the batch they are
+// written with. If the settings cannot be read the defaults apply.
+func (s *TrackingSettingsService) Policy(userID uuid.UUID) (TrackingPolicy, error) {
+	if policy, ok := s.policies.Get(userID); ok {
+		return policy, nil
+	}
+
+	settings, err := s.settingsRepo.FindByUserID(userID)
+	if errors.Is(err, repository.ErrNotFound) {
+		// Settings are only stored once changed, so check the account itself
+		var exists bool
+		if exists, err = s.settingsRepo.OwnerExists(userID); err == nil && !exists {
+			return TrackingPolicy{}, ErrTrackingOwnerNotFound
+		}
+		settings = models.DefaultTrackingSettings(userID)
+	}
+	if err != nil {
+		log.Printf("⚠️  Failed to load tracking settings of %s, using defaults: %v", userID, err)
+		return trackingPolicyFrom(models.DefaultTrackingSettings(userID)), nil
+	}
+
+	policy := trackingPolicyFrom(settings)
+	s.policies.Set(userID, policy, policyCacheTTL)
+	return policy, nil
+}
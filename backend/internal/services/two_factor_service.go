@@ -0,0 +1,227 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/onedash/backend/internal/models"
+	"github.com/onedash/backend/internal/repository"
+	"github.com/onedash/backend/internal/totp"
+)
+
+const (
+	totpIssuer        = "OneDash"
+	totpSkew          = 1 // Accept codes one period before/after to tolerate clock drift
+	recoveryCodeCount = 10
+)
+
+var (
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled     = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotSetUp       = errors.New("start two-factor setup first")
+	ErrInvalidTwoFactorCode    = errors.New("invalid two-factor code")
+)
+
+type TwoFactorService struct {
+	userRepo         *repository.UserRepository
+	recoveryCodeRepo *repository.RecoveryCodeRepository
+}
+
+func NewTwoFactorService(userRepo *repository.UserRepository, recoveryCodeRepo *repository.RecoveryCodeRepository) *TwoFactorService {
+	return &TwoFactorService{
+		userRepo:         userRepo,
+		recoveryCodeRepo: recoveryCodeRepo,
+	}
+}
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"` // Render as QR code
+}
+
+type EnableTwoFactorInput struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type DisableTwoFactorInput struct {
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"` // TOTP or recovery code
+}
+
+// Setup generates a new secret for the user. 2FA is not enforced until Enable confirms a code.
+func (s *TwoFactorService) Setup(userID uuid.UUID) (*TwoFactorSetupResponse, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, errors.New("failed to generate secret")
+	}
+
+	user.TOTPSecret = secret
+	user.TOTPLastUsedStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return &TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: totp.ProvisioningURI(secret, totpIssuer, user.Email),
+	}, nil
+}
+
+// Enable turns on 2FA after the user proves their authenticator works, and returns
+// recovery codes. The codes are shown only once.
+func (s *TwoFactorService) Enable(userID uuid.UUID, input *EnableTwoFactorInput) ([]string, error) {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.TwoFactorEnabled {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+	if user.TOTPSecret == "" {
+		return nil, ErrTwoFactorNotSetUp
+	}
+
+	if err := s.verifyTOTP(user, input.Code); err != nil {
+		return nil, err
+	}
+
+	codes, err := s.regenerateRecoveryCodes(user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reload so the step advanced by verifyTOTP is not overwritten
+	user, err = s.userRepo.FindByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	user.TwoFactorEnabled = true
+	if err := s.userRepo.Update(user); err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// Disable turns off 2FA. Requires the password and a current TOTP or recovery code.
+func (s *TwoFactorService) Disable(userID uuid.UUID, input *DisableTwoFactorInput) error {
+	user, err := s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	if !user.TwoFactorEnabled {
+		return ErrTwoFactorNotEnabled
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(input.Password)); err != nil {
+		return errors.New("invalid password")
+	}
+	if err := s.VerifyCode(user, input.Code); err != nil {
+		return err
+	}
+
+	user, err = s.userRepo.FindByID(userID)
+	if err != nil {
+		return err
+	}
+	user.TwoFactorEnabled = false
+	user.TOTPSecret = ""
+	user.TOTPLastUsedStep = 0
+	if err := s.userRepo.Update(user); err != nil {
+		return err
+	}
+
+	return s.recoveryCodeRepo.DeleteByUserID(user.ID)
+}
+
+// VerifyCode accepts either a TOTP code or an unused recovery code
+func (s *TwoFactorService) VerifyCode(user *models.User, code string) error {
+	if len(normalizeRecoveryCode(code)) == recoveryCodeLength {
+		used, err := s.recoveryCodeRepo.Consume(user.ID, hashToken(normalizeRecoveryCode(code)))
+		if err != nil {
+			return err
+		}
+		if !used {
+			return ErrInvalidTwoFactorCode
+		}
+		return nil
+	}
+	return s.verifyTOTP(user, code)
+}
+
+// verifyTOTP validates the code and rejects reuse of a code that was already accepted
+func (s *TwoFactorService) verifyTOTP(user *models.User, code string) error {
+	step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew)
+	if !ok {
+		return ErrInvalidTwoFactorCode
+	}
+
+	advanced, err := s.userRepo.AdvanceTOTPStep(user.ID, step)
+	if err != nil {
+		return err
+	}
+	if !advanced {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) regenerateRecoveryCodes(userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodeCount)
+	hashes := make([]string, recoveryCodeCount)
+	for i := range codes {
+		code, err := generateRecoveryCode()
+		if err != nil {
+			return nil, errors.New("failed to generate recovery codes")
+		}
+		codes[i] = code[:recoveryCodeLength/2] + "-" + code[recoveryCodeLength/2:]
+		hashes[i] = hashToken(code)
+	}
+
+	if err := s.recoveryCodeRepo.Replace(userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Recovery codes are 10 characters from an alphabet without look-alike characters
+const (
+	recoveryCodeLength   = 10
+	recoveryCodeAlphabet = "abcdefghjkmnpqrstuvwxyz23456789"
+)
+
+func generateRecoveryCode() (string, error) {
+	// Reject bytes above the largest multiple of the alphabet size to avoid modulo bias
+	limit := byte(256 - 256%len(recoveryCodeAlphabet))
+	code := make([]byte, 0, recoveryCodeLength)
+	buf := make([]byte, 1)
+	for len(code) < recoveryCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", err
+		}
+		if buf[0] >= limit {
+			continue
+		}
+		code = append(code, recoveryCodeAlphabet[int(buf[0])%len(recoveryCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// normalizeRecoveryCode accepts codes typed with or without the dash, in any case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	return strings.NewReplacer("-", "", " ", "").Replace(code)
+}
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/onedash/backend/internal/totp"
+)
+
+func TestTwoFactorLoginNeedsACodeOnce(t *testing.T) {
+	f := newAuthFixture(t)
+	user := f.register(t, "oka", "correct-horse").User
+
+	setup, err := f.twoFactor.Setup(user.ID)
+	require.NoError(t, err)
+	step := totp.Step(time.Now())
+	code := func(step int64) string {
+		c, err := totp.CodeAt(setup.Secret, step)
+		require.NoError(t, err)
+		return c
+	}
+	recoveryCodes, err := f.twoFactor.Enable(user.ID, &EnableTwoFactorInput{Code: code(step)})
+	require.NoError(t, err)
+	require.Len(t, recoveryCodes, recoveryCodeCount)
+
+	challenge := func() string {
+		resp, err := f.service.Login(&LoginInput{Username: "oka", Password: "correct-horse"}, ClientInfo{})
+		require.NoError(t, err)
+		require.True(t, resp.TwoFactorRequired)
+		assert.Empty(t, resp.Token, "no session before the second factor")
+		return resp.ChallengeToken
+	}
+	verify := func(challenge, code string) (*AuthResponse, error) {
+		return f.service.VerifyTwoFactor(&VerifyTwoFactorInput{ChallengeToken: challenge, Code: code}, ClientInfo{})
+	}
+
+	// Wrong codes and forged challenges are rejected
+	_, err = verify(challenge(), "000000")
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	_, err = verify("not-a-challenge", code(step+1))
+	assert.Error(t, err)
+
+	// The code of the next period completes the login, but only once
+	resp, err := verify(challenge(), code(step+1))
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+	_, err = verify(challenge(), code(step+1))
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	// So does a recovery code, typed in any case and without the dash
+	recovery := recoveryCodes[0]
+	resp, err = verify(challenge(), " "+strings.ToUpper(recovery[:5]+recovery[6:])+" ")
+	require.NoError(t, err)
+	assert.NotEmpty(t, resp.Token)
+	_, err = verify(challenge(), recovery)
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+}
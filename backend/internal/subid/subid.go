@@ -0,0 +1,136 @@
+// Package subid tags outbound marketplace URLs with the sub-ID parameters of the
+// affiliate programs, naming the link and traffic source a visitor came from,
+// and reads those sub-IDs back from the order reports. The first sub-ID holds
+// the link ID without dashes and the second the source, so a conversion joins
+// the clicks it came from on link_id and source.
+package subid
+
+import (
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// Tag is what a tagged URL records about a click
+type Tag struct {
+	LinkID uuid.UUID
+	Source string
+}
+
+// maxSourceLength bounds the source sub-ID; marketplaces cap sub-IDs at 50 characters
+const maxSourceLength = 30
+
+// LinkValue is the sub-ID naming a link: its ID without dashes, which every
+// marketplace accepts
+func LinkValue(linkID uuid.UUID) string {
+	return strings.ReplaceAll(linkID.String(), "-", "")
+}
+
+// SourceValue reduces a traffic source to the characters every marketplace
+// accepts in a sub-ID (lower-case letters, digits and "_")
+func SourceValue(source string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(source) {
+		if b.Len() == maxSourceLength {
+			break
+		}
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '_' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// rewriter sets the sub-ID parameters of one marketplace on a query;
+// affiliateID is the creator's ID in its affiliate program and may be empty
+type rewriter func(query url.Values, affiliateID string, tag Tag)
+
+var rewriters = map[string]rewriter{
+	// Shopee Affiliate reads up to five sub-IDs from utm_content, separated by "-"
+	"shopee": func(query url.Values, affiliateID string, tag Tag) {
+		query.Set("utm_content", LinkValue(tag.LinkID)+"-"+SourceValue(tag.Source)+"---")
+		if affiliateID != "" {
+			if !strings.HasPrefix(affiliateID, "an_") {
+				affiliateID = "an_" + affiliateID
+			}
+			query.Set("utm_source", affiliateID)
+			query.Set("utm_medium", "affiliates")
+			query.Set("mmp_pid", affiliateID)
+		}
+	},
+	// Lazada Affiliate reports sub_id1 to sub_id6 and the sub-affiliate ID
+	"lazada": func(query url.Values, affiliateID string, tag Tag) {
+		query.Set("sub_id1", LinkValue(tag.LinkID))
+		query.Set("sub_id2", SourceValue(tag.Source))
+		if affiliateID != "" {
+			query.Set("sub_aff_id", affiliateID)
+		}
+	},
+	// Tokopedia and TikTok Shop affiliate reports carry the campaign parameters
+	"tokopedia":   utmRewriter,
+	"tiktok_shop": utmRewriter,
+}
+
+func utmRewriter(query url.Values, affiliateID string, tag Tag) {
+	query.Set("utm_campaign", LinkValue(tag.LinkID))
+	query.Set("utm_content", SourceValue(tag.Source))
+	query.Set("utm_medium", "affiliate")
+	if affiliateID != "" {
+		query.Set("utm_source", affiliateID)
+	}
+}
+
+// Supported reports whether URLs of platform can be tagged
+func Supported(platform string) bool {
+	_, ok := rewriters[platform]
+	return ok
+}
+
+// Platforms returns the marketplaces whose URLs can be tagged, sorted
+func Platforms() []string {
+	platforms := make([]string, 0, len(rewriters))
+	for platform := range rewriters {
+		platforms = append(platforms, platform)
+	}
+	sort.Strings(platforms)
+	return platforms
+}
+
+// Rewrite returns productURL with the sub-ID parameters of platform set for
+// tag. URLs of other platforms and unparseable URLs are returned unchanged.
+func Rewrite(platform, productURL, affiliateID string, tag Tag) string {
+	rewrite, ok := rewriters[platform]
+	if !ok {
+		return productURL
+	}
+	u, err := url.Parse(productURL)
+	if err != nil {
+		return productURL
+	}
+
+	query := u.Query()
+	rewrite(query, affiliateID, tag)
+	u.RawQuery = query.Encode()
+	return u.String()
+}
+
+// tagPattern finds a link ID (with or without dashes) followed by an optional
+// source inside the sub-IDs of a report, which may carry a prefix or other sub-IDs
+var tagPattern = regexp.MustCompile(`([0-9a-f]{8}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{4}-?[0-9a-f]{12})(?:-([a-z0-9_]+))?`)
+
+// Parse reads the tags in the sub-IDs of a report row ("-" separated), first
+// match first
+func Parse(subIDs string) []Tag {
+	var tags []Tag
+	for _, m := range tagPattern.FindAllStringSubmatch(strings.ToLower(subIDs), -1) {
+		id, err := uuid.Parse(m[1])
+		if err != nil {
+			continue
+		}
+		tags = append(tags, Tag{LinkID: id, Source: m[2]})
+	}
+	return tags
+}
@@ -0,0 +1,67 @@
+package subid
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTag = Tag{LinkID: uuid.MustParse("0b6c4a1e-3d5f-4c2a-9e8b-7f1d2c3b4a59"), Source: "IG Bio!"}
+
+const testLinkValue = "0b6c4a1e3d5f4c2a9e8b7f1d2c3b4a59"
+
+func TestRewrite(t *testing.T) {
+	tests := []struct {
+		name, platform, url, affiliateID string
+		want                             map[string]string
+	}{
+		{"shopee", "shopee", "https://shopee.co.id/Serum-i.1.2?sp_atk=x", "12345", map[string]string{
+			"sp_atk":      "x",
+			"utm_content": testLinkValue + "-igbio---",
+			"utm_source":  "an_12345",
+			"utm_medium":  "affiliates",
+			"mmp_pid":     "an_12345",
+		}},
+		{"shopee without affiliate ID", "shopee", "https://s.shopee.co.id/AbC", "", map[string]string{
+			"utm_content": testLinkValue + "-igbio---",
+		}},
+		{"lazada", "lazada", "https://www.lazada.co.id/products/x-i5.html?sub_id1=old", "creator", map[string]string{
+			"sub_id1":    testLinkValue,
+			"sub_id2":    "igbio",
+			"sub_aff_id": "creator",
+		}},
+		{"tiktok shop", "tiktok_shop", "https://shop-id.tokopedia.com/view/product/1", "", map[string]string{
+			"utm_campaign": testLinkValue,
+			"utm_content":  "igbio",
+			"utm_medium":   "affiliate",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(Rewrite(tt.platform, tt.url, tt.affiliateID, testTag))
+			require.NoError(t, err)
+			got := make(map[string]string)
+			for key := range u.Query() {
+				got[key] = u.Query().Get(key)
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	// Other platforms are left alone
+	assert.Equal(t, "https://example.com/a?b=c", Rewrite("generic", "https://example.com/a?b=c", "", testTag))
+}
+
+func TestParseReadsRewrittenSubIDs(t *testing.T) {
+	want := []Tag{{LinkID: testTag.LinkID, Source: "igbio"}}
+
+	// Shopee reports the five sub-IDs of utm_content in their own columns
+	assert.Equal(t, want, Parse(testLinkValue+"-igbio"))
+	// Prefixed and dashed link IDs are found too
+	assert.Equal(t, want, Parse("od-"+testTag.LinkID.String()+"-IGBIO"))
+	assert.Equal(t, []Tag{{LinkID: testTag.LinkID}}, Parse(testLinkValue))
+	assert.Empty(t, Parse("summer_sale"))
+}
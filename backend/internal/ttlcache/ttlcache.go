@@ -0,0 +1,119 @@
+// Package ttlcache is a small in-memory map whose entries expire.
+package ttlcache
+
+import (
+	"sync"
+	"time"
+)
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// Cache is safe for concurrent use. Expired entries are invisible immediately
+// and removed by a sweep that runs at most once per sweep interval.
+type Cache[K comparable, V any] struct {
+	mu         sync.Mutex
+	entries    map[K]entry[V]
+	now        func() time.Time
+	sweepEach  time.Duration
+	lastSweep  time.Time
+	maxEntries int // 0 for no limit
+}
+
+// New returns a cache that sweeps expired entries at most every sweepEach
+func New[K comparable, V any](sweepEach time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		entries:   make(map[K]entry[V]),
+		now:       time.Now,
+		sweepEach: sweepEach,
+	}
+}
+
+// NewBounded returns a cache like New that holds at most maxEntries entries.
+// While it is full new keys are not stored, so callers must cope with misses.
+func NewBounded[K comparable, V any](sweepEach time.Duration, maxEntries int) *Cache[K, V] {
+	c := New[K, V](sweepEach)
+	c.maxEntries = maxEntries
+	return c
+}
+
+// Get returns the value stored for key if it has not expired
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		var zero V
+		return zero, false
+	}
+	return e.value, true
+}
+
+// Set stores value for key for ttl
+func (c *Cache[K, V]) Set(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.sweep(now)
+	c.store(key, value, now.Add(ttl))
+}
+
+// Add stores value for key only if no unexpired entry exists, and reports
+// whether it did (a full bounded cache reports true without storing).
+// Checking and storing happen atomically.
+func (c *Cache[K, V]) Add(key K, value V, ttl time.Duration) bool {
+	return c.AddUnless(key, value, ttl, func(V) bool { return true })
+}
+
+// AddUnless is Add where an unexpired entry only blocks storing if keep
+// accepts its value
+func (c *Cache[K, V]) AddUnless(key K, value V, ttl time.Duration, keep func(V) bool) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	c.sweep(now)
+	if e, ok := c.entries[key]; ok && now.Before(e.expiresAt) && keep(e.value) {
+		return false
+	}
+	c.store(key, value, now.Add(ttl))
+	return true
+}
+
+// store sets key unless the cache is full and key is new
+func (c *Cache[K, V]) store(key K, value V, expiresAt time.Time) {
+	if _, ok := c.entries[key]; !ok && c.maxEntries > 0 && len(c.entries) >= c.maxEntries {
+		return
+	}
+	c.entries[key] = entry[V]{value: value, expiresAt: expiresAt}
+}
+
+// Delete removes key
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// Len returns the number of stored entries, including expired ones not yet swept
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.entries)
+}
+
+func (c *Cache[K, V]) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.sweepEach {
+		return
+	}
+	c.lastSweep = now
+	for key, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, key)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package ttlcache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEntriesExpire(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New[string, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	c.Set("a", 1, 10*time.Second)
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, 1, v)
+
+	now = now.Add(10 * time.Second)
+	_, ok = c.Get("a")
+	assert.False(t, ok)
+}
+
+func TestAddOnlyStoresMissingOrExpiredKeys(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New[string, struct{}](time.Minute)
+	c.now = func() time.Time { return now }
+
+	assert.True(t, c.Add("k", struct{}{}, time.Hour))
+	assert.False(t, c.Add("k", struct{}{}, time.Hour))
+
+	now = now.Add(time.Hour)
+	assert.True(t, c.Add("k", struct{}{}, time.Hour))
+
+	c.Delete("k")
+	assert.True(t, c.Add("k", struct{}{}, time.Hour))
+}
+
+func TestSweepRemovesExpiredEntries(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := New[int, int](time.Minute)
+	c.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		c.Set(i, i, time.Second)
+	}
+	assert.Equal(t, 10, c.Len())
+
+	now = now.Add(2 * time.Minute)
+	c.Set(100, 100, time.Hour)
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestAddUnlessReplacesRejectedEntries(t *testing.T) {
+	c := New[string, int](time.Minute)
+
+	assert.True(t, c.AddUnless("k", 1, time.Hour, func(int) bool { return true }))
+	assert.False(t, c.AddUnless("k", 2, time.Hour, func(v int) bool { return v == 1 }))
+	assert.True(t, c.AddUnless("k", 3, time.Hour, func(v int) bool { return v == 2 }))
+	v, _ := c.Get("k")
+	assert.Equal(t, 3, v)
+}
+
+func TestBoundedCacheDropsNewKeysWhenFull(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := NewBounded[int, int](time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.Set(1, 1, time.Hour)
+	assert.True(t, c.Add(2, 2, time.Second))
+	assert.True(t, c.Add(3, 3, time.Hour), "a full cache does not block")
+	_, ok := c.Get(3)
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+
+	// Existing keys are still updated, and expired ones make room
+	c.Set(1, 10, time.Hour)
+	v, _ := c.Get(1)
+	assert.Equal(t, 10, v)
+	now = now.Add(2 * time.Minute)
+	c.Set(3, 3, time.Hour)
+	_, ok = c.Get(3)
+	assert.True(t, ok)
+}
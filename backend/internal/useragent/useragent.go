@@ -0,0 +1,164 @@
+// Package useragent derives the device type, operating system and browser of
+// a visitor from the User-Agent header.
+//
+// In-app browsers matter more to creators than the engine behind them, so a
+// page opened inside Instagram, TikTok, WhatsApp and similar apps reports the
+// app as its browser. Android webviews of apps that do not mark their
+// User-Agent are recognised by the X-Requested-With header they send.
+package useragent
+
+import "strings"
+
+// Device types
+const (
+	DeviceMobile  = "mobile"
+	DeviceTablet  = "tablet"
+	DeviceDesktop = "desktop"
+)
+
+// BrowserWebView is reported for in-app browsers of apps we cannot name
+const BrowserWebView = "WebView"
+
+// Client is what a User-Agent tells about the visitor; unknown fields are empty
+type Client struct {
+	DeviceType string // Device*
+	OS         string
+	Browser    string // browser family, or the app of an in-app browser
+}
+
+// match pairs lower-case User-Agent fragments with the name they indicate
+type match struct {
+	fragments []string
+	name      string
+}
+
+// inAppBrowsers are checked in order; TikTok and Facebook tokens differ by app version
+var inAppBrowsers = []match{
+	{[]string{"instagram"}, "Instagram"},
+	{[]string{"musical_ly", "bytedancewebview", "trill_", "tiktok"}, "TikTok"},
+	{[]string{"whatsapp"}, "WhatsApp"},
+	{[]string{"fban/", "fbav/", "fb_iab", "fbios", "messengerforios"}, "Facebook"},
+	{[]string{" line/"}, "LINE"},
+	{[]string{"twitter"}, "X"},
+	{[]string{"telegram"}, "Telegram"},
+	{[]string{"snapchat"}, "Snapchat"},
+	{[]string{"pinterest"}, "Pinterest"},
+	{[]string{"linkedinapp"}, "LinkedIn"},
+}
+
+// androidApps maps the X-Requested-With package names of Android webviews to apps
+var androidApps = map[string]string{
+	"com.instagram.android":    "Instagram",
+	"com.zhiliaoapp.musically": "TikTok",
+	"com.ss.android.ugc.trill": "TikTok",
+	"com.ss.android.ugc.aweme": "TikTok",
+	"com.whatsapp":             "WhatsApp",
+	"com.whatsapp.w4b":         "WhatsApp",
+	"com.facebook.katana":      "Facebook",
+	"com.facebook.orca":        "Facebook",
+	"jp.naver.line.android":    "LINE",
+	"com.twitter.android":      "X",
+	"org.telegram.messenger":   "Telegram",
+	"com.snapchat.android":     "Snapchat",
+	"com.pinterest":            "Pinterest",
+	"com.linkedin.android":     "LinkedIn",
+}
+
+// browsers are checked in order: most User-Agents also name the engines they derive from
+var browsers = []match{
+	{[]string{"edg/", "edga/", "edgios/"}, "Edge"},
+	{[]string{"opr/", "opera", "opt/"}, "Opera"},
+	{[]string{"samsungbrowser"}, "Samsung Internet"},
+	{[]string{"ucbrowser"}, "UC Browser"},
+	{[]string{"miuibrowser"}, "MIUI Browser"},
+	{[]string{"yabrowser"}, "Yandex"},
+	{[]string{"firefox", "fxios"}, "Firefox"},
+	{[]string{"crios", "chrome", "chromium"}, "Chrome"},
+	{[]string{"safari"}, "Safari"},
+}
+
+// operatingSystems are checked in order: iOS claims to be "like Mac OS X",
+// HarmonyOS and ChromeOS mention Android or Linux
+var operatingSystems = []match{
+	{[]string{"iphone", "ipad", "ipod"}, "iOS"},
+	{[]string{"harmonyos"}, "HarmonyOS"},
+	{[]string{"android"}, "Android"},
+	{[]string{"windows"}, "Windows"},
+	{[]string{"cros"}, "ChromeOS"},
+	{[]string{"macintosh", "mac os x"}, "macOS"},
+	{[]string{"linux", "x11"}, "Linux"},
+}
+
+func (m match) in(ua string) bool {
+	for _, fragment := range m.fragments {
+		if strings.Contains(ua, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+func find(matches []match, ua string) string {
+	for _, m := range matches {
+		if m.in(ua) {
+			return m.name
+		}
+	}
+	return ""
+}
+
+// Parse classifies a visitor from its User-Agent and X-Requested-With headers;
+// requestedWith may be empty (it is not stored with events)
+func Parse(userAgent, requestedWith string) Client {
+	ua := strings.ToLower(userAgent)
+	if strings.TrimSpace(ua) == "" {
+		return Client{}
+	}
+
+	client := Client{OS: find(operatingSystems, ua)}
+	client.DeviceType = deviceType(ua, client.OS)
+
+	if app := find(inAppBrowsers, ua); app != "" {
+		client.Browser = app
+		return client
+	}
+	if app, ok := androidApps[strings.ToLower(strings.TrimSpace(requestedWith))]; ok {
+		client.Browser = app
+		return client
+	}
+	if isWebView(ua, client.OS) {
+		client.Browser = BrowserWebView
+		return client
+	}
+	client.Browser = find(browsers, ua)
+	return client
+}
+
+func deviceType(ua, os string) string {
+	switch {
+	case strings.Contains(ua, "ipad"), strings.Contains(ua, "tablet"),
+		strings.Contains(ua, "kindle"), strings.Contains(ua, "silk/"):
+		return DeviceTablet
+	case strings.Contains(ua, "mobi"), strings.Contains(ua, "iphone"), strings.Contains(ua, "ipod"),
+		strings.Contains(ua, "windows phone"), strings.Contains(ua, "opera mini"):
+		return DeviceMobile
+	case os == "Android", os == "HarmonyOS":
+		// Android tablets leave "Mobile" out of their User-Agent
+		return DeviceTablet
+	case os == "Windows", os == "macOS", os == "Linux", os == "ChromeOS":
+		return DeviceDesktop
+	}
+	return ""
+}
+
+// isWebView recognises embedded browsers: Android marks them with "; wv)",
+// iOS webviews lack the "Safari/" token every iOS browser sends
+func isWebView(ua, os string) bool {
+	switch os {
+	case "Android":
+		return strings.Contains(ua, "; wv)")
+	case "iOS":
+		return strings.Contains(ua, "applewebkit") && !strings.Contains(ua, "safari/")
+	}
+	return false
+}
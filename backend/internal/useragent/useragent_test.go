@@ -0,0 +1,79 @@
+package useragent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		ua            string
+		requestedWith string
+		want          Client
+	}{
+		{"chrome android",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "",
+			Client{DeviceMobile, "Android", "Chrome"}},
+		{"safari iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Mobile/15E148 Safari/604.1", "",
+			Client{DeviceMobile, "iOS", "Safari"}},
+		{"chrome iphone",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) CriOS/126.0.6478.54 Mobile/15E148 Safari/604.1", "",
+			Client{DeviceMobile, "iOS", "Chrome"}},
+		{"samsung internet",
+			"Mozilla/5.0 (Linux; Android 13; SM-S911B) AppleWebKit/537.36 (KHTML, like Gecko) SamsungBrowser/24.0 Chrome/117.0.0.0 Mobile Safari/537.36", "",
+			Client{DeviceMobile, "Android", "Samsung Internet"}},
+		{"android tablet",
+			"Mozilla/5.0 (Linux; Android 13; SM-X200) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36", "",
+			Client{DeviceTablet, "Android", "Chrome"}},
+		{"ipad",
+			"Mozilla/5.0 (iPad; CPU OS 16_6 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.6 Mobile/15E148 Safari/604.1", "",
+			Client{DeviceTablet, "iOS", "Safari"}},
+		{"edge windows",
+			"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Safari/537.36 Edg/126.0.2592.68", "",
+			Client{DeviceDesktop, "Windows", "Edge"}},
+		{"safari mac",
+			"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.5 Safari/605.1.15", "",
+			Client{DeviceDesktop, "macOS", "Safari"}},
+		{"firefox linux",
+			"Mozilla/5.0 (X11; Linux x86_64; rv:127.0) Gecko/20100101 Firefox/127.0", "",
+			Client{DeviceDesktop, "Linux", "Firefox"}},
+		{"instagram ios",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 Instagram 335.0.3.17.108 (iPhone15,2; iOS 17_5; id_ID; id; scale=3.00; 1179x2556; 614416391)", "",
+			Client{DeviceMobile, "iOS", "Instagram"}},
+		{"instagram android",
+			"Mozilla/5.0 (Linux; Android 13; SM-A145F Build/TP1A.220624.014; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/120.0.6099.230 Mobile Safari/537.36 Instagram 312.1.0.34.111 Android", "",
+			Client{DeviceMobile, "Android", "Instagram"}},
+		{"tiktok android",
+			"Mozilla/5.0 (Linux; Android 13; SM-A145F) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0 Mobile Safari/537.36 musical_ly_2023405030 BytedanceWebview/d8a21c6", "",
+			Client{DeviceMobile, "Android", "TikTok"}},
+		{"tiktok ios",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 musical_ly_34.5.0 JsSdk/2.0 NetType/WIFI Channel/App Store ByteLocale/id Region/ID", "",
+			Client{DeviceMobile, "iOS", "TikTok"}},
+		{"facebook ios",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148 [FBAN/FBIOS;FBAV/470.0.0.38.108;FBBV/606279624;FBDV/iPhone15,2]", "",
+			Client{DeviceMobile, "iOS", "Facebook"}},
+		{"whatsapp android webview",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP2A.240605.024; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/126.0.6478.71 Mobile Safari/537.36", "com.whatsapp",
+			Client{DeviceMobile, "Android", "WhatsApp"}},
+		{"unknown android webview",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8 Build/AP2A.240605.024; wv) AppleWebKit/537.36 (KHTML, like Gecko) Version/4.0 Chrome/126.0.6478.71 Mobile Safari/537.36", "",
+			Client{DeviceMobile, "Android", BrowserWebView}},
+		{"xhr is not an app",
+			"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/126.0.0.0 Mobile Safari/537.36", "XMLHttpRequest",
+			Client{DeviceMobile, "Android", "Chrome"}},
+		{"unknown ios webview",
+			"Mozilla/5.0 (iPhone; CPU iPhone OS 17_5 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Mobile/15E148", "",
+			Client{DeviceMobile, "iOS", BrowserWebView}},
+		{"empty", "", "com.whatsapp", Client{}},
+		{"library", "curl/8.4.0", "", Client{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Parse(tt.ua, tt.requestedWith))
+		})
+	}
+}